@@ -6,6 +6,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/aws"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/testhelpers"
 
@@ -28,7 +29,7 @@ func NewAWSLBHelper(c acceptance.Config) awsLBHelper {
 
 	logger := application.NewLogger(os.Stdout, os.Stdin)
 
-	client := aws.NewClient(creds, logger)
+	client := aws.NewClient(creds, helpers.HTTPSettings{}, logger)
 
 	elbConfig := &awslib.Config{
 		Credentials: credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, ""),
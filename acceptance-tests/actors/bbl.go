@@ -141,7 +141,7 @@ func (b BBL) DirectorUsername() string {
 }
 
 func (b BBL) DirectorPassword() string {
-	return b.fetchValue("director-password")
+	return b.fetchValue("--no-confirm", "director-password")
 }
 
 func (b BBL) DirectorAddress() string {
@@ -233,19 +233,18 @@ func getExport(keyName string, lines []string) string {
 	return ""
 }
 
-func (b BBL) fetchValue(value string) string {
+func (b BBL) fetchValue(value ...string) string {
 	if b.useBBLStateBucket {
-		return b.fetchValueFromRemoteBBLState(value)
+		return b.fetchValueFromRemoteBBLState(value...)
 	}
-	return b.fetchValueFromLocalBBLState(value)
+	return b.fetchValueFromLocalBBLState(value...)
 }
 
-func (b BBL) fetchValueFromRemoteBBLState(value string) string {
-	args := []string{
+func (b BBL) fetchValueFromRemoteBBLState(value ...string) string {
+	args := append([]string{
 		"--name", b.envID,
 		"--state-bucket", b.configuration.BBLStateBucket,
-		value,
-	}
+	}, value...)
 
 	stdout := bytes.NewBuffer([]byte{})
 	stderr := bytes.NewBuffer([]byte{})
@@ -254,11 +253,10 @@ func (b BBL) fetchValueFromRemoteBBLState(value string) string {
 
 	return strings.TrimSpace(string(stdout.Bytes()))
 }
-func (b BBL) fetchValueFromLocalBBLState(value string) string {
-	args := []string{
+func (b BBL) fetchValueFromLocalBBLState(value ...string) string {
+	args := append([]string{
 		"--state-dir", b.stateDirectory,
-		value,
-	}
+	}, value...)
 
 	stdout := bytes.NewBuffer([]byte{})
 	stderr := bytes.NewBuffer([]byte{})
@@ -84,5 +84,9 @@ func (a App) execute() error {
 		return err
 	}
 
+	if a.configuration.Global.DryRun {
+		return nil
+	}
+
 	return command.Execute(a.configuration.SubcommandFlags, a.configuration.State)
 }
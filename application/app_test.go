@@ -72,6 +72,22 @@ var _ = Describe("App", func() {
 					"--second-subcommand-flag", "second-value",
 				}))
 			})
+
+			Context("when it is a dry run", func() {
+				It("only runs the checks of the command", func() {
+					app = NewAppWithConfiguration(application.Configuration{
+						Command: "some",
+						Global: application.GlobalConfiguration{
+							DryRun: true,
+						},
+					})
+
+					Expect(app.Run()).To(Succeed())
+
+					Expect(someCmd.CheckFastFailsCall.CallCount).To(Equal(1))
+					Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+				})
+			})
 		})
 
 		Context("when name is passed as a global flag", func() {
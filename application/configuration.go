@@ -3,9 +3,12 @@ package application
 import "github.com/cloudfoundry/bosh-bootloader/storage"
 
 type GlobalConfiguration struct {
-	StateDir string
-	Debug    bool
-	Name     string
+	StateDir    string
+	Debug       bool
+	Name        string
+	TFWorkspace string
+	// DryRun only runs the checks of a command, without making changes.
+	DryRun bool
 }
 
 type StringSlice []string
@@ -28,4 +31,8 @@ type Configuration struct {
 	State                storage.State
 	ShowCommandHelp      bool
 	CommandModifiesState bool
+	// StoredIAAS is the iaas in the state file when --iaas overrode it for
+	// destroy, and IAASOverridden whether it did.
+	StoredIAAS     string
+	IAASOverridden bool
 }
@@ -1,16 +1,35 @@
 package application
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
+	"time"
 )
 
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
 type Logger struct {
 	newline   bool
 	writer    io.Writer
 	reader    io.Reader
 	noConfirm bool
+	noTTY     bool
+
+	json         bool
+	envID        string
+	promptWriter io.Writer
+}
+
+type jsonLine struct {
+	Time    string `json:"time"`
+	Level   string `json:"level"`
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message,omitempty"`
+	EnvID   string `json:"env_id,omitempty"`
 }
 
 func NewLogger(writer io.Writer, reader io.Reader) *Logger {
@@ -31,38 +50,125 @@ func (l *Logger) clear() {
 	l.newline = true
 }
 
+// plain strips ANSI control sequences when there is no terminal to render
+// them.
+func (l *Logger) plain(message string) string {
+	if !l.noTTY {
+		return message
+	}
+
+	return ansiSequence.ReplaceAllString(message, "")
+}
+
+// writeJSON writes line as a single json object, without ANSI sequences.
+func (l *Logger) writeJSON(line jsonLine) {
+	line.Time = time.Now().UTC().Format(time.RFC3339)
+	line.Level = "info"
+	line.Step = ansiSequence.ReplaceAllString(line.Step, "")
+	line.Message = ansiSequence.ReplaceAllString(line.Message, "")
+	line.EnvID = l.envID
+
+	contents, _ := json.Marshal(line) // strings always marshal
+	l.writer.Write(append(contents, '\n'))
+}
+
 func (l *Logger) Step(message string, a ...interface{}) {
+	if l.json {
+		l.writeJSON(jsonLine{Step: fmt.Sprintf(message, a...)})
+		return
+	}
+
 	l.clear()
-	fmt.Fprintf(l.writer, "step: %s\n", fmt.Sprintf(message, a...))
+	step := l.plain(fmt.Sprintf("step: %s", fmt.Sprintf(message, a...)))
+	if l.noTTY {
+		// Only progress lines are timestamped so that command output such
+		// as print-env stays usable in scripts.
+		step = fmt.Sprintf("%s %s", time.Now().UTC().Format(time.RFC3339), step)
+	}
+	fmt.Fprintf(l.writer, "%s\n", step)
 	l.newline = true
 }
 
 func (l *Logger) Dot() {
+	if l.noTTY || l.json {
+		return
+	}
+
 	l.writer.Write([]byte("\u2022"))
 	l.newline = false
 }
 
 func (l *Logger) Printf(message string, a ...interface{}) {
+	if l.json {
+		if message := strings.TrimRight(fmt.Sprintf(message, a...), "\n"); message != "" {
+			l.writeJSON(jsonLine{Message: message})
+		}
+		return
+	}
+
 	l.clear()
-	fmt.Fprintf(l.writer, "%s", fmt.Sprintf(message, a...))
+	fmt.Fprintf(l.writer, "%s", l.plain(fmt.Sprintf(message, a...)))
 }
 
 func (l *Logger) Println(message string) {
+	if l.json {
+		l.writeJSON(jsonLine{Message: message})
+		return
+	}
+
 	l.clear()
-	fmt.Fprintf(l.writer, "%s\n", message)
+	fmt.Fprintf(l.writer, "%s\n", l.plain(message))
 }
 
 func (l *Logger) NoConfirm() {
 	l.noConfirm = true
 }
 
+// NoTTY switches the logger to plain, timestamped output for environments
+// such as CI where stdout is not a terminal.
+func (l *Logger) NoTTY() {
+	l.noTTY = true
+}
+
+// JSON switches the logger to one json object per step or message for log
+// aggregation. Prompts are written to promptWriter instead, so that they
+// still reach the operator without breaking up the json.
+func (l *Logger) JSON(promptWriter io.Writer) {
+	l.json = true
+	l.promptWriter = promptWriter
+}
+
+// SetEnvID adds the env id to every json line.
+func (l *Logger) SetEnvID(envID string) {
+	l.envID = envID
+}
+
+// prompter is where prompts are written.
+func (l *Logger) prompter() io.Writer {
+	if l.json {
+		return l.promptWriter
+	}
+
+	l.clear()
+	return l.writer
+}
+
+// CanPrompt returns an error when a confirmation prompt would have no
+// terminal to answer it.
+func (l *Logger) CanPrompt() error {
+	if l.noTTY && !l.noConfirm {
+		return errors.New("Cannot ask for confirmation without a terminal. Run with --no-confirm, or --force-tty to prompt anyway.")
+	}
+
+	return nil
+}
+
 func (l *Logger) Prompt(message string) bool {
 	if l.noConfirm {
 		return true
 	}
 
-	l.clear()
-	fmt.Fprintf(l.writer, "%s (y/N): ", message)
+	fmt.Fprintf(l.prompter(), "%s (y/N): ", message)
 	l.newline = true
 
 	var proceed string
@@ -75,6 +181,22 @@ func (l *Logger) Prompt(message string) bool {
 	return false
 }
 
+// PromptForName asks for name to be typed back, a stronger confirmation than
+// y/N. Like Prompt, it passes without asking after NoConfirm.
+func (l *Logger) PromptForName(message, name string) bool {
+	if l.noConfirm {
+		return true
+	}
+
+	fmt.Fprintf(l.prompter(), "%s Type %q to confirm: ", message, name)
+	l.newline = true
+
+	var answer string
+	fmt.Fscanln(l.reader, &answer)
+
+	return answer == name
+}
+
 func (l *Logger) PromptWithDetails(resourceType, resourceName string) bool {
 	return l.Prompt(fmt.Sprintf("[%s: %s] Delete?", resourceType, resourceName))
 }
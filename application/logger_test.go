@@ -3,7 +3,9 @@ package application_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"math/rand"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 
@@ -59,6 +61,74 @@ var _ = Describe("Logger", func() {
 		})
 	})
 
+	Describe("NoTTY", func() {
+		BeforeEach(func() {
+			logger.NoTTY()
+		})
+
+		It("prints timestamped steps without ANSI sequences", func() {
+			logger.Step("destroying %s", "\x1b[1mterraform\x1b[0m")
+			logger.Println("\x1b[31mdone\x1b[0m")
+
+			lines := strings.Split(strings.TrimSpace(writer.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(MatchRegexp(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z step: destroying terraform$`))
+			Expect(lines[1]).To(Equal("done"))
+		})
+
+		It("does not print dots", func() {
+			logger.Dot()
+			logger.Dot()
+
+			Expect(writer.String()).To(Equal(""))
+		})
+
+		It("cannot prompt", func() {
+			Expect(logger.CanPrompt()).To(MatchError("Cannot ask for confirmation without a terminal. Run with --no-confirm, or --force-tty to prompt anyway."))
+		})
+
+		Context("when NoConfirm has been called", func() {
+			It("can prompt", func() {
+				logger.NoConfirm()
+
+				Expect(logger.CanPrompt()).To(Succeed())
+				Expect(logger.Prompt("do you like cheese?")).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("JSON", func() {
+		var promptWriter *bytes.Buffer
+
+		BeforeEach(func() {
+			promptWriter = bytes.NewBuffer([]byte{})
+			logger.JSON(promptWriter)
+			logger.SetEnvID("some-lake")
+		})
+
+		It("writes each step and message as a json object", func() {
+			logger.Step("destroying %s", "\x1b[1mbosh director\x1b[0m")
+			logger.Dot()
+			logger.Println("done")
+			logger.Printf("Warning: %s\n", "something")
+
+			lines := strings.Split(strings.TrimSpace(writer.String()), "\n")
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(MatchRegexp(`^\{"time":"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z","level":"info","step":"destroying bosh director","env_id":"some-lake"\}$`))
+			Expect(lines[1]).To(MatchRegexp(`^\{"time":"[^"]+","level":"info","message":"done","env_id":"some-lake"\}$`))
+			Expect(lines[2]).To(MatchRegexp(`^\{"time":"[^"]+","level":"info","message":"Warning: something","env_id":"some-lake"\}$`))
+		})
+
+		It("writes prompts to the prompt writer", func() {
+			reader.WriteString("yes\n")
+
+			Expect(logger.Prompt("do you like cheese?")).To(BeTrue())
+
+			Expect(promptWriter.String()).To(Equal("do you like cheese? (y/N): "))
+			Expect(writer.String()).To(Equal(""))
+		})
+	})
+
 	Describe("Prompt", func() {
 		Context("when NoConfirm has been called", func() {
 			BeforeEach(func() {
@@ -95,6 +165,55 @@ var _ = Describe("Logger", func() {
 			Entry("responding with 'No'", "No", false),
 			Entry("responding with 'N'", "N", false),
 		)
+
+		Context("when the response is not terminated by a newline", func() {
+			It("evaluates the response once stdin is closed", func() {
+				pipeReader, pipeWriter := io.Pipe()
+				logger = application.NewLogger(writer, pipeReader)
+
+				go func() {
+					defer GinkgoRecover()
+					_, err := pipeWriter.Write([]byte("yes"))
+					Expect(err).NotTo(HaveOccurred())
+					pipeWriter.Close()
+				}()
+
+				proceed := make(chan bool)
+				go func() {
+					proceed <- logger.Prompt("Do you like bananas?")
+				}()
+
+				Eventually(proceed).Should(Receive(BeTrue()))
+			})
+
+			It("does not proceed when stdin is closed without a response", func() {
+				Expect(logger.Prompt("Do you like bananas?")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("PromptForName", func() {
+		It("proceeds when the name is typed back", func() {
+			fmt.Fprintf(reader, "some-env\n")
+
+			Expect(logger.PromptForName("Delete it?", "some-env")).To(BeTrue())
+			Expect(writer.String()).To(Equal(`Delete it? Type "some-env" to confirm: `))
+		})
+
+		It("does not proceed on anything else", func() {
+			fmt.Fprintf(reader, "y\n")
+
+			Expect(logger.PromptForName("Delete it?", "some-env")).To(BeFalse())
+		})
+
+		Context("when NoConfirm has been called", func() {
+			It("doesn't prompt", func() {
+				logger.NoConfirm()
+
+				Expect(logger.PromptForName("Delete it?", "some-env")).To(BeTrue())
+				Expect(writer.String()).To(Equal(""))
+			})
+		})
 	})
 
 	Describe("mixing steps, dots and printlns", func() {
@@ -0,0 +1,108 @@
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	StatusApproved = "approved"
+	StatusDenied   = "denied"
+)
+
+// Service talks to a change-approval system that takes a request to destroy
+// an environment and later approves or denies it.
+type Service struct {
+	httpClient *http.Client
+	operator   string
+}
+
+type approvalRequest struct {
+	EnvID    string `json:"env_id"`
+	Operator string `json:"operator"`
+}
+
+type approvalResponse struct {
+	URL    string `json:"url"`
+	Status string `json:"status"`
+}
+
+func NewService(httpClient *http.Client, operator string) Service {
+	return Service{
+		httpClient: httpClient,
+		operator:   operator,
+	}
+}
+
+// Request posts an approval request for the environment and returns the
+// url to poll for its status.
+func (s Service) Request(approvalURL, envID string) (string, error) {
+	body, err := json.Marshal(approvalRequest{EnvID: envID, Operator: s.operator})
+	if err != nil {
+		return "", err // not tested
+	}
+
+	response, err := s.do("POST", approvalURL, body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.URL == "" {
+		return "", errors.New("Approval service did not return a request url")
+	}
+
+	base, err := url.Parse(approvalURL)
+	if err != nil {
+		return "", fmt.Errorf("Parse approval url: %s", err) // not tested
+	}
+	requestURL, err := base.Parse(response.URL)
+	if err != nil {
+		return "", fmt.Errorf("Parse approval request url: %s", err)
+	}
+
+	return requestURL.String(), nil
+}
+
+// Status returns the current status of the approval request, such as
+// "pending", "approved" or "denied".
+func (s Service) Status(requestURL string) (string, error) {
+	response, err := s.do("GET", requestURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(response.Status), nil
+}
+
+func (s Service) do(method, requestURL string, body []byte) (approvalResponse, error) {
+	request, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return approvalResponse{}, fmt.Errorf("Create approval request: %s", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return approvalResponse{}, fmt.Errorf("Contact approval service: %s", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return approvalResponse{}, fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var decoded approvalResponse
+	err = json.NewDecoder(response.Body).Decode(&decoded)
+	if err != nil {
+		return approvalResponse{}, fmt.Errorf("Decode approval response: %s", err)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,98 @@
+package approval_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/bosh-bootloader/approval"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service", func() {
+	var (
+		server   *httptest.Server
+		status   int
+		response string
+		method   string
+		path     string
+		body     string
+
+		service approval.Service
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			path = r.URL.Path
+			contents, _ := ioutil.ReadAll(r.Body)
+			body = string(contents)
+			w.WriteHeader(status)
+			fmt.Fprint(w, response)
+		}))
+
+		service = approval.NewService(http.DefaultClient, "some-operator")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Request", func() {
+		It("posts the env id and operator and returns the request url", func() {
+			response = `{"url": "/requests/123"}`
+
+			requestURL, err := service.Request(server.URL+"/approvals", "some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(method).To(Equal("POST"))
+			Expect(path).To(Equal("/approvals"))
+			Expect(body).To(MatchJSON(`{"env_id": "some-env-id", "operator": "some-operator"}`))
+			Expect(requestURL).To(Equal(server.URL + "/requests/123"))
+		})
+
+		Context("when the service does not return a request url", func() {
+			It("returns an error", func() {
+				response = `{}`
+
+				_, err := service.Request(server.URL, "some-env-id")
+				Expect(err).To(MatchError("Approval service did not return a request url"))
+			})
+		})
+
+		Context("when the service responds with an error", func() {
+			It("returns an error", func() {
+				status = http.StatusForbidden
+
+				_, err := service.Request(server.URL, "some-env-id")
+				Expect(err).To(MatchError("unexpected http response 403 Forbidden"))
+			})
+		})
+	})
+
+	Describe("Status", func() {
+		It("returns the status of the request", func() {
+			response = `{"status": "Approved"}`
+
+			status, err := service.Status(server.URL + "/requests/123")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(method).To(Equal("GET"))
+			Expect(path).To(Equal("/requests/123"))
+			Expect(status).To(Equal(approval.StatusApproved))
+		})
+
+		Context("when the response is not json", func() {
+			It("returns an error", func() {
+				response = "%%%"
+
+				_, err := service.Status(server.URL)
+				Expect(err).To(MatchError(ContainSubstring("Decode approval response: ")))
+			})
+		})
+	})
+})
@@ -1,16 +1,23 @@
 package aws
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
+	"sync"
 
 	awslib "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
 	awsroute53 "github.com/aws/aws-sdk-go/service/route53"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
@@ -18,10 +25,31 @@ type EC2Client interface {
 	DescribeAvailabilityZones(*awsec2.DescribeAvailabilityZonesInput) (*awsec2.DescribeAvailabilityZonesOutput, error)
 	DescribeInstances(*awsec2.DescribeInstancesInput) (*awsec2.DescribeInstancesOutput, error)
 	DescribeVpcs(*awsec2.DescribeVpcsInput) (*awsec2.DescribeVpcsOutput, error)
+	DescribeRegions(*awsec2.DescribeRegionsInput) (*awsec2.DescribeRegionsOutput, error)
+	DescribeVpcPeeringConnections(*awsec2.DescribeVpcPeeringConnectionsInput) (*awsec2.DescribeVpcPeeringConnectionsOutput, error)
+	DeleteVpcPeeringConnection(*awsec2.DeleteVpcPeeringConnectionInput) (*awsec2.DeleteVpcPeeringConnectionOutput, error)
+	DescribeImages(*awsec2.DescribeImagesInput) (*awsec2.DescribeImagesOutput, error)
+	DeregisterImage(*awsec2.DeregisterImageInput) (*awsec2.DeregisterImageOutput, error)
+	DeleteSnapshot(*awsec2.DeleteSnapshotInput) (*awsec2.DeleteSnapshotOutput, error)
 }
 
 type Route53Client interface {
 	ListHostedZonesByName(*awsroute53.ListHostedZonesByNameInput) (*awsroute53.ListHostedZonesByNameOutput, error)
+	ListHostedZones(*awsroute53.ListHostedZonesInput) (*awsroute53.ListHostedZonesOutput, error)
+	ListResourceRecordSets(*awsroute53.ListResourceRecordSetsInput) (*awsroute53.ListResourceRecordSetsOutput, error)
+}
+
+type KMSClient interface {
+	ListKeys(*awskms.ListKeysInput) (*awskms.ListKeysOutput, error)
+	ListResourceTags(*awskms.ListResourceTagsInput) (*awskms.ListResourceTagsOutput, error)
+	DescribeKey(*awskms.DescribeKeyInput) (*awskms.DescribeKeyOutput, error)
+	ListAliases(*awskms.ListAliasesInput) (*awskms.ListAliasesOutput, error)
+	DeleteAlias(*awskms.DeleteAliasInput) (*awskms.DeleteAliasOutput, error)
+	ScheduleKeyDeletion(*awskms.ScheduleKeyDeletionInput) (*awskms.ScheduleKeyDeletionOutput, error)
+}
+
+type S3Client interface {
+	PutObject(*awss3.PutObjectInput) (*awss3.PutObjectOutput, error)
 }
 
 type logger interface {
@@ -39,22 +67,97 @@ type DNSZones interface {
 type Client struct {
 	ec2Client     EC2Client
 	route53Client Route53Client
+	s3Client      S3Client
+	kmsClient     KMSClient
+	credentials   *refreshableCredentials
 	logger        logger
 }
 
-func NewClient(creds storage.AWS, logger logger) Client {
+// refreshableCredentials hands the sdk clients the credentials bbl loaded
+// last, so that credentials refreshed during a long destroy reach the
+// clients built when bbl started.
+type refreshableCredentials struct {
+	mutex   sync.Mutex
+	creds   storage.AWS
+	changed bool
+}
+
+func (r *refreshableCredentials) Retrieve() (credentials.Value, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.changed = false
+	return credentials.Value{
+		AccessKeyID:     r.creds.AccessKeyID,
+		SecretAccessKey: r.creds.SecretAccessKey,
+		SessionToken:    r.creds.SessionToken,
+		ProviderName:    "bbl",
+	}, nil
+}
+
+func (r *refreshableCredentials) IsExpired() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.changed
+}
+
+func (r *refreshableCredentials) get() storage.AWS {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.creds
+}
+
+func (r *refreshableCredentials) set(creds storage.AWS) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.creds = creds
+	r.changed = true
+}
+
+func NewClient(creds storage.AWS, httpSettings helpers.HTTPSettings, logger logger) Client {
+	refreshable := &refreshableCredentials{creds: creds}
 	config := &awslib.Config{
-		Credentials: credentials.NewStaticCredentials(creds.AccessKeyID, creds.SecretAccessKey, ""),
+		Credentials: credentials.NewCredentials(refreshable),
 		Region:      awslib.String(creds.Region),
 	}
 
+	// The aws sdk retries on its own, so only the timeout, the recording and
+	// the trusted CAs are applied to its http client. It gets a client of its
+	// own, since bbl wraps http.DefaultClient with all of the settings.
+	config.HTTPClient = helpers.HTTPSettings{
+		Timeout:      httpSettings.Timeout,
+		Interactions: httpSettings.Interactions,
+		RootCAs:      httpSettings.RootCAs,
+	}.Wrap(&http.Client{})
+	if httpSettings.MaxRetries > 0 {
+		config.MaxRetries = awslib.Int(httpSettings.MaxRetries)
+	}
+
 	return Client{
 		ec2Client:     awsec2.New(session.New(config)),
 		route53Client: awsroute53.New(session.New(config)),
+		s3Client:      awss3.New(session.New(config)),
+		kmsClient:     awskms.New(session.New(config)),
+		credentials:   refreshable,
 		logger:        logger,
 	}
 }
 
+// Credentials returns the credentials the client signs its requests with.
+func (c Client) Credentials() storage.AWS {
+	return c.credentials.get()
+}
+
+// SetCredentials makes the client sign its next requests with creds. The
+// region the client was built for is kept.
+func (c Client) SetCredentials(creds storage.AWS) {
+	creds.Region = c.credentials.get().Region
+	c.credentials.set(creds)
+}
+
 // If the parent domain for the provided url exists
 // in AWS Route53, return that zone's name.
 func (c Client) RetrieveDNS(url string) string {
@@ -81,6 +184,93 @@ func (c Client) RetrieveDNS(url string) string {
 	return parentDomain
 }
 
+// LBReferences returns the route53 records that still point at any of the
+// load balancer targets, given as dns names or ips. Records under the env's
+// own domain are left out since they are deleted along with the env.
+func (c Client) LBReferences(targets []string, ownDomain string) ([]string, error) {
+	wanted := map[string]bool{}
+	for _, target := range targets {
+		if target != "" {
+			wanted[normalizeDNSName(target)] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	ownDomain = normalizeDNSName(ownDomain)
+
+	var zones []*awsroute53.HostedZone
+	input := &awsroute53.ListHostedZonesInput{}
+	for {
+		output, err := c.route53Client.ListHostedZones(input)
+		if err != nil {
+			return nil, fmt.Errorf("List hosted zones: %s", err)
+		}
+		zones = append(zones, output.HostedZones...)
+
+		if !awslib.BoolValue(output.IsTruncated) {
+			break
+		}
+		input = &awsroute53.ListHostedZonesInput{Marker: output.NextMarker}
+	}
+
+	references := []string{}
+	for _, zone := range zones {
+		input := &awsroute53.ListResourceRecordSetsInput{HostedZoneId: zone.Id}
+		for {
+			output, err := c.route53Client.ListResourceRecordSets(input)
+			if err != nil {
+				return nil, fmt.Errorf("List records of hosted zone %s: %s", awslib.StringValue(zone.Name), err)
+			}
+
+			for _, record := range output.ResourceRecordSets {
+				name := normalizeDNSName(awslib.StringValue(record.Name))
+				if ownDomain != "" && (name == ownDomain || strings.HasSuffix(name, "."+ownDomain)) {
+					continue
+				}
+
+				if recordPointsAt(record, wanted) {
+					references = append(references, fmt.Sprintf("%s %s", name, awslib.StringValue(record.Type)))
+				}
+			}
+
+			if !awslib.BoolValue(output.IsTruncated) {
+				break
+			}
+			input = &awsroute53.ListResourceRecordSetsInput{
+				HostedZoneId:          zone.Id,
+				StartRecordName:       output.NextRecordName,
+				StartRecordType:       output.NextRecordType,
+				StartRecordIdentifier: output.NextRecordIdentifier,
+			}
+		}
+	}
+
+	return references, nil
+}
+
+func recordPointsAt(record *awsroute53.ResourceRecordSet, targets map[string]bool) bool {
+	if record.AliasTarget != nil {
+		alias := strings.TrimPrefix(normalizeDNSName(awslib.StringValue(record.AliasTarget.DNSName)), "dualstack.")
+		if targets[alias] {
+			return true
+		}
+	}
+
+	for _, value := range record.ResourceRecords {
+		if targets[normalizeDNSName(awslib.StringValue(value.Value))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
 // Return the AWS Availability Zones for a given region.
 func (c Client) RetrieveAZs(region string) ([]string, error) {
 	output, err := c.ec2Client.DescribeAvailabilityZones(&awsec2.DescribeAvailabilityZonesInput{
@@ -131,6 +321,100 @@ func (c Client) CheckExists(networkName string) (bool, error) {
 	return false, nil
 }
 
+// Make the cheapest authenticated call to confirm that the credentials
+// are accepted and the EC2 API is reachable.
+func (c Client) CheckConnectivity() error {
+	_, err := c.ec2Client.DescribeRegions(&awsec2.DescribeRegionsInput{})
+	if err != nil {
+		return fmt.Errorf("Describe regions: %s", err)
+	}
+
+	return nil
+}
+
+// WriteTombstone puts an object named for the env id into the bucket,
+// tagged so it can be told apart from anything else stored there. bbl never
+// deletes it.
+func (c Client) WriteTombstone(bucket, envID string, contents []byte) error {
+	_, err := c.s3Client.PutObject(&awss3.PutObjectInput{
+		Bucket:      awslib.String(bucket),
+		Key:         awslib.String(fmt.Sprintf("%s.json", envID)),
+		Body:        bytes.NewReader(contents),
+		ContentType: awslib.String("application/json"),
+		Tagging:     awslib.String(fmt.Sprintf("bbl-env-id=%s&bbl-tombstone=true", envID)),
+	})
+	if err != nil {
+		return fmt.Errorf("Put tombstone object: %s", err)
+	}
+
+	return nil
+}
+
+// Regions returns the names of the regions enabled for the account.
+func (c Client) Regions() ([]string, error) {
+	output, err := c.ec2Client.DescribeRegions(&awsec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("Describe regions: %s", err)
+	}
+
+	regions := []string{}
+	for _, region := range output.Regions {
+		regions = append(regions, awslib.StringValue(region.RegionName))
+	}
+
+	return regions, nil
+}
+
+// CheckLocation fails if the region the env was created in is no longer
+// offered to the account. AWS envs span every zone of their region, so only
+// the region is checked.
+func (c Client) CheckLocation(region, zone string) error {
+	output, err := c.ec2Client.DescribeRegions(&awsec2.DescribeRegionsInput{
+		Filters: []*awsec2.Filter{{
+			Name:   awslib.String("region-name"),
+			Values: []*string{awslib.String(region)},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("Describe regions: %s", err)
+	}
+
+	if len(output.Regions) == 0 {
+		return fmt.Errorf("region %s no longer exists", region)
+	}
+
+	return nil
+}
+
+// VMExists reports whether the instance is still around, treating instances
+// that are shutting down or terminated, like a reclaimed spot instance, as
+// gone.
+func (c Client) VMExists(instanceID string) (bool, error) {
+	output, err := c.ec2Client.DescribeInstances(&awsec2.DescribeInstancesInput{
+		Filters: []*awsec2.Filter{
+			{
+				Name:   awslib.String("instance-id"),
+				Values: []*string{awslib.String(instanceID)},
+			},
+			{
+				Name:   awslib.String("instance-state-name"),
+				Values: awslib.StringSlice([]string{"pending", "running", "stopping", "stopped"}),
+			},
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("Describe instances: %s", err)
+	}
+
+	for _, reservation := range output.Reservations {
+		if len(reservation.Instances) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (c Client) ValidateSafeToDelete(vpcID, envID string) error {
 	output, err := c.ec2Client.DescribeInstances(&awsec2.DescribeInstancesInput{
 		Filters: []*awsec2.Filter{{
@@ -149,23 +433,320 @@ func (c Client) ValidateSafeToDelete(vpcID, envID string) error {
 	vms = c.removeOneVM(vms, "jumpbox/0")
 
 	if len(vms) > 0 {
-		return fmt.Errorf("vpc %s is not safe to delete; vms still exist: [%s]", vpcID, strings.Join(vms, ", "))
+		return VPCNotSafeToDeleteError{VPCID: vpcID, VMs: vms}
 	}
 
 	return nil
 }
 
-func (c Client) flattenVMs(reservations []*awsec2.Reservation) []string {
+// VPCNotSafeToDeleteError is returned when vms other than bbl's own are still
+// in the vpc. errors.Is(err, VPCNotSafeToDeleteError{}) reports whether err is
+// one, whatever its vpc and vms.
+type VPCNotSafeToDeleteError struct {
+	VPCID string
+	VMs   []*awsec2.Instance
+}
+
+func (e VPCNotSafeToDeleteError) Is(target error) bool {
+	_, ok := target.(VPCNotSafeToDeleteError)
+	return ok
+}
+
+func (e VPCNotSafeToDeleteError) Error() string {
 	vms := []string{}
-	for _, reservation := range reservations {
-		for _, instance := range reservation.Instances {
-			vms = append(vms, c.vmName(instance))
+	for _, instance := range e.VMs {
+		vm := vmName(instance)
+		if id := awslib.StringValue(instance.InstanceId); id != "" {
+			vm = fmt.Sprintf("%s (%s)", vm, id)
 		}
+		vms = append(vms, vm)
+	}
+
+	return fmt.Sprintf("vpc %s is not safe to delete; vms still exist: [%s]", e.VPCID, strings.Join(vms, ", "))
+}
+
+// BlockingVMs describes each vm in the vpc by its id, name and other tags,
+// so that an operator can find and delete them.
+func (e VPCNotSafeToDeleteError) BlockingVMs() []string {
+	vms := []string{}
+	for _, instance := range e.VMs {
+		tags := []string{}
+		for _, tag := range instance.Tags {
+			if key := awslib.StringValue(tag.Key); key != "Name" {
+				tags = append(tags, fmt.Sprintf("%s=%s", key, awslib.StringValue(tag.Value)))
+			}
+		}
+		sort.Strings(tags)
+
+		vm := fmt.Sprintf("%s %s", awslib.StringValue(instance.InstanceId), vmName(instance))
+		if len(tags) > 0 {
+			vm = fmt.Sprintf("%s (%s)", vm, strings.Join(tags, ", "))
+		}
+		vms = append(vms, strings.TrimSpace(vm))
+	}
+
+	return vms
+}
+
+// ValidateEnvSafeToDelete runs ValidateSafeToDelete against every vpc named
+// after the env in the client's region. A region without one is safe.
+func (c Client) ValidateEnvSafeToDelete(envID string) error {
+	vpcs, err := c.ec2Client.DescribeVpcs(&awsec2.DescribeVpcsInput{
+		Filters: []*awsec2.Filter{{
+			Name:   awslib.String("tag:Name"),
+			Values: []*string{awslib.String(fmt.Sprintf("%s-vpc", envID))},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("Describe vpcs: %s", err)
+	}
+
+	for _, vpc := range vpcs.Vpcs {
+		err = c.ValidateSafeToDelete(awslib.StringValue(vpc.VpcId), envID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ActivePeerings returns the ids of the peering connections the vpc
+// requested or accepted that are still in use.
+func (c Client) ActivePeerings(vpcID string) ([]string, error) {
+	peerings := []string{}
+	for _, side := range []string{"requester-vpc-info.vpc-id", "accepter-vpc-info.vpc-id"} {
+		output, err := c.ec2Client.DescribeVpcPeeringConnections(&awsec2.DescribeVpcPeeringConnectionsInput{
+			Filters: []*awsec2.Filter{
+				{
+					Name:   awslib.String(side),
+					Values: []*string{awslib.String(vpcID)},
+				},
+				{
+					Name:   awslib.String("status-code"),
+					Values: []*string{awslib.String("active"), awslib.String("pending-acceptance")},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Describe vpc peering connections: %s", err)
+		}
+
+		for _, peering := range output.VpcPeeringConnections {
+			peerings = append(peerings, awslib.StringValue(peering.VpcPeeringConnectionId))
+		}
+	}
+
+	return peerings, nil
+}
+
+func (c Client) DeletePeerings(vpcID string) error {
+	peerings, err := c.ActivePeerings(vpcID)
+	if err != nil {
+		return err
+	}
+
+	for _, peering := range peerings {
+		c.logger.Step("deleting vpc peering connection %s", peering)
+		_, err = c.ec2Client.DeleteVpcPeeringConnection(&awsec2.DeleteVpcPeeringConnectionInput{
+			VpcPeeringConnectionId: awslib.String(peering),
+		})
+		if err != nil {
+			return fmt.Errorf("Delete vpc peering connection %s: %s", peering, err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteImages deregisters the amis owned by the account that are tagged
+// with the env id and deletes their backing snapshots. It returns the ids
+// of the images it deleted. Images and snapshots that are already gone are
+// skipped.
+func (c Client) DeleteImages(envID string) ([]string, error) {
+	output, err := c.ec2Client.DescribeImages(&awsec2.DescribeImagesInput{
+		Owners: []*string{awslib.String("self")},
+		Filters: []*awsec2.Filter{{
+			Name:   awslib.String("tag:EnvID"),
+			Values: []*string{awslib.String(envID)},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Describe images: %s", err)
+	}
+
+	deleted := []string{}
+	for _, image := range output.Images {
+		imageID := awslib.StringValue(image.ImageId)
+
+		_, err = c.ec2Client.DeregisterImage(&awsec2.DeregisterImageInput{
+			ImageId: image.ImageId,
+		})
+		if err != nil && !isAWSNotFound(err, "InvalidAMIID.NotFound", "InvalidAMIID.Unavailable") {
+			return deleted, fmt.Errorf("Deregister image %s: %s", imageID, err)
+		}
+
+		for _, mapping := range image.BlockDeviceMappings {
+			if mapping.Ebs == nil || mapping.Ebs.SnapshotId == nil {
+				continue
+			}
+
+			_, err = c.ec2Client.DeleteSnapshot(&awsec2.DeleteSnapshotInput{
+				SnapshotId: mapping.Ebs.SnapshotId,
+			})
+			if err != nil && !isAWSNotFound(err, "InvalidSnapshot.NotFound") {
+				return deleted, fmt.Errorf("Delete snapshot %s of image %s: %s", awslib.StringValue(mapping.Ebs.SnapshotId), imageID, err)
+			}
+		}
+
+		deleted = append(deleted, imageID)
+	}
+
+	return deleted, nil
+}
+
+// DeleteKMSKeys schedules the deletion of the customer managed kms keys
+// tagged with the env id, after deleting the aliases that point at them. It
+// returns the ids of the keys it scheduled for deletion. Keys that are
+// already pending deletion, or already gone, are skipped.
+func (c Client) DeleteKMSKeys(envID string, pendingWindowDays int) ([]string, error) {
+	keyIDs, err := c.envKMSKeys(envID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyIDs) == 0 {
+		return []string{}, nil
+	}
+
+	aliases, err := c.kmsAliases()
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, keyID := range keyIDs {
+		for _, alias := range aliases[keyID] {
+			_, err = c.kmsClient.DeleteAlias(&awskms.DeleteAliasInput{AliasName: awslib.String(alias)})
+			if err != nil && !isAWSNotFound(err, awskms.ErrCodeNotFoundException) {
+				return deleted, fmt.Errorf("Delete kms alias %s: %s", alias, err)
+			}
+		}
+
+		_, err = c.kmsClient.ScheduleKeyDeletion(&awskms.ScheduleKeyDeletionInput{
+			KeyId:               awslib.String(keyID),
+			PendingWindowInDays: awslib.Int64(int64(pendingWindowDays)),
+		})
+		if isAWSNotFound(err, awskms.ErrCodeNotFoundException) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("Schedule deletion of kms key %s: %s", keyID, err)
+		}
+
+		deleted = append(deleted, keyID)
+	}
+
+	return deleted, nil
+}
+
+// envKMSKeys returns the customer managed keys tagged with the env id that
+// are not already pending deletion.
+func (c Client) envKMSKeys(envID string) ([]string, error) {
+	keyIDs := []string{}
+	input := &awskms.ListKeysInput{}
+	for {
+		output, err := c.kmsClient.ListKeys(input)
+		if err != nil {
+			return nil, fmt.Errorf("List kms keys: %s", err)
+		}
+
+		for _, key := range output.Keys {
+			keyID := awslib.StringValue(key.KeyId)
+
+			description, err := c.kmsClient.DescribeKey(&awskms.DescribeKeyInput{KeyId: key.KeyId})
+			if err != nil {
+				return nil, fmt.Errorf("Describe kms key %s: %s", keyID, err)
+			}
+			metadata := description.KeyMetadata
+			if metadata == nil ||
+				awslib.StringValue(metadata.KeyManager) == awskms.KeyManagerTypeAws ||
+				awslib.StringValue(metadata.KeyState) == awskms.KeyStatePendingDeletion {
+				continue
+			}
+
+			tags, err := c.kmsClient.ListResourceTags(&awskms.ListResourceTagsInput{KeyId: key.KeyId})
+			if err != nil {
+				return nil, fmt.Errorf("List tags of kms key %s: %s", keyID, err)
+			}
+			for _, tag := range tags.Tags {
+				if awslib.StringValue(tag.TagKey) == "EnvID" && awslib.StringValue(tag.TagValue) == envID {
+					keyIDs = append(keyIDs, keyID)
+					break
+				}
+			}
+		}
+
+		if !awslib.BoolValue(output.Truncated) {
+			break
+		}
+		input = &awskms.ListKeysInput{Marker: output.NextMarker}
+	}
+
+	return keyIDs, nil
+}
+
+// kmsAliases returns the alias names of the account by the key they point
+// at.
+func (c Client) kmsAliases() (map[string][]string, error) {
+	aliases := map[string][]string{}
+	input := &awskms.ListAliasesInput{}
+	for {
+		output, err := c.kmsClient.ListAliases(input)
+		if err != nil {
+			return nil, fmt.Errorf("List kms aliases: %s", err)
+		}
+
+		for _, alias := range output.Aliases {
+			if alias.TargetKeyId == nil {
+				continue
+			}
+			keyID := awslib.StringValue(alias.TargetKeyId)
+			aliases[keyID] = append(aliases[keyID], awslib.StringValue(alias.AliasName))
+		}
+
+		if !awslib.BoolValue(output.Truncated) {
+			break
+		}
+		input = &awskms.ListAliasesInput{Marker: output.NextMarker}
+	}
+
+	return aliases, nil
+}
+
+func isAWSNotFound(err error, codes ...string) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	for _, code := range codes {
+		if awsErr.Code() == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Client) flattenVMs(reservations []*awsec2.Reservation) []*awsec2.Instance {
+	vms := []*awsec2.Instance{}
+	for _, reservation := range reservations {
+		vms = append(vms, reservation.Instances...)
 	}
 	return vms
 }
 
-func (c Client) vmName(instance *awsec2.Instance) string {
+func vmName(instance *awsec2.Instance) string {
 	name := "unnamed"
 
 	for _, tag := range instance.Tags {
@@ -177,9 +758,9 @@ func (c Client) vmName(instance *awsec2.Instance) string {
 	return name
 }
 
-func (c Client) removeOneVM(vms []string, vmToRemove string) []string {
+func (c Client) removeOneVM(vms []*awsec2.Instance, vmToRemove string) []*awsec2.Instance {
 	for index, vm := range vms {
-		if vm == vmToRemove {
+		if vmName(vm) == vmToRemove {
 			return append(vms[:index], vms[index+1:]...)
 		}
 	}
@@ -2,15 +2,21 @@ package aws_test
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/cloudfoundry/bosh-bootloader/aws"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	awslib "github.com/aws/aws-sdk-go/aws"
 	awsec2 "github.com/aws/aws-sdk-go/service/ec2"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
 	awsroute53 "github.com/aws/aws-sdk-go/service/route53"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -25,6 +31,7 @@ var _ = Describe("Client", func() {
 					SecretAccessKey: "some-secret-access-key",
 					Region:          "some-region",
 				},
+				helpers.HTTPSettings{},
 				&fakes.Logger{},
 			)
 
@@ -34,9 +41,83 @@ var _ = Describe("Client", func() {
 			_, ok = client.GetRoute53Client().(*awsroute53.Route53)
 			Expect(ok).To(BeTrue())
 
-			Expect(ec2Client.Config.Credentials).To(Equal(credentials.NewStaticCredentials("some-access-key-id", "some-secret-access-key", "")))
+			_, ok = client.GetS3Client().(*awss3.S3)
+			Expect(ok).To(BeTrue())
+
+			_, ok = client.GetKMSClient().(*awskms.KMS)
+			Expect(ok).To(BeTrue())
+
+			value, err := ec2Client.Config.Credentials.Get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.AccessKeyID).To(Equal("some-access-key-id"))
+			Expect(value.SecretAccessKey).To(Equal("some-secret-access-key"))
+			Expect(value.SessionToken).To(BeEmpty())
 			Expect(ec2Client.Config.Region).To(Equal(awslib.String("some-region")))
 		})
+
+		It("uses the session token of temporary credentials", func() {
+			client := aws.NewClient(
+				storage.AWS{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					SessionToken:    "some-session-token",
+					Region:          "some-region",
+				},
+				helpers.HTTPSettings{},
+				&fakes.Logger{},
+			)
+
+			ec2Client, ok := client.GetEC2Client().(*awsec2.EC2)
+			Expect(ok).To(BeTrue())
+
+			value, err := ec2Client.Config.Credentials.Get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.SessionToken).To(Equal("some-session-token"))
+		})
+
+		It("signs the next requests with credentials set after it was built", func() {
+			client := aws.NewClient(
+				storage.AWS{
+					AccessKeyID:     "some-access-key-id",
+					SecretAccessKey: "some-secret-access-key",
+					Region:          "some-region",
+				},
+				helpers.HTTPSettings{},
+				&fakes.Logger{},
+			)
+
+			kmsClient, ok := client.GetKMSClient().(*awskms.KMS)
+			Expect(ok).To(BeTrue())
+
+			_, err := kmsClient.Config.Credentials.Get()
+			Expect(err).NotTo(HaveOccurred())
+
+			client.SetCredentials(storage.AWS{
+				AccessKeyID:     "rotated-access-key-id",
+				SecretAccessKey: "rotated-secret-access-key",
+				SessionToken:    "rotated-session-token",
+			})
+
+			value, err := kmsClient.Config.Credentials.Get()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.AccessKeyID).To(Equal("rotated-access-key-id"))
+			Expect(value.SecretAccessKey).To(Equal("rotated-secret-access-key"))
+			Expect(value.SessionToken).To(Equal("rotated-session-token"))
+			Expect(client.Credentials().Region).To(Equal("some-region"))
+		})
+
+		It("applies the http settings", func() {
+			client := aws.NewClient(storage.AWS{Region: "some-region"}, helpers.HTTPSettings{
+				Timeout:    time.Minute,
+				MaxRetries: 7,
+			}, &fakes.Logger{})
+
+			ec2Client, ok := client.GetEC2Client().(*awsec2.EC2)
+			Expect(ok).To(BeTrue())
+
+			Expect(ec2Client.Config.HTTPClient.Timeout).To(Equal(time.Minute))
+			Expect(ec2Client.Config.MaxRetries).To(Equal(awslib.Int(7)))
+		})
 	})
 
 	Describe("RetrieveDNS", func() {
@@ -127,6 +208,112 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("LBReferences", func() {
+		var (
+			client        aws.Client
+			route53Client *fakes.AWSRoute53Client
+		)
+
+		BeforeEach(func() {
+			route53Client = &fakes.AWSRoute53Client{}
+			client = aws.NewClientWithInjectedRoute53Client(route53Client, &fakes.Logger{})
+
+			route53Client.ListHostedZonesCall.Returns.Outputs = []*awsroute53.ListHostedZonesOutput{
+				{
+					HostedZones: []*awsroute53.HostedZone{{Id: awslib.String("zone-1"), Name: awslib.String("example.com.")}},
+					IsTruncated: awslib.Bool(true),
+					NextMarker:  awslib.String("some-marker"),
+				},
+				{
+					HostedZones: []*awsroute53.HostedZone{{Id: awslib.String("zone-2"), Name: awslib.String("other.com.")}},
+					IsTruncated: awslib.Bool(false),
+				},
+			}
+			route53Client.ListResourceRecordSetsCall.Returns.Outputs = []*awsroute53.ListResourceRecordSetsOutput{
+				{
+					ResourceRecordSets: []*awsroute53.ResourceRecordSet{
+						{
+							Name:            awslib.String("*.sys.example.com."),
+							Type:            awslib.String("CNAME"),
+							ResourceRecords: []*awsroute53.ResourceRecord{{Value: awslib.String("router-lb.elb.amazonaws.com")}},
+						},
+						{
+							Name:            awslib.String("apps.example.com."),
+							Type:            awslib.String("CNAME"),
+							ResourceRecords: []*awsroute53.ResourceRecord{{Value: awslib.String("Router-LB.elb.amazonaws.com.")}},
+						},
+					},
+					IsTruncated:    awslib.Bool(true),
+					NextRecordName: awslib.String("b.example.com."),
+					NextRecordType: awslib.String("A"),
+				},
+				{
+					ResourceRecordSets: []*awsroute53.ResourceRecordSet{
+						{
+							Name:        awslib.String("b.example.com."),
+							Type:        awslib.String("A"),
+							AliasTarget: &awsroute53.AliasTarget{DNSName: awslib.String("dualstack.ssh-lb.elb.amazonaws.com.")},
+						},
+					},
+					IsTruncated: awslib.Bool(false),
+				},
+				{
+					ResourceRecordSets: []*awsroute53.ResourceRecordSet{
+						{
+							Name:            awslib.String("unrelated.other.com."),
+							Type:            awslib.String("A"),
+							ResourceRecords: []*awsroute53.ResourceRecord{{Value: awslib.String("10.0.0.1")}},
+						},
+					},
+					IsTruncated: awslib.Bool(false),
+				},
+			}
+		})
+
+		It("returns the records outside the env domain that point at the load balancers", func() {
+			references, err := client.LBReferences([]string{"router-lb.elb.amazonaws.com", "ssh-lb.elb.amazonaws.com"}, "sys.example.com")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(references).To(Equal([]string{"apps.example.com CNAME", "b.example.com A"}))
+
+			Expect(route53Client.ListHostedZonesCall.Receives.Inputs[1].Marker).To(Equal(awslib.String("some-marker")))
+			Expect(route53Client.ListResourceRecordSetsCall.Receives.Inputs[1]).To(Equal(&awsroute53.ListResourceRecordSetsInput{
+				HostedZoneId:    awslib.String("zone-1"),
+				StartRecordName: awslib.String("b.example.com."),
+				StartRecordType: awslib.String("A"),
+			}))
+			Expect(route53Client.ListResourceRecordSetsCall.Receives.Inputs[2].HostedZoneId).To(Equal(awslib.String("zone-2")))
+		})
+
+		Context("when there are no targets", func() {
+			It("does not look anything up", func() {
+				references, err := client.LBReferences([]string{""}, "sys.example.com")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(references).To(BeEmpty())
+
+				Expect(route53Client.ListHostedZonesCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when listing the hosted zones fails", func() {
+			It("returns an error", func() {
+				route53Client.ListHostedZonesCall.Returns.Error = errors.New("access denied")
+
+				_, err := client.LBReferences([]string{"router-lb.elb.amazonaws.com"}, "sys.example.com")
+				Expect(err).To(MatchError("List hosted zones: access denied"))
+			})
+		})
+
+		Context("when listing the records fails", func() {
+			It("returns an error", func() {
+				route53Client.ListResourceRecordSetsCall.Returns.Error = errors.New("throttled")
+
+				_, err := client.LBReferences([]string{"router-lb.elb.amazonaws.com"}, "sys.example.com")
+				Expect(err).To(MatchError("List records of hosted zone example.com.: throttled"))
+			})
+		})
+	})
+
 	Describe("RetrieveAZs", func() {
 		var (
 			client    aws.Client
@@ -200,6 +387,513 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("CheckConnectivity", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+		})
+
+		It("describes the regions", func() {
+			err := client.CheckConnectivity()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ec2Client.DescribeRegionsCall.CallCount).To(Equal(1))
+			Expect(ec2Client.DescribeRegionsCall.Receives.Input).To(Equal(&awsec2.DescribeRegionsInput{}))
+		})
+
+		Context("when describe regions fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeRegionsCall.Returns.Error = errors.New("auth failure")
+
+				err := client.CheckConnectivity()
+				Expect(err).To(MatchError("Describe regions: auth failure"))
+			})
+		})
+	})
+
+	Describe("WriteTombstone", func() {
+		var (
+			client   aws.Client
+			s3Client *fakes.AWSS3Client
+		)
+
+		BeforeEach(func() {
+			s3Client = &fakes.AWSS3Client{}
+			client = aws.NewClientWithInjectedS3Client(s3Client, &fakes.Logger{})
+		})
+
+		It("puts a tagged object named for the env id into the bucket", func() {
+			err := client.WriteTombstone("some-bucket", "some-env-id", []byte(`{"env_id": "some-env-id"}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			input := s3Client.PutObjectCall.Receives.Input
+			Expect(input.Bucket).To(Equal(awslib.String("some-bucket")))
+			Expect(input.Key).To(Equal(awslib.String("some-env-id.json")))
+			Expect(input.Tagging).To(Equal(awslib.String("bbl-env-id=some-env-id&bbl-tombstone=true")))
+
+			body, err := ioutil.ReadAll(input.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(Equal(`{"env_id": "some-env-id"}`))
+		})
+
+		Context("when putting the object fails", func() {
+			It("returns an error", func() {
+				s3Client.PutObjectCall.Returns.Error = errors.New("access denied")
+
+				err := client.WriteTombstone("some-bucket", "some-env-id", []byte("{}"))
+				Expect(err).To(MatchError("Put tombstone object: access denied"))
+			})
+		})
+	})
+
+	Describe("Regions", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+		})
+
+		It("returns the names of the regions", func() {
+			ec2Client.DescribeRegionsCall.Returns.Output = &awsec2.DescribeRegionsOutput{
+				Regions: []*awsec2.Region{
+					{RegionName: awslib.String("us-east-1")},
+					{RegionName: awslib.String("eu-west-1")},
+				},
+			}
+
+			regions, err := client.Regions()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(regions).To(Equal([]string{"us-east-1", "eu-west-1"}))
+		})
+
+		Context("when describe regions fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeRegionsCall.Returns.Error = errors.New("auth failure")
+
+				_, err := client.Regions()
+				Expect(err).To(MatchError("Describe regions: auth failure"))
+			})
+		})
+	})
+
+	Describe("CheckLocation", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+			ec2Client.DescribeRegionsCall.Returns.Output = &awsec2.DescribeRegionsOutput{
+				Regions: []*awsec2.Region{{RegionName: awslib.String("us-east-1")}},
+			}
+		})
+
+		It("describes the region", func() {
+			err := client.CheckLocation("us-east-1", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ec2Client.DescribeRegionsCall.Receives.Input.Filters).To(Equal([]*awsec2.Filter{{
+				Name:   awslib.String("region-name"),
+				Values: []*string{awslib.String("us-east-1")},
+			}}))
+		})
+
+		Context("when the region is gone", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeRegionsCall.Returns.Output = &awsec2.DescribeRegionsOutput{}
+
+				err := client.CheckLocation("us-east-1", "")
+				Expect(err).To(MatchError("region us-east-1 no longer exists"))
+			})
+		})
+
+		Context("when describe regions fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeRegionsCall.Returns.Error = errors.New("auth failure")
+
+				err := client.CheckLocation("us-east-1", "")
+				Expect(err).To(MatchError("Describe regions: auth failure"))
+			})
+		})
+	})
+
+	Describe("DeleteImages", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+			ec2Client.DescribeImagesCall.Returns.Output = &awsec2.DescribeImagesOutput{
+				Images: []*awsec2.Image{{
+					ImageId: awslib.String("ami-123"),
+					BlockDeviceMappings: []*awsec2.BlockDeviceMapping{
+						{Ebs: &awsec2.EbsBlockDevice{SnapshotId: awslib.String("snap-123")}},
+						{VirtualName: awslib.String("ephemeral0")},
+					},
+				}},
+			}
+		})
+
+		It("deregisters the images tagged with the env id and deletes their snapshots", func() {
+			deleted, err := client.DeleteImages("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal([]string{"ami-123"}))
+
+			Expect(ec2Client.DescribeImagesCall.Receives.Input.Owners).To(Equal([]*string{awslib.String("self")}))
+			Expect(ec2Client.DescribeImagesCall.Receives.Input.Filters).To(Equal([]*awsec2.Filter{{
+				Name:   awslib.String("tag:EnvID"),
+				Values: []*string{awslib.String("some-env-id")},
+			}}))
+			Expect(ec2Client.DeregisterImageCall.Receives.Inputs[0].ImageId).To(Equal(awslib.String("ami-123")))
+			Expect(ec2Client.DeleteSnapshotCall.CallCount).To(Equal(1))
+			Expect(ec2Client.DeleteSnapshotCall.Receives.Inputs[0].SnapshotId).To(Equal(awslib.String("snap-123")))
+		})
+
+		Context("when the image and snapshot are already gone", func() {
+			It("treats them as deleted", func() {
+				ec2Client.DeregisterImageCall.Returns.Error = awserr.New("InvalidAMIID.NotFound", "not found", nil)
+				ec2Client.DeleteSnapshotCall.Returns.Error = awserr.New("InvalidSnapshot.NotFound", "not found", nil)
+
+				deleted, err := client.DeleteImages("some-env-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(Equal([]string{"ami-123"}))
+			})
+		})
+
+		Context("when describing the images fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeImagesCall.Returns.Error = errors.New("auth failure")
+
+				_, err := client.DeleteImages("some-env-id")
+				Expect(err).To(MatchError("Describe images: auth failure"))
+			})
+		})
+
+		Context("when deregistering an image fails", func() {
+			It("returns an error", func() {
+				ec2Client.DeregisterImageCall.Returns.Error = errors.New("in use")
+
+				_, err := client.DeleteImages("some-env-id")
+				Expect(err).To(MatchError("Deregister image ami-123: in use"))
+				Expect(ec2Client.DeleteSnapshotCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when deleting a snapshot fails", func() {
+			It("returns an error", func() {
+				ec2Client.DeleteSnapshotCall.Returns.Error = errors.New("in use")
+
+				_, err := client.DeleteImages("some-env-id")
+				Expect(err).To(MatchError("Delete snapshot snap-123 of image ami-123: in use"))
+			})
+		})
+	})
+
+	Describe("DeleteKMSKeys", func() {
+		var (
+			client    aws.Client
+			kmsClient *fakes.AWSKMSClient
+		)
+
+		customerKey := func(state string) *awskms.DescribeKeyOutput {
+			return &awskms.DescribeKeyOutput{KeyMetadata: &awskms.KeyMetadata{
+				KeyManager: awslib.String("CUSTOMER"),
+				KeyState:   awslib.String(state),
+			}}
+		}
+
+		envTag := func(envID string) *awskms.ListResourceTagsOutput {
+			return &awskms.ListResourceTagsOutput{Tags: []*awskms.Tag{{
+				TagKey:   awslib.String("EnvID"),
+				TagValue: awslib.String(envID),
+			}}}
+		}
+
+		BeforeEach(func() {
+			kmsClient = &fakes.AWSKMSClient{}
+			client = aws.NewClientWithInjectedKMSClient(kmsClient, &fakes.Logger{})
+
+			kmsClient.ListKeysCall.Returns.Outputs = []*awskms.ListKeysOutput{
+				{
+					Keys: []*awskms.KeyListEntry{
+						{KeyId: awslib.String("env-key")},
+						{KeyId: awslib.String("other-env-key")},
+					},
+					Truncated:  awslib.Bool(true),
+					NextMarker: awslib.String("some-marker"),
+				},
+				{
+					Keys: []*awskms.KeyListEntry{
+						{KeyId: awslib.String("pending-env-key")},
+						{KeyId: awslib.String("aws-managed-key")},
+					},
+				},
+			}
+			kmsClient.DescribeKeyCall.Returns.Outputs = map[string]*awskms.DescribeKeyOutput{
+				"env-key":         customerKey("Enabled"),
+				"other-env-key":   customerKey("Enabled"),
+				"pending-env-key": customerKey("PendingDeletion"),
+				"aws-managed-key": {KeyMetadata: &awskms.KeyMetadata{KeyManager: awslib.String("AWS"), KeyState: awslib.String("Enabled")}},
+			}
+			kmsClient.ListResourceTagsCall.Returns.Outputs = map[string]*awskms.ListResourceTagsOutput{
+				"env-key":       envTag("some-env-id"),
+				"other-env-key": envTag("other-env-id"),
+			}
+			kmsClient.ListAliasesCall.Returns.Outputs = []*awskms.ListAliasesOutput{{
+				Aliases: []*awskms.AliasListEntry{
+					{AliasName: awslib.String("alias/some-env-id"), TargetKeyId: awslib.String("env-key")},
+					{AliasName: awslib.String("alias/other-env-id"), TargetKeyId: awslib.String("other-env-key")},
+					{AliasName: awslib.String("alias/aws/s3")},
+				},
+			}}
+		})
+
+		It("deletes the aliases and schedules the deletion of the keys tagged with the env id", func() {
+			deleted, err := client.DeleteKMSKeys("some-env-id", 7)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal([]string{"env-key"}))
+
+			Expect(kmsClient.ListKeysCall.Receives.Inputs[1].Marker).To(Equal(awslib.String("some-marker")))
+			Expect(kmsClient.ListResourceTagsCall.CallCount).To(Equal(2))
+			Expect(kmsClient.DeleteAliasCall.Receives.Inputs).To(Equal([]*awskms.DeleteAliasInput{
+				{AliasName: awslib.String("alias/some-env-id")},
+			}))
+			Expect(kmsClient.ScheduleKeyDeletionCall.Receives.Inputs).To(Equal([]*awskms.ScheduleKeyDeletionInput{
+				{KeyId: awslib.String("env-key"), PendingWindowInDays: awslib.Int64(7)},
+			}))
+		})
+
+		Context("when no keys are tagged with the env id", func() {
+			It("does not list the aliases", func() {
+				deleted, err := client.DeleteKMSKeys("unknown-env-id", 7)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(BeEmpty())
+
+				Expect(kmsClient.ListAliasesCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the key is already gone", func() {
+			It("skips it without reporting it as scheduled", func() {
+				kmsClient.ScheduleKeyDeletionCall.Returns.Error = awserr.New("NotFoundException", "not found", nil)
+
+				deleted, err := client.DeleteKMSKeys("some-env-id", 7)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(BeEmpty())
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when listing the keys fails", func() {
+				kmsClient.ListKeysCall.Returns.Error = errors.New("auth failure")
+
+				_, err := client.DeleteKMSKeys("some-env-id", 7)
+				Expect(err).To(MatchError("List kms keys: auth failure"))
+			})
+
+			It("returns an error when listing the aliases fails", func() {
+				kmsClient.ListAliasesCall.Returns.Error = errors.New("auth failure")
+
+				_, err := client.DeleteKMSKeys("some-env-id", 7)
+				Expect(err).To(MatchError("List kms aliases: auth failure"))
+			})
+
+			It("returns an error when deleting an alias fails", func() {
+				kmsClient.DeleteAliasCall.Returns.Error = errors.New("access denied")
+
+				_, err := client.DeleteKMSKeys("some-env-id", 7)
+				Expect(err).To(MatchError("Delete kms alias alias/some-env-id: access denied"))
+				Expect(kmsClient.ScheduleKeyDeletionCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when scheduling the deletion fails", func() {
+				kmsClient.ScheduleKeyDeletionCall.Returns.Error = errors.New("access denied")
+
+				_, err := client.DeleteKMSKeys("some-env-id", 7)
+				Expect(err).To(MatchError("Schedule deletion of kms key env-key: access denied"))
+			})
+		})
+	})
+
+	Describe("VMExists", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+			ec2Client.DescribeInstancesCall.Returns.Output = &awsec2.DescribeInstancesOutput{}
+		})
+
+		It("looks for the instance unless it is terminated", func() {
+			ec2Client.DescribeInstancesCall.Returns.Output = &awsec2.DescribeInstancesOutput{
+				Reservations: []*awsec2.Reservation{{
+					Instances: []*awsec2.Instance{{InstanceId: awslib.String("i-123")}},
+				}},
+			}
+
+			exists, err := client.VMExists("i-123")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			Expect(ec2Client.DescribeInstancesCall.Receives.Input.Filters).To(Equal([]*awsec2.Filter{
+				{
+					Name:   awslib.String("instance-id"),
+					Values: []*string{awslib.String("i-123")},
+				},
+				{
+					Name:   awslib.String("instance-state-name"),
+					Values: awslib.StringSlice([]string{"pending", "running", "stopping", "stopped"}),
+				},
+			}))
+		})
+
+		It("reports an instance that is gone", func() {
+			exists, err := client.VMExists("i-123")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		Context("when describe instances fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeInstancesCall.Returns.Error = errors.New("auth failure")
+
+				_, err := client.VMExists("i-123")
+				Expect(err).To(MatchError("Describe instances: auth failure"))
+			})
+		})
+	})
+
+	Describe("ActivePeerings", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+
+			ec2Client.DescribeVpcPeeringConnectionsCall.Returns.Outputs = []*awsec2.DescribeVpcPeeringConnectionsOutput{
+				{VpcPeeringConnections: []*awsec2.VpcPeeringConnection{{VpcPeeringConnectionId: awslib.String("pcx-requested")}}},
+				{VpcPeeringConnections: []*awsec2.VpcPeeringConnection{{VpcPeeringConnectionId: awslib.String("pcx-accepted")}}},
+			}
+		})
+
+		It("returns the peerings the vpc requested and accepted", func() {
+			peerings, err := client.ActivePeerings("some-vpc-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(peerings).To(Equal([]string{"pcx-requested", "pcx-accepted"}))
+
+			inputs := ec2Client.DescribeVpcPeeringConnectionsCall.Receives.Inputs
+			Expect(inputs).To(HaveLen(2))
+			Expect(awslib.StringValue(inputs[0].Filters[0].Name)).To(Equal("requester-vpc-info.vpc-id"))
+			Expect(awslib.StringValue(inputs[1].Filters[0].Name)).To(Equal("accepter-vpc-info.vpc-id"))
+			Expect(awslib.StringValue(inputs[1].Filters[0].Values[0])).To(Equal("some-vpc-id"))
+		})
+
+		It("deletes them", func() {
+			err := client.DeletePeerings("some-vpc-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			inputs := ec2Client.DeleteVpcPeeringConnectionCall.Receives.Inputs
+			Expect(inputs).To(HaveLen(2))
+			Expect(awslib.StringValue(inputs[0].VpcPeeringConnectionId)).To(Equal("pcx-requested"))
+			Expect(awslib.StringValue(inputs[1].VpcPeeringConnectionId)).To(Equal("pcx-accepted"))
+		})
+
+		Context("when describing the peerings fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeVpcPeeringConnectionsCall.Returns.Error = errors.New("throttled")
+
+				_, err := client.ActivePeerings("some-vpc-id")
+				Expect(err).To(MatchError("Describe vpc peering connections: throttled"))
+			})
+		})
+
+		Context("when deleting a peering fails", func() {
+			It("returns an error", func() {
+				ec2Client.DeleteVpcPeeringConnectionCall.Returns.Error = errors.New("in use")
+
+				err := client.DeletePeerings("some-vpc-id")
+				Expect(err).To(MatchError("Delete vpc peering connection pcx-requested: in use"))
+			})
+		})
+	})
+
+	Describe("ValidateEnvSafeToDelete", func() {
+		var (
+			client    aws.Client
+			ec2Client *fakes.AWSEC2Client
+		)
+
+		BeforeEach(func() {
+			ec2Client = &fakes.AWSEC2Client{}
+			client = aws.NewClientWithInjectedEC2Client(ec2Client, &fakes.Logger{})
+			ec2Client.DescribeVpcsCall.Returns.Output = &awsec2.DescribeVpcsOutput{
+				Vpcs: []*awsec2.Vpc{{VpcId: awslib.String("some-vpc-id")}},
+			}
+			ec2Client.DescribeInstancesCall.Returns.Output = &awsec2.DescribeInstancesOutput{}
+		})
+
+		It("validates the vpc named after the env", func() {
+			err := client.ValidateEnvSafeToDelete("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ec2Client.DescribeVpcsCall.Receives.Input.Filters).To(Equal([]*awsec2.Filter{{
+				Name:   awslib.String("tag:Name"),
+				Values: []*string{awslib.String("some-env-id-vpc")},
+			}}))
+			Expect(ec2Client.DescribeInstancesCall.Receives.Input.Filters[0].Values).To(Equal([]*string{awslib.String("some-vpc-id")}))
+		})
+
+		Context("when vms other than bbl's are in the vpc", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeInstancesCall.Returns.Output = &awsec2.DescribeInstancesOutput{
+					Reservations: []*awsec2.Reservation{reservationContainingInstance("some-vm")},
+				}
+
+				err := client.ValidateEnvSafeToDelete("some-env-id")
+				Expect(err).To(MatchError("vpc some-vpc-id is not safe to delete; vms still exist: [some-vm]"))
+			})
+		})
+
+		Context("when the region has no vpc for the env", func() {
+			It("returns nil", func() {
+				ec2Client.DescribeVpcsCall.Returns.Output = &awsec2.DescribeVpcsOutput{}
+
+				err := client.ValidateEnvSafeToDelete("some-env-id")
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when describing the vpcs fails", func() {
+			It("returns an error", func() {
+				ec2Client.DescribeVpcsCall.Returns.Error = errors.New("auth failure")
+
+				err := client.ValidateEnvSafeToDelete("some-env-id")
+				Expect(err).To(MatchError("Describe vpcs: auth failure"))
+			})
+		})
+	})
+
 	Describe("ValidateSafeToDelete", func() {
 		var (
 			client    aws.Client
@@ -346,6 +1040,51 @@ var _ = Describe("Client", func() {
 			})
 		})
 
+		Context("when the vms have ids and tags", func() {
+			BeforeEach(func() {
+				ec2Client.DescribeInstancesCall.Returns.Output = &awsec2.DescribeInstancesOutput{
+					Reservations: []*awsec2.Reservation{{
+						Instances: []*awsec2.Instance{{
+							InstanceId: awslib.String("i-1"),
+							Tags: []*awsec2.Tag{
+								{Key: awslib.String("job"), Value: awslib.String("router")},
+								{Key: awslib.String("Name"), Value: awslib.String("router/0")},
+								{Key: awslib.String("deployment"), Value: awslib.String("cf")},
+							},
+						}, {
+							InstanceId: awslib.String("i-2"),
+						}},
+					}},
+				}
+			})
+
+			It("returns an error with the ids", func() {
+				err := client.ValidateSafeToDelete("some-vpc-id", "")
+				Expect(err).To(MatchError("vpc some-vpc-id is not safe to delete; vms still exist: [router/0 (i-1), unnamed (i-2)]"))
+			})
+
+			It("describes each vm with its tags", func() {
+				err := client.ValidateSafeToDelete("some-vpc-id", "")
+				Expect(err).To(BeAssignableToTypeOf(aws.VPCNotSafeToDeleteError{}))
+
+				Expect(err.(aws.VPCNotSafeToDeleteError).BlockingVMs()).To(Equal([]string{
+					"i-1 router/0 (deployment=cf, job=router)",
+					"i-2 unnamed",
+				}))
+			})
+
+			It("can be told apart from other errors", func() {
+				err := client.ValidateSafeToDelete("some-vpc-id", "")
+				Expect(errors.Is(err, aws.VPCNotSafeToDeleteError{})).To(BeTrue())
+				Expect(errors.Is(errors.New("some-error"), aws.VPCNotSafeToDeleteError{})).To(BeFalse())
+
+				var notSafe aws.VPCNotSafeToDeleteError
+				Expect(errors.As(fmt.Errorf("Verify network is empty: %w", err), &notSafe)).To(BeTrue())
+				Expect(notSafe.VPCID).To(Equal("some-vpc-id"))
+				Expect(notSafe.VMs).To(HaveLen(2))
+			})
+		})
+
 		Describe("failure cases", func() {
 			Context("when the describe instances call fails", func() {
 				BeforeEach(func() {
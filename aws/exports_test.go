@@ -14,6 +14,20 @@ func NewClientWithInjectedRoute53Client(route53Client Route53Client, logger logg
 	}
 }
 
+func NewClientWithInjectedS3Client(s3Client S3Client, logger logger) Client {
+	return Client{
+		s3Client: s3Client,
+		logger:   logger,
+	}
+}
+
+func NewClientWithInjectedKMSClient(kmsClient KMSClient, logger logger) Client {
+	return Client{
+		kmsClient: kmsClient,
+		logger:    logger,
+	}
+}
+
 func (c Client) GetEC2Client() EC2Client {
 	return c.ec2Client
 }
@@ -21,3 +35,11 @@ func (c Client) GetEC2Client() EC2Client {
 func (c Client) GetRoute53Client() Route53Client {
 	return c.route53Client
 }
+
+func (c Client) GetS3Client() S3Client {
+	return c.s3Client
+}
+
+func (c Client) GetKMSClient() KMSClient {
+	return c.kmsClient
+}
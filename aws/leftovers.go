@@ -0,0 +1,50 @@
+package aws
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	awsleftovers "github.com/genevieve/leftovers/aws"
+)
+
+type leftoversLogger interface {
+	Printf(m string, a ...interface{})
+	Println(m string)
+	PromptWithDetails(resourceType, resourceName string) bool
+	NoConfirm()
+}
+
+// Leftovers deletes the resources matching a filter in any region of the
+// account, not only the one bbl was configured with.
+type Leftovers struct {
+	client Client
+	logger leftoversLogger
+}
+
+func NewLeftovers(client Client, logger leftoversLogger) Leftovers {
+	return Leftovers{
+		client: client,
+		logger: logger,
+	}
+}
+
+func (l Leftovers) Regions() ([]string, error) {
+	return l.client.Regions()
+}
+
+// ValidateSafeToDeleteInRegion checks that the env's vpc in the region, if
+// there is one, has no vms left besides the ones bbl created.
+func (l Leftovers) ValidateSafeToDeleteInRegion(region, envID string) error {
+	creds := l.client.Credentials()
+	creds.Region = region
+
+	return NewClient(creds, helpers.HTTPSettings{}, l.client.logger).ValidateEnvSafeToDelete(envID)
+}
+
+func (l Leftovers) DeleteInRegion(region, filter string) error {
+	creds := l.client.Credentials()
+	leftovers, err := awsleftovers.NewLeftovers(l.logger, creds.AccessKeyID, creds.SecretAccessKey, region)
+	if err != nil {
+		return err
+	}
+
+	return leftovers.Delete(filter)
+}
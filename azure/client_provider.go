@@ -1,6 +1,8 @@
 package azure
 
 import (
+	"net/http"
+
 	"github.com/Azure/azure-sdk-for-go/arm/compute"
 	"github.com/Azure/azure-sdk-for-go/arm/resources/resources"
 	azurestorage "github.com/Azure/azure-sdk-for-go/arm/storage"
@@ -10,7 +12,9 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
-func NewClient(azureConfig storage.Azure) (Client, error) {
+// NewClient returns a client that sends its api calls and token requests with
+// httpClient.
+func NewClient(azureConfig storage.Azure, httpClient *http.Client) (Client, error) {
 	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, azureConfig.TenantID)
 	if err != nil {
 		return Client{}, err
@@ -20,18 +24,19 @@ func NewClient(azureConfig storage.Azure) (Client, error) {
 	if err != nil {
 		return Client{}, err
 	}
+	servicePrincipalToken.SetSender(httpClient)
 
 	ac := azurestorage.NewAccountsClient(azureConfig.SubscriptionID)
 	ac.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
-	ac.Sender = autorest.CreateSender(autorest.AsIs())
+	ac.Sender = autorest.DecorateSender(httpClient, autorest.AsIs())
 
 	vmsClient := compute.NewVirtualMachinesClient(azureConfig.SubscriptionID)
 	vmsClient.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
-	vmsClient.Sender = autorest.CreateSender(autorest.AsIs())
+	vmsClient.Sender = autorest.DecorateSender(httpClient, autorest.AsIs())
 
 	groupsClient := resources.NewGroupsClient(azureConfig.SubscriptionID)
 	groupsClient.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
-	groupsClient.Sender = autorest.CreateSender(autorest.AsIs())
+	groupsClient.Sender = autorest.DecorateSender(httpClient, autorest.AsIs())
 
 	client := Client{
 		azureVMsClient:    vmsClient,
@@ -2,14 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 
+	awsdefaults "github.com/aws/aws-sdk-go/aws/defaults"
 	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/approval"
 	"github.com/cloudfoundry/bosh-bootloader/aws"
 	"github.com/cloudfoundry/bosh-bootloader/azure"
 	"github.com/cloudfoundry/bosh-bootloader/backends"
@@ -20,12 +25,14 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/config"
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/metrics"
 	"github.com/cloudfoundry/bosh-bootloader/renderers"
 	"github.com/cloudfoundry/bosh-bootloader/runtimeconfig"
 	"github.com/cloudfoundry/bosh-bootloader/ssh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 	proxy "github.com/cloudfoundry/socks5-proxy"
+	isatty "github.com/mattn/go-isatty"
 	"github.com/spf13/afero"
 
 	awscloudconfig "github.com/cloudfoundry/bosh-bootloader/cloudconfig/aws"
@@ -62,26 +69,79 @@ func main() {
 	}
 	if globals.NoConfirm {
 		logger.NoConfirm()
+		stderrLogger.NoConfirm()
+	}
+	stdoutIsTerminal := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	if globals.NoTTY || (!stdoutIsTerminal && !globals.ForceTTY) {
+		logger.NoTTY()
+	}
+	stderrIsTerminal := isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())
+	if globals.NoTTY || (!stderrIsTerminal && !globals.ForceTTY) {
+		stderrLogger.NoTTY()
+	}
+	if globals.LogFormat == "json" {
+		promptWriter := terminalWriter()
+		logger.JSON(promptWriter)
+		stderrLogger.JSON(promptWriter)
 	}
 
 	// File IO
 	fs := afero.NewOsFs()
 	afs := &afero.Afero{Fs: fs}
 
+	// IAAS API settings
+	httpSettings := helpers.HTTPSettings{
+		Timeout:    globals.HTTPTimeout,
+		MaxRetries: globals.HTTPMaxRetries,
+	}
+	if globals.CACertFile != "" {
+		httpSettings.RootCAs, err = helpers.LoadCACertFile(globals.CACertFile)
+		if err != nil {
+			log.Fatalf("\n\n%s\n", err)
+		}
+
+		// The azure leftovers build their http clients on the default
+		// transport, so it trusts the CAs as well.
+		http.DefaultTransport = httpSettings.Transport()
+	}
+	if globals.RecordInteractions != "" {
+		httpSettings.Interactions = helpers.RecordInteractions(globals.RecordInteractions)
+	}
+	if globals.ReplayInteractions != "" {
+		httpSettings.Interactions, err = helpers.ReplayInteractions(globals.ReplayInteractions)
+		if err != nil {
+			log.Fatalf("\n\n%s\n", err)
+		}
+	}
+
+	// The clients that take an http client share this one. The aws and gcp
+	// leftovers build theirs on http.DefaultClient, so it is replaced too.
+	httpClient := httpSettings.Wrap(&http.Client{})
+	http.DefaultClient = httpClient
+
 	// bbl Configuration
 	garbageCollector := storage.NewGarbageCollector(afs)
 	stateStore := storage.NewStore(globals.StateDir, afs, garbageCollector)
 	patchDetector := storage.NewPatchDetector(globals.StateDir, logger)
 	stateMigrator := storage.NewMigrator(stateStore, afs)
-	stateMerger := config.NewMerger(afs)
+	stateMerger := config.NewMerger(afs, awsdefaults.CredChain(awsdefaults.Config(), awsdefaults.Handlers()))
 	storageProvider := backends.NewProvider()
 	stateDownloader := config.NewDownloader(storageProvider)
-	newConfig := config.NewConfig(stateBootstrap, stateMigrator, stateMerger, stateDownloader, stderrLogger, afs)
+	newConfig := config.NewConfig(stateBootstrap, stateMigrator, stateMerger, stateDownloader, stderrLogger, afs, config.NewNetworkClientProvider(httpSettings, logger))
 
 	appConfig, err := newConfig.Bootstrap(globals, remainingArgs, len(os.Args))
 	if err != nil {
 		log.Fatalf("\n\n%s\n", err)
 	}
+	logger.SetEnvID(appConfig.State.EnvID)
+	stderrLogger.SetEnvID(appConfig.State.EnvID)
+
+	// destroy logs its progress to stderr and keeps stdout for the results
+	// that scripts read, such as --print-config.
+	progressLogger, progressOut := logger, io.Writer(os.Stdout)
+	if appConfig.Command == "destroy" {
+		progressLogger, progressOut = stderrLogger, os.Stderr
+	}
 
 	// Utilities
 	envIDGenerator := helpers.NewEnvIDGenerator(rand.Reader)
@@ -91,6 +151,12 @@ func main() {
 	sshCLI := ssh.NewCLI(os.Stdin, os.Stdout, os.Stderr)
 	pathFinder := helpers.NewPathFinder()
 
+	// Destroy redacts the secrets in the state from what terraform and the
+	// bosh cli print, unless it is run with --no-scrub.
+	outputScrubber := helpers.NewOutputScrubber()
+	scrubbedStdout := outputScrubber.Writer(os.Stdout)
+	scrubbedStderr := outputScrubber.Writer(os.Stderr)
+
 	// Terraform
 	terraformOutputBuffer := bytes.NewBuffer([]byte{})
 	dotTerraformDir := filepath.Join(appConfig.Global.StateDir, "terraform", ".terraform")
@@ -100,14 +166,14 @@ func main() {
 		out          io.Writer
 	)
 	if appConfig.Global.Debug {
-		errBuffer := io.MultiWriter(os.Stderr, terraformOutputBuffer)
+		errBuffer := io.MultiWriter(scrubbedStderr, terraformOutputBuffer)
 		terraformCLI = terraform.NewCLI(errBuffer, terraformOutputBuffer, dotTerraformDir)
-		out = os.Stdout
+		out = outputScrubber.Writer(progressOut)
 	} else {
 		terraformCLI = bufferingCLI
 		out = ioutil.Discard
 	}
-	terraformExecutor := terraform.NewExecutor(terraformCLI, bufferingCLI, stateStore, afs, appConfig.Global.Debug, out)
+	terraformExecutor := terraform.NewExecutor(terraformCLI, bufferingCLI, stateStore, afs, appConfig.Global.Debug, out, appConfig.Global.TFWorkspace)
 
 	// BOSH
 	hostKey := proxy.NewHostKey()
@@ -116,12 +182,12 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	boshCommand := bosh.NewCLI(os.Stderr, boshPath)
-	boshExecutor := bosh.NewExecutor(boshCommand, afs)
+	boshCommand := bosh.NewCLI(scrubbedStderr, boshPath)
+	boshExecutor := bosh.NewExecutor(boshCommand, afs, scrubbedStdout, scrubbedStderr)
 	sshKeyGetter := bosh.NewSSHKeyGetter(stateStore, afs)
 	allProxyGetter := bosh.NewAllProxyGetter(sshKeyGetter, afs)
 	credhubGetter := bosh.NewCredhubGetter(stateStore, afs)
-	boshManager := bosh.NewManager(boshExecutor, logger, stateStore, sshKeyGetter, afs)
+	boshManager := bosh.NewManager(boshExecutor, progressLogger, stateStore, sshKeyGetter, afs)
 	boshClientProvider := bosh.NewClientProvider(allProxyGetter, socks5Proxy, sshKeyGetter, boshPath)
 
 	// Clients that require IAAS credentials.
@@ -131,7 +197,21 @@ func main() {
 		networkDeletionValidator commands.NetworkDeletionValidator
 
 		// function extract InitializeLeftovers
-		leftovers commands.FilteredDeleter
+		leftovers             commands.FilteredDeleter
+		diskDeleter           commands.DiskDeleter
+		connectivityChecker   commands.ConnectivityChecker
+		vpcPeeringChecker     commands.VPCPeeringChecker
+		regionalDeleter       commands.RegionalDeleter
+		vmChecker             commands.VMChecker
+		tombstoneWriter       commands.TombstoneWriter
+		dnsReferenceChecker   commands.DNSReferenceChecker
+		imageCleaner          commands.ImageCleaner
+		kmsCleaner            commands.KMSCleaner
+		regionalSafetyChecker commands.RegionalSafetyChecker
+		routerCleaner         commands.RouterCleaner
+		locationChecker       commands.LocationChecker
+		residualLister        commands.ResidualLister
+		credentialRefresher   commands.CredentialRefresher
 
 		awsClient aws.Client
 	)
@@ -139,24 +219,50 @@ func main() {
 	if appConfig.CommandModifiesState {
 		switch appConfig.State.IAAS {
 		case "aws":
-			awsClient = aws.NewClient(appConfig.State.AWS, logger)
+			awsClient = aws.NewClient(appConfig.State.AWS, httpSettings, progressLogger)
 
 			networkDeletionValidator = awsClient
 			networkClient = awsClient
+			connectivityChecker = awsClient
+			vpcPeeringChecker = awsClient
+			vmChecker = awsClient
+			tombstoneWriter = awsClient
+			dnsReferenceChecker = awsClient
+			imageCleaner = awsClient
+			kmsCleaner = awsClient
+			locationChecker = awsClient
+			credentialRefresher = config.NewCredentialRefresher(globals, stateBootstrap, stateMerger, awsClient)
+			regionalLeftovers := aws.NewLeftovers(awsClient, progressLogger)
+			regionalDeleter = regionalLeftovers
+			regionalSafetyChecker = regionalLeftovers
+
+			leftovers, err = awsleftovers.NewLeftovers(progressLogger, appConfig.State.AWS.AccessKeyID, appConfig.State.AWS.SecretAccessKey, appConfig.State.AWS.Region)
+			if err != nil {
+				log.Fatalf("\n\n%s\n", err)
+			}
 
-			leftovers, err = awsleftovers.NewLeftovers(logger, appConfig.State.AWS.AccessKeyID, appConfig.State.AWS.SecretAccessKey, appConfig.State.AWS.Region)
+			residualRecorder := helpers.NewLeftoversRecorder()
+			residualLeftovers, err := awsleftovers.NewLeftovers(residualRecorder, appConfig.State.AWS.AccessKeyID, appConfig.State.AWS.SecretAccessKey, appConfig.State.AWS.Region)
 			if err != nil {
 				log.Fatalf("\n\n%s\n", err)
 			}
+			residualLister = helpers.NewResidualLister(residualLeftovers, residualRecorder)
 
 		case "gcp":
-			gcpClient, err := gcp.NewClient(appConfig.State.GCP, "")
+			gcpClient, err := gcp.NewClient(appConfig.State.GCP, httpSettings, "")
 			if err != nil {
 				log.Fatalf("\n\n%s\n", err)
 			}
 
 			networkDeletionValidator = gcpClient
 			networkClient = gcpClient
+			diskDeleter = gcpClient
+			connectivityChecker = gcpClient
+			vmChecker = gcpClient
+			imageCleaner = gcpClient
+			routerCleaner = gcpClient
+			locationChecker = gcpClient
+			credentialRefresher = config.NewCredentialRefresher(globals, stateBootstrap, stateMerger, nil)
 
 			gcpZonerHack := config.NewGCPZonerHack(gcpClient)
 			stateWithZones, err := gcpZonerHack.SetZones(appConfig.State)
@@ -165,13 +271,20 @@ func main() {
 			}
 			appConfig.State = stateWithZones
 
-			leftovers, err = gcpleftovers.NewLeftovers(logger, appConfig.State.GCP.ServiceAccountKeyPath)
+			leftovers, err = gcpleftovers.NewLeftovers(progressLogger, appConfig.State.GCP.ServiceAccountKeyPath)
+			if err != nil {
+				log.Fatalf("\n\n%s\n", err)
+			}
+
+			residualRecorder := helpers.NewLeftoversRecorder()
+			residualLeftovers, err := gcpleftovers.NewLeftovers(residualRecorder, appConfig.State.GCP.ServiceAccountKeyPath)
 			if err != nil {
 				log.Fatalf("\n\n%s\n", err)
 			}
+			residualLister = helpers.NewResidualLister(residualLeftovers, residualRecorder)
 
 		case "azure":
-			azureClient, err := azure.NewClient(appConfig.State.Azure)
+			azureClient, err := azure.NewClient(appConfig.State.Azure, httpClient)
 			if err != nil {
 				log.Fatalf("\n\n%s\n", err)
 			}
@@ -179,16 +292,30 @@ func main() {
 			networkDeletionValidator = azureClient
 			networkClient = azureClient
 
-			leftovers, err = azureleftovers.NewLeftovers(logger, appConfig.State.Azure.ClientID, appConfig.State.Azure.ClientSecret, appConfig.State.Azure.SubscriptionID, appConfig.State.Azure.TenantID)
+			leftovers, err = azureleftovers.NewLeftovers(progressLogger, appConfig.State.Azure.ClientID, appConfig.State.Azure.ClientSecret, appConfig.State.Azure.SubscriptionID, appConfig.State.Azure.TenantID)
+			if err != nil {
+				log.Fatalf("\n\n%s\n", err)
+			}
+
+			residualRecorder := helpers.NewLeftoversRecorder()
+			residualLeftovers, err := azureleftovers.NewLeftovers(residualRecorder, appConfig.State.Azure.ClientID, appConfig.State.Azure.ClientSecret, appConfig.State.Azure.SubscriptionID, appConfig.State.Azure.TenantID)
 			if err != nil {
 				log.Fatalf("\n\n%s\n", err)
 			}
+			residualLister = helpers.NewResidualLister(residualLeftovers, residualRecorder)
 		case "vsphere":
-			vSphereLogger := application.NewLogger(os.Stdout, os.Stdin)
+			vSphereLogger := application.NewLogger(progressOut, os.Stdin)
 			leftovers, err = vsphereleftovers.NewLeftovers(vSphereLogger, appConfig.State.VSphere.VCenterIP, appConfig.State.VSphere.VCenterUser, appConfig.State.VSphere.VCenterPassword, appConfig.State.VSphere.VCenterDC)
 			if err != nil {
 				log.Fatalf("\n\n%s\n", err)
 			}
+
+			residualRecorder := helpers.NewLeftoversRecorder()
+			residualLeftovers, err := vsphereleftovers.NewLeftovers(residualRecorder, appConfig.State.VSphere.VCenterIP, appConfig.State.VSphere.VCenterUser, appConfig.State.VSphere.VCenterPassword, appConfig.State.VSphere.VCenterDC)
+			if err != nil {
+				log.Fatalf("\n\n%s\n", err)
+			}
+			residualLister = helpers.NewResidualLister(residualLeftovers, residualRecorder)
 		}
 	}
 
@@ -207,7 +334,7 @@ func main() {
 		templateGenerator = awsterraform.NewTemplateGenerator()
 		inputGenerator = awsterraform.NewInputGenerator(awsClient)
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, progressLogger)
 
 		cloudConfigOpsGenerator = awscloudconfig.NewOpsGenerator(terraformManager, awsClient)
 
@@ -216,7 +343,7 @@ func main() {
 		templateGenerator = azureterraform.NewTemplateGenerator()
 		inputGenerator = azureterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, progressLogger)
 
 		cloudConfigOpsGenerator = azurecloudconfig.NewOpsGenerator(terraformManager)
 
@@ -225,7 +352,7 @@ func main() {
 		templateGenerator = gcpterraform.NewTemplateGenerator()
 		inputGenerator = gcpterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, progressLogger)
 
 		cloudConfigOpsGenerator = gcpcloudconfig.NewOpsGenerator(terraformManager)
 
@@ -234,7 +361,7 @@ func main() {
 		templateGenerator = vsphereterraform.NewTemplateGenerator()
 		inputGenerator = vsphereterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, progressLogger)
 
 		cloudConfigOpsGenerator = vspherecloudconfig.NewOpsGenerator(terraformManager)
 
@@ -242,7 +369,7 @@ func main() {
 		templateGenerator = openstackterraform.NewTemplateGenerator()
 		inputGenerator = openstackterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, progressLogger)
 
 		cloudConfigOpsGenerator = openstackcloudconfig.NewOpsGenerator(terraformManager)
 	}
@@ -267,7 +394,48 @@ func main() {
 	commandSet["plan"] = plan
 	sshKeyDeleter := bosh.NewSSHKeyDeleter(stateStore, afs)
 	commandSet["rotate"] = commands.NewRotate(stateValidator, sshKeyDeleter, up)
-	commandSet["destroy"] = commands.NewDestroy(plan, logger, boshManager, stateStore, stateValidator, terraformManager, networkDeletionValidator)
+	metricsPusher := metrics.NewPushgateway(httpClient)
+	var approvalService commands.ApprovalService
+	if !globals.NoConfirm {
+		approvalService = approval.NewService(httpClient, os.Getenv("USER"))
+	}
+	destroy := commands.NewDestroy(plan, progressLogger, logger, boshManager, stateStore, stateValidator, terraformManager, networkDeletionValidator, commands.DestroyDependencies{
+		MetricsPusher:               metricsPusher,
+		DirectorCredentialRefresher: config.NewCredentialRefresher(globals, stateBootstrap, stateMerger, nil),
+		GitStatus:                   helpers.NewGitStatus(),
+		BOSHClientProvider:          boshClientProvider,
+		DestroyKeys:                 storage.NewDestroyKeys(globals.StateDir, afs),
+		DestroySchedule:             storage.NewDestroySchedule(afs),
+		RecoveryBundle:              storage.NewRecoveryBundle(globals.StateDir, afs),
+		HookRunner:                  helpers.NewHookRunner(),
+		OutputScrubber:              outputScrubber,
+
+		Leftovers:             leftovers,
+		CredentialRefresher:   credentialRefresher,
+		DiskDeleter:           diskDeleter,
+		ConnectivityChecker:   connectivityChecker,
+		VPCPeeringChecker:     vpcPeeringChecker,
+		RegionalDeleter:       regionalDeleter,
+		VMChecker:             vmChecker,
+		TombstoneWriter:       tombstoneWriter,
+		DNSReferenceChecker:   dnsReferenceChecker,
+		ImageCleaner:          imageCleaner,
+		ApprovalService:       approvalService,
+		KMSCleaner:            kmsCleaner,
+		RegionalSafetyChecker: regionalSafetyChecker,
+		RouterCleaner:         routerCleaner,
+		LocationChecker:       locationChecker,
+		ResidualLister:        residualLister,
+	})
+	if appConfig.Command == "destroy" || appConfig.Command == "down" {
+		destroy = destroy.WithContext(interruptContext())
+	}
+	destroy = destroy.WithNoConfirm(globals.NoConfirm)
+	if appConfig.IAASOverridden {
+		destroy = destroy.WithStoredIAAS(appConfig.StoredIAAS)
+	}
+	destroy = destroy.WithEnvDestroyer(helpers.NewEnvDestroyer(os.Args[0], os.Args[1:]))
+	commandSet["destroy"] = destroy
 	commandSet["down"] = commandSet["destroy"]
 	commandSet["cleanup-leftovers"] = commands.NewCleanupLeftovers(leftovers)
 	commandSet["leftovers"] = commandSet["cleanup-leftovers"]
@@ -275,7 +443,7 @@ func main() {
 	commandSet["jumpbox-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.JumpboxAddressPropertyName)
 	commandSet["director-address"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorAddressPropertyName)
 	commandSet["director-username"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorUsernamePropertyName)
-	commandSet["director-password"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorPasswordPropertyName)
+	commandSet["director-password"] = commands.NewDirectorPassword(logger, stderrLogger, stateValidator)
 	commandSet["director-ca-cert"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.DirectorCACertPropertyName)
 	commandSet["ssh-key"] = commands.NewSSHKey(logger, stateValidator, sshKeyGetter)
 	commandSet["validate"] = commands.NewValidate(plan, stateStore, terraformManager)
@@ -292,3 +460,31 @@ func main() {
 		log.Fatalf("\n\n%s\n", err)
 	}
 }
+
+// interruptContext is cancelled by the first interrupt, which lets destroy
+// stop after its current phase and save the state. A second interrupt exits
+// right away.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		signal.Reset(os.Interrupt)
+		cancel()
+	}()
+
+	return ctx
+}
+
+// terminalWriter is where prompts go when logging json, so that they reach
+// the operator rather than the log pipeline. It falls back to stderr when
+// there is no controlling terminal.
+func terminalWriter() io.Writer {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return os.Stderr
+	}
+	return tty
+}
@@ -16,6 +16,7 @@ import (
 type ConfigUpdater interface {
 	UpdateCloudConfig(yaml []byte) error
 	Info() (Info, error)
+	Deployments() ([]string, error)
 }
 
 type RuntimeConfigUpdater interface {
@@ -102,17 +103,8 @@ func (c Client) UpdateConfig(configType, name string, content []byte) error {
 		return err
 	}
 	request.Header.Set("Content-Type", "application/json")
-	ctx := context.Background()
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.httpClient)
 
-	conf := &clientcredentials.Config{
-		ClientID:     c.username,
-		ClientSecret: c.password,
-		TokenURL:     fmt.Sprintf("%s/oauth/token", c.UAAAddress),
-	}
-
-	httpClient := conf.Client(ctx)
-	response, err := makeRequests(httpClient, request)
+	response, err := makeRequests(c.uaaHTTPClient(), request)
 	if err != nil {
 		return err
 	}
@@ -128,6 +120,49 @@ func (c Client) UpdateCloudConfig(yaml []byte) error {
 	return c.UpdateConfig("cloud", "default", yaml)
 }
 
+func (c Client) Deployments() ([]string, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/deployments", c.DirectorAddress), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := makeRequests(c.uaaHTTPClient(), request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var deployments []struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&deployments); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, deployment := range deployments {
+		names = append(names, deployment.Name)
+	}
+
+	return names, nil
+}
+
+func (c Client) uaaHTTPClient() *http.Client {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, c.httpClient)
+
+	conf := &clientcredentials.Config{
+		ClientID:     c.username,
+		ClientSecret: c.password,
+		TokenURL:     fmt.Sprintf("%s/oauth/token", c.UAAAddress),
+	}
+
+	return conf.Client(ctx)
+}
+
 func makeRequests(httpClient *http.Client, request *http.Request) (*http.Response, error) {
 	var (
 		response *http.Response
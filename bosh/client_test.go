@@ -77,6 +77,15 @@ var _ = Describe("Client", func() {
 				          "uuid": "some-uuid",
 				          "version": "some-version"
 		                }`))
+			case "/deployments":
+				if failStatus != 0 {
+					w.WriteHeader(failStatus)
+					return
+				}
+
+				token = req.Header.Get("Authorization")
+
+				w.Write([]byte(`[{"name": "cf"}, {"name": "concourse"}]`))
 			case "/configs":
 				if failStatus != 0 {
 					w.WriteHeader(failStatus)
@@ -205,6 +214,33 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("Deployments", func() {
+		It("lists the deployment names using a uaa token", func() {
+			fakeBOSH.StartTLS()
+			client := bosh.NewClient(httpClient, fakeBOSH.URL, fakeBOSH.URL, "some-username", "some-password", string(ca))
+
+			deployments, err := client.Deployments()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token).To(Equal("Bearer some-uaa-token"))
+			Expect(deployments).To(Equal([]string{"cf", "concourse"}))
+		})
+
+		Context("when the response is not StatusOK", func() {
+			BeforeEach(func() {
+				failStatus = http.StatusUnauthorized
+			})
+
+			It("returns an error", func() {
+				fakeBOSH.StartTLS()
+				client := bosh.NewClient(httpClient, fakeBOSH.URL, fakeBOSH.URL, "some-username", "some-password", string(ca))
+
+				_, err := client.Deployments()
+				Expect(err).To(MatchError("unexpected http response 401 Unauthorized"))
+			})
+		})
+	})
+
 	Describe("UpdateCloudConfig", func() {
 		It("uses UAA to get a token in order to upload the cloud-config", func() {
 			fakeBOSH.StartTLS()
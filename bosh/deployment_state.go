@@ -0,0 +1,88 @@
+package bosh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The bosh-init and early bosh cli releases recorded the manifest checksum of
+// a create-env deployment as current_manifest_sha1. Current bosh cli releases
+// read current_manifest_sha instead.
+const (
+	legacyManifestSHAKey = "current_manifest_sha1"
+	manifestSHAKey       = "current_manifest_sha"
+)
+
+// deploymentStateVersion detects which schema a create-env state file uses.
+func deploymentStateVersion(state map[string]json.RawMessage) int {
+	if _, ok := state[legacyManifestSHAKey]; ok {
+		return 1
+	}
+	return 2
+}
+
+// upgradeDeploymentState renames the manifest checksum of a state file
+// written by an older bosh cli to the key the bosh cli that bbl runs reads.
+// Only that key changes; every other byte of the file is kept. States that
+// already have the current key, and contents that are not a state file, are
+// returned unchanged.
+func upgradeDeploymentState(contents []byte) ([]byte, bool, error) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &state); err != nil {
+		return contents, false, nil
+	}
+
+	if deploymentStateVersion(state) != 1 {
+		return contents, false, nil
+	}
+	if _, ok := state[manifestSHAKey]; ok {
+		return contents, false, nil
+	}
+
+	start, end, err := topLevelKeyOffsets(contents, legacyManifestSHAKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var upgraded []byte
+	upgraded = append(upgraded, contents[:start]...)
+	upgraded = append(upgraded, strconv.Quote(manifestSHAKey)...)
+	upgraded = append(upgraded, contents[end:]...)
+
+	return upgraded, true, nil
+}
+
+// topLevelKeyOffsets returns where the quoted key of the top level object
+// starts and ends in contents.
+func topLevelKeyOffsets(contents []byte, key string) (int, int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	if _, err := decoder.Token(); err != nil {
+		return 0, 0, err // not tested
+	}
+
+	quoted := strconv.Quote(key)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, err // not tested
+		}
+
+		end := int(decoder.InputOffset())
+		start := end - len(quoted)
+		if token == key {
+			if start < 0 || string(contents[start:end]) != quoted {
+				return 0, 0, fmt.Errorf("%s is escaped in the state file", key)
+			}
+			return start, end, nil
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return 0, 0, err // not tested
+		}
+	}
+
+	return 0, 0, fmt.Errorf("%s is not in the state file", key) // not tested
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -22,14 +23,22 @@ type executorFs interface {
 }
 
 type Executor struct {
-	cli cli
-	fs  executorFs
+	cli    cli
+	fs     executorFs
+	stdout io.Writer
+	stderr io.Writer
 }
 
 type DirInput struct {
 	StateDir   string
 	VarsDir    string
 	Deployment string
+
+	// Debug runs delete-env with the bosh cli logging at debug level.
+	Debug bool
+
+	// Deadline stops delete-env once it has passed, unless it is zero.
+	Deadline time.Time
 }
 
 type cli interface {
@@ -48,10 +57,14 @@ var (
 	boshDeploymentRepo    = "vendor/github.com/cloudfoundry/bosh-deployment"
 )
 
-func NewExecutor(cmd cli, fs executorFs) Executor {
+// NewExecutor returns an executor that writes the output of the create-env
+// and delete-env scripts to stdout and stderr.
+func NewExecutor(cmd cli, fs executorFs, stdout, stderr io.Writer) Executor {
 	return Executor{
-		cli: cmd,
-		fs:  fs,
+		cli:    cmd,
+		fs:     fs,
+		stdout: stdout,
+		stderr: stderr,
 	}
 }
 
@@ -327,8 +340,8 @@ func (e Executor) CreateEnv(input DirInput, state storage.State) (string, error)
 	}
 
 	cmd := exec.Command(createEnvScript)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = e.stdout
+	cmd.Stderr = e.stderr
 
 	err = cmd.Run()
 	if err != nil {
@@ -350,6 +363,11 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 		return nil
 	}
 
+	err = e.upgradeDeploymentState(input.VarsDir, input.Deployment)
+	if err != nil {
+		return err
+	}
+
 	os.Setenv("BBL_STATE_DIR", input.StateDir)
 
 	deleteEnvScript := filepath.Join(input.StateDir, fmt.Sprintf("delete-%s-override.sh", input.Deployment))
@@ -376,11 +394,42 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 		os.Setenv("BBL_VSPHERE_VCENTER_PASSWORD", state.VSphere.VCenterPassword)
 	}
 
+	// Only destroy deletes envs, and it keeps stdout for its results.
 	cmd := exec.Command(deleteEnvScript)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = e.stderr
+	cmd.Stderr = e.stderr
+	if input.Debug {
+		cmd.Env = append(os.Environ(), "BOSH_LOG_LEVEL=debug")
+	}
 
-	err = cmd.Run()
+	if input.Deadline.IsZero() {
+		err = cmd.Run()
+		if err != nil {
+			return fmt.Errorf("Run bosh delete-env %s: %s", input.Deployment, err)
+		}
+		return nil
+	}
+
+	// The script runs the bosh cli as a child, so killing the script alone
+	// would leave bosh running. Its whole process group is killed instead.
+	err = startInProcessGroup(cmd)
+	if err != nil {
+		return fmt.Errorf("Run bosh delete-env %s: %s", input.Deployment, err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	timer := time.NewTimer(time.Until(input.Deadline))
+	defer timer.Stop()
+
+	select {
+	case err = <-done:
+	case <-timer.C:
+		killProcessGroup(cmd)
+		<-done
+		return fmt.Errorf("Run bosh delete-env %s: timed out", input.Deployment)
+	}
 	if err != nil {
 		return fmt.Errorf("Run bosh delete-env %s: %s", input.Deployment, err)
 	}
@@ -388,23 +437,64 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 	return nil
 }
 
-func (e Executor) deploymentExists(varsDir, deployment string) (bool, error) {
-	var deploymentBoshState string
+func deploymentStatePath(varsDir, deployment string) (string, error) {
 	switch deployment {
 	case "director":
-		deploymentBoshState = filepath.Join(varsDir, "bosh-state.json")
+		return filepath.Join(varsDir, "bosh-state.json"), nil
 	case "jumpbox":
-		deploymentBoshState = filepath.Join(varsDir, "jumpbox-state.json")
+		return filepath.Join(varsDir, "jumpbox-state.json"), nil
 	default:
-		return false, fmt.Errorf("Executor doesn't know how to delete a deployed %s", deployment)
+		return "", fmt.Errorf("Executor doesn't know how to delete a deployed %s", deployment)
 	}
-	_, err := e.fs.Stat(deploymentBoshState)
+}
+
+func (e Executor) deploymentExists(varsDir, deployment string) (bool, error) {
+	deploymentBoshState, err := deploymentStatePath(varsDir, deployment)
+	if err != nil {
+		return false, err
+	}
+	_, err = e.fs.Stat(deploymentBoshState)
 	if err != nil {
 		return false, nil
 	}
 	return true, nil
 }
 
+// upgradeDeploymentState lets delete-env read a state file written by an
+// older bosh cli, for example one that bootstrapped the director from a
+// container. The file is backed up next to itself before it is rewritten.
+func (e Executor) upgradeDeploymentState(varsDir, deployment string) error {
+	deploymentBoshState, err := deploymentStatePath(varsDir, deployment)
+	if err != nil {
+		return err
+	}
+
+	contents, err := e.fs.ReadFile(deploymentBoshState)
+	if err != nil {
+		return fmt.Errorf("Read %s deployment state: %s", deployment, err)
+	}
+
+	upgraded, changed, err := upgradeDeploymentState(contents)
+	if err != nil {
+		return fmt.Errorf("Upgrade %s deployment state: %s", deployment, err) // not tested
+	}
+	if !changed {
+		return nil
+	}
+
+	err = e.fs.WriteFile(deploymentBoshState+".bak", contents, storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Back up %s deployment state: %s", deployment, err)
+	}
+
+	err = e.fs.WriteFile(deploymentBoshState, upgraded, storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write %s deployment state: %s", deployment, err)
+	}
+
+	return nil
+}
+
 func (e Executor) Path() string {
 	return e.cli.GetBOSHPath()
 }
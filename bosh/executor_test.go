@@ -1,11 +1,15 @@
 package bosh_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -62,7 +66,7 @@ var _ = Describe("Executor", func() {
 			StateDir: stateDir,
 		}
 
-		executor = bosh.NewExecutor(cli, fs)
+		executor = bosh.NewExecutor(cli, fs, ioutil.Discard, ioutil.Discard)
 	})
 
 	Describe("PlanJumpbox", func() {
@@ -466,7 +470,7 @@ var _ = Describe("Executor", func() {
 			stateDir, err = fs.TempDir("", "")
 			Expect(err).NotTo(HaveOccurred())
 
-			executor = bosh.NewExecutor(cli, fs)
+			executor = bosh.NewExecutor(cli, fs, ioutil.Discard, ioutil.Discard)
 
 			dirInput = bosh.DirInput{
 				Deployment: "some-deployment",
@@ -652,6 +656,7 @@ var _ = Describe("Executor", func() {
 			deleteEnvPath string
 			varsDir       string
 			stateDir      string
+			output        *bytes.Buffer
 
 			dirInput bosh.DirInput
 			state    storage.State
@@ -666,7 +671,8 @@ var _ = Describe("Executor", func() {
 			stateDir, err = fs.TempDir("", "")
 			Expect(err).NotTo(HaveOccurred())
 
-			executor = bosh.NewExecutor(cli, fs)
+			output = bytes.NewBuffer([]byte{})
+			executor = bosh.NewExecutor(cli, fs, ioutil.Discard, output)
 
 			dirInput = bosh.DirInput{
 				Deployment: "director",
@@ -716,6 +722,67 @@ var _ = Describe("Executor", func() {
 			})
 		})
 
+		Context("when debug is requested", func() {
+			BeforeEach(func() {
+				deleteEnvContents := fmt.Sprintf("#!/bin/bash\necho $BOSH_LOG_LEVEL > %s/delete-env-output\n", varsDir)
+				fs.WriteFile(deleteEnvPath, []byte(deleteEnvContents), storage.ScriptMode)
+			})
+
+			AfterEach(func() {
+				fs.Remove(filepath.Join(varsDir, "delete-env-output"))
+			})
+
+			It("runs delete-env with the bosh cli logging at debug level", func() {
+				dirInput.Debug = true
+
+				err := executor.DeleteEnv(dirInput, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				output, err := fs.ReadFile(filepath.Join(varsDir, "delete-env-output"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(output)).To(Equal("debug\n"))
+			})
+
+			It("writes the debug output of delete-env to the writer it was given", func() {
+				fs.WriteFile(deleteEnvPath, []byte("#!/bin/bash\necho some debug output\necho some error >&2\n"), storage.ScriptMode)
+				dirInput.Debug = true
+
+				err := executor.DeleteEnv(dirInput, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(output.String()).To(ContainSubstring("some debug output\n"))
+				Expect(output.String()).To(ContainSubstring("some error\n"))
+			})
+		})
+
+		Context("when the deadline passes", func() {
+			var markerPath string
+
+			BeforeEach(func() {
+				markerPath = filepath.Join(varsDir, "still-running")
+				deleteEnvContents := fmt.Sprintf("#!/bin/bash\n(sleep 1 && touch %s) &\nwait\n", markerPath)
+				fs.WriteFile(deleteEnvPath, []byte(deleteEnvContents), storage.ScriptMode)
+			})
+
+			AfterEach(func() {
+				fs.Remove(markerPath)
+			})
+
+			It("stops delete-env and the processes it started, and returns an error", func() {
+				dirInput.Deadline = time.Now().Add(100 * time.Millisecond)
+
+				started := time.Now()
+				err := executor.DeleteEnv(dirInput, state)
+				Expect(err).To(MatchError("Run bosh delete-env director: timed out"))
+				Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+
+				Consistently(func() bool {
+					_, err := fs.Stat(markerPath)
+					return err == nil
+				}, 1500*time.Millisecond).Should(BeFalse())
+			})
+		})
+
 		Context("when the user tries to delete a jumpbox", func() {
 			BeforeEach(func() {
 				dirInput.Deployment = "jumpbox"
@@ -745,6 +812,67 @@ var _ = Describe("Executor", func() {
 			})
 		})
 
+		Context("when the deployment state was written by an older bosh cli", func() {
+			It("upgrades the state before deleting", func() {
+				legacyState, err := fs.ReadFile("fixtures/bosh-state-v1.json")
+				Expect(err).NotTo(HaveOccurred())
+				err = fs.WriteFile(filepath.Join(varsDir, "bosh-state.json"), legacyState, storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = executor.DeleteEnv(dirInput, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				currentState, err := fs.ReadFile("fixtures/bosh-state-v2.json")
+				Expect(err).NotTo(HaveOccurred())
+				upgradedState, err := fs.ReadFile(filepath.Join(varsDir, "bosh-state.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(upgradedState).To(MatchJSON(currentState))
+
+				By("renaming only the manifest checksum key", func() {
+					Expect(string(upgradedState)).To(Equal(strings.Replace(string(legacyState), `"current_manifest_sha1"`, `"current_manifest_sha"`, 1)))
+				})
+
+				By("backing up the state first", func() {
+					backup, err := fs.ReadFile(filepath.Join(varsDir, "bosh-state.json.bak"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(backup).To(Equal(legacyState))
+				})
+			})
+
+			It("leaves a state that already has the current key as it is", func() {
+				legacyState, err := fs.ReadFile("fixtures/bosh-state-v1.json")
+				Expect(err).NotTo(HaveOccurred())
+				bothKeys := strings.Replace(string(legacyState), `"current_manifest_sha1"`, `"current_manifest_sha": "some-sha",
+    "current_manifest_sha1"`, 1)
+				err = fs.WriteFile(filepath.Join(varsDir, "bosh-state.json"), []byte(bothKeys), storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = executor.DeleteEnv(dirInput, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				deletedState, err := fs.ReadFile(filepath.Join(varsDir, "bosh-state.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(deletedState)).To(Equal(bothKeys))
+
+				_, err = fs.Stat(filepath.Join(varsDir, "bosh-state.json.bak"))
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("leaves a current state as it is", func() {
+				currentState, err := fs.ReadFile("fixtures/bosh-state-v2.json")
+				Expect(err).NotTo(HaveOccurred())
+				err = fs.WriteFile(filepath.Join(varsDir, "bosh-state.json"), currentState, storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = executor.DeleteEnv(dirInput, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				deletedState, err := fs.ReadFile(filepath.Join(varsDir, "bosh-state.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deletedState).To(Equal(currentState))
+			})
+		})
+
 		Context("when the user tries to delete an unfamiliar deployment-type-thing", func() {
 			BeforeEach(func() {
 				dirInput.Deployment = "garbaggio-deployment"
@@ -873,7 +1001,7 @@ var _ = Describe("Executor", func() {
 				return nil
 			}
 
-			executor = bosh.NewExecutor(cli, fs)
+			executor = bosh.NewExecutor(cli, fs, ioutil.Discard, ioutil.Discard)
 		})
 
 		It("returns the correctly trimmed version", func() {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -29,6 +30,10 @@ type Manager struct {
 	stateStore   stateStore
 	sshKeyGetter sshKeyGetter
 	fs           managerFs
+
+	deleteEnvDebug bool
+
+	deleteEnvDeadline time.Time
 }
 
 type directorVars struct {
@@ -75,6 +80,18 @@ func NewManager(executor executor, logger logger, stateStore stateStore, sshKeyG
 	}
 }
 
+// SetDeleteEnvDebug makes the director and jumpbox deletions run bosh
+// delete-env with debug logging.
+func (m *Manager) SetDeleteEnvDebug(debug bool) {
+	m.deleteEnvDebug = debug
+}
+
+// SetDeleteEnvDeadline stops the director and jumpbox deletions once the
+// deadline has passed. The zero time leaves them unbounded.
+func (m *Manager) SetDeleteEnvDeadline(deadline time.Time) {
+	m.deleteEnvDeadline = deadline
+}
+
 func (m *Manager) Path() string {
 	return m.executor.Path()
 }
@@ -268,6 +285,8 @@ func (m *Manager) DeleteDirector(state storage.State, terraformOutputs terraform
 		Deployment: "director",
 		StateDir:   stateDir,
 		VarsDir:    varsDir,
+		Debug:      m.deleteEnvDebug,
+		Deadline:   m.deleteEnvDeadline,
 	}
 
 	err = m.executor.WriteDeploymentVars(dirInput, m.GetDirectorDeploymentVars(state, terraformOutputs))
@@ -320,6 +339,8 @@ func (m *Manager) DeleteJumpbox(state storage.State, terraformOutputs terraform.
 		Deployment: "jumpbox",
 		StateDir:   stateDir,
 		VarsDir:    varsDir,
+		Debug:      m.deleteEnvDebug,
+		Deadline:   m.deleteEnvDeadline,
 	}
 
 	err = m.executor.WriteDeploymentVars(dirInput, m.GetJumpboxDeploymentVars(state, terraformOutputs))
@@ -383,6 +404,10 @@ func (m *Manager) GetDirectorDeploymentVars(state storage.State, terraformOutput
 		}
 	}
 
+	if state.BOSH.DirectorVMType != "" {
+		allOutputs["director_vm_type"] = state.BOSH.DirectorVMType
+	}
+
 	vars := sharedDeploymentVarsYAML{
 		TerraformOutputs: allOutputs,
 	}
@@ -3,6 +3,7 @@ package bosh_test
 import (
 	"errors"
 	"io/ioutil"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -447,6 +448,55 @@ director_ssl:
 			}))
 		})
 
+		Context("when the director has a custom vm type", func() {
+			It("deletes it with the same vm type", func() {
+				err := boshManager.DeleteDirector(storage.State{
+					BOSH: storage.BOSH{
+						Manifest:       "some-manifest",
+						Variables:      boshVars,
+						DirectorVMType: "m5.2xlarge",
+					},
+				}, terraform.Outputs{Map: map[string]interface{}{"some-key": "some-value"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.WriteDeploymentVarsCall.Receives.DeploymentVars).To(MatchYAML(`---
+some-key: some-value
+director_vm_type: m5.2xlarge
+`))
+			})
+		})
+
+		Context("when delete-env debug is set", func() {
+			It("asks for debug logging", func() {
+				boshManager.SetDeleteEnvDebug(true)
+
+				err := boshManager.DeleteDirector(storage.State{
+					BOSH: storage.BOSH{
+						State: map[string]interface{}{"key": "value"},
+					},
+				}, terraform.Outputs{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DeleteEnvCall.Receives.DirInput.Debug).To(BeTrue())
+			})
+		})
+
+		Context("when a delete-env deadline is set", func() {
+			It("passes it to delete-env", func() {
+				deadline := time.Now().Add(20 * time.Minute)
+				boshManager.SetDeleteEnvDeadline(deadline)
+
+				err := boshManager.DeleteDirector(storage.State{
+					BOSH: storage.BOSH{
+						State: map[string]interface{}{"key": "value"},
+					},
+				}, terraform.Outputs{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.DeleteEnvCall.Receives.DirInput.Deadline).To(Equal(deadline))
+			})
+		})
+
 		Context("when an error occurs", func() {
 			var state storage.State
 
@@ -537,6 +587,20 @@ some-key: some-value
 key: some-director-value
 `))
 		})
+
+		Context("when the director has a custom vm type", func() {
+			It("passes it as director_vm_type", func() {
+				vars := boshManager.GetDirectorDeploymentVars(storage.State{
+					BOSH: storage.BOSH{DirectorVMType: "n1-standard-8"},
+				}, terraform.Outputs{Map: map[string]interface{}{
+					"some-key": "some-value",
+				}})
+				Expect(vars).To(MatchYAML(`---
+some-key: some-value
+director_vm_type: n1-standard-8
+`))
+			})
+		})
 	})
 
 	Describe("Version", func() {
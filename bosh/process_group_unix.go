@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package bosh
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInProcessGroup starts the command in a process group of its own, so
+// that the bosh cli the delete-env script runs can be stopped along with it.
+func startInProcessGroup(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd.Start()
+}
+
+// killProcessGroup kills every process in the command's process group.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,14 @@
+package bosh
+
+import "os/exec"
+
+// startInProcessGroup starts the command. Windows has no process groups to
+// put it in.
+func startInProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
+// killProcessGroup kills the command's process.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -20,10 +20,12 @@ var _ = Describe("Commands Usage", func() {
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --env-class                Classification to record in the state, such as "production" (optional)
 
   --aws-access-key-id                AWS Access Key ID                env: $BBL_AWS_ACCESS_KEY_ID
   --aws-secret-access-key            AWS Secret Access Key            env: $BBL_AWS_SECRET_ACCESS_KEY
   --aws-region                       AWS Region                       env: $BBL_AWS_REGION
+  --aws-default-credentials          AWS default credential chain     env: $BBL_AWS_DEFAULT_CREDENTIALS
 
   --gcp-service-account-key          GCP Service Access Key to use    env: $BBL_GCP_SERVICE_ACCOUNT_KEY
   --gcp-region                       GCP Region to use                env: $BBL_GCP_REGION
@@ -80,6 +82,7 @@ var _ = Describe("Commands Usage", func() {
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --env-class                Classification to record in the state, such as "production" (optional)
 %s%s`, commands.Credentials, commands.LBUsage)))
 			})
 		})
@@ -92,7 +95,65 @@ var _ = Describe("Commands Usage", func() {
 				usageText := command.Usage()
 				Expect(usageText).To(Equal(fmt.Sprintf(`Tears down BOSH director infrastructure
 
-  [--no-confirm]       Do not ask for confirmation (optional)
+  [--no-confirm]               Do not ask for confirmation (optional)
+  [--metrics-pushgateway]      Push destroy metrics to this Prometheus Pushgateway URL (optional)
+  [--cleaners-only]            Only delete resources matching the env id, skipping bosh and terraform (optional)
+  [--require-clean-git]        Refuse to destroy when bbl-state.json has uncommitted changes in git (optional)
+  [--connectivity-check]       Check that the IAAS API can be reached with the current credentials, without destroying (optional)
+  [--director-before-lbs]      Delete the director before the load balancers, the default (optional)
+  [--lbs-before-director]      Delete the load balancers before the director (optional)
+  [--verify-idempotent]        Fail if any state remains after the destroy (optional)
+  [--check-deployments]        Refuse to delete a director that still has deployments (optional)
+  [--force]                    Destroy even if --check-deployments finds deployments or the aws vpc is peered (optional)
+  [--keep-network]             Delete everything but the network and subnetwork so they can be reused, gcp only (optional)
+  [--director-drain-delay]     Time to wait before deleting the director, e.g. 5m. Interrupt to abort (optional)
+  [--continue-past-bosh-failure] Destroy the infrastructure even if bosh fails to delete the director (optional)
+  [--print-required-permissions] Print the IAAS permissions destroy needs as json and exit (optional)
+  [--delete-peerings]          Delete the peering connections of the aws vpc before destroying it (optional)
+  [--delete-leftover-disks]    Delete the unattached gcp disks labelled with the env id once the director is deleted (optional)
+  [--region-all]               Also delete resources matching the env id in every other aws region (optional)
+  [--idempotency-key]          Skip the destroy if one with the same key already completed in the state directory (optional)
+  [--leave-tombstone]          Record the destroyed environment in an object named for the env id in this s3 bucket (optional)
+  [--bosh-debug]               Run bosh delete-env with debug logging (optional)
+  [--schedule-destroy]         Record the intent to destroy, and destroy when run again after the cool-off (optional)
+  [--cancel-scheduled-destroy] Clear a destroy scheduled with --schedule-destroy (optional)
+  [--cool-off]                 How long a scheduled destroy must wait, defaults to 24h (optional)
+  [--schedule-file]            Where to record the scheduled destroy, defaults to the state directory (optional)
+  [--fail-on-dns-reference]    Fail instead of warning when route53 records outside the env domain point at its load balancers (optional)
+  [--tf-template-file]         Destroy with this terraform template in place of the one bbl generates (optional)
+  [--no-scrub]                 Do not redact credentials from the output and errors of destroy (optional)
+  [--approval-url]             Request approval from this service and wait for it before destroying. Skipped with --no-confirm (optional)
+  [--approval-poll-interval]   How often to check the approval request. Defaults to 10s (optional)
+  [--approval-timeout]         How long to wait for approval before giving up. Defaults to 1h (optional)
+  [--delete-routers]           Delete the gcp cloud routers and nat configs named after the env id or attached to its network, once terraform destroy is done (optional)
+  [--delete-images]            Delete the gcp images labelled, or deregister the aws amis tagged, with the env id and their snapshots, once terraform destroy is done (optional)
+  [--delete-kms-keys]          Delete the aliases of the aws kms keys tagged with the env id and schedule the keys for deletion, once terraform destroy is done (optional)
+  [--kms-pending-window]       Days before the kms keys of --delete-kms-keys are deleted, from 7 to 30. Defaults to 30 (optional)
+  [--print-config]             Print the resolved destroy configuration as json, with secrets redacted, and exit (optional)
+  [--recovery-bundle]          Write the state, terraform outputs and director credentials to a gzipped tar at this path before deleting anything (optional)
+  [--recovery-bundle-key-file] Encrypt the recovery bundle with the key in this file (optional)
+  [--repair-state]             Clear director and jumpbox vms that no longer exist from the state before deleting anything (optional)
+  [--event-log]                Write the resolved config, each phase and the result as json lines to this file, with secrets redacted (optional)
+  [--pre-delete-hook]          Run this executable with BBL_ENV_ID and BBL_DIRECTOR_ADDRESS set before deleting the director (optional)
+  [--ignore-hook-failure]      Delete the director even if the pre-delete hook fails (optional)
+  [--explain]                  Log why each step is run or skipped (optional)
+  [--diagnostics-on-failure]   Write the state, latest terraform output and each phase to this directory if the destroy fails, with secrets redacted (optional)
+  [--post-verify-empty]        Check that no vms are left in the env's network after it is destroyed (optional)
+  [--production-pattern]       Record the environment as "production" in the state when the env id matches this regular expression (optional)
+  [--skip-terraform-version-check] Do not check that the terraform binary is new enough (optional)
+  [--reconcile-after]          List the resources still named or tagged with the env id once everything is destroyed (optional)
+  [--fail-on-residual]         Fail if --reconcile-after finds any resources (optional)
+  [--timeout]                  Stop bosh delete-env and terraform destroy, save the state and fail once the destroy has run this long, e.g. 20m (optional)
+  [--dry-run]                  Log what destroy would delete without deleting anything or changing the state (optional)
+  [--plan-format]              With --dry-run, "terraform" also prints the resources terraform destroy would delete as a terraform plan (optional)
+  [--measure-only]             Print the billable resources destroy would delete and what they cost a month, without deleting anything (optional)
+  [--price-list]               JSON file with the monthly price of each terraform resource type, "director" and "jumpbox", for --measure-only (optional)
+  [--confirm-env-id]           Go on without asking when this matches the env id in the state, and fail otherwise (optional)
+  [--confirm-message]          Ask this instead of the default confirmation, with %%s replaced by the env id (optional)
+  [--max-retries]              Run terraform destroy again up to this many times when it fails with a transient api error. Defaults to 0 (optional)
+  [--audit-log]                Append who confirmed the destroy and how it went to this file (optional)
+  [--skip-bosh]                Clear the director from the state without deleting it, when its vm is already gone (optional)
+  [--all-matching]             Destroy every env under the state dir whose env id starts with this prefix, one after the other (optional)
 
   Credentials for your IaaS are required:%s`, commands.Credentials)))
 			})
@@ -167,7 +228,7 @@ var _ = Describe("Commands Usage", func() {
 		Entry("outputs", commands.Outputs{}, "Prints the outputs from terraform."),
 		Entry("jumpbox-address", newStateQuery("jumpbox address"), "Prints BOSH jumpbox address"),
 		Entry("director-address", newStateQuery("director address"), "Prints BOSH director address"),
-		Entry("director-password", newStateQuery("director password"), "Prints BOSH director password"),
+		Entry("director-password", commands.DirectorPassword{}, "Prints BOSH director password"),
 		Entry("director-username", newStateQuery("director username"), "Prints BOSH director username"),
 		Entry("director-ca-cert", newStateQuery("director ca cert"), "Prints BOSH director CA certificate"),
 		Entry("env-id", newStateQuery("environment id"), "Prints environment ID"),
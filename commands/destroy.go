@@ -1,57 +1,430 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/approval"
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
 
+const (
+	drainCountdownInterval = 10 * time.Second
+
+	// regionalCheckConcurrency bounds how many regions are checked at once
+	// so that accounts with many regions are not throttled.
+	regionalCheckConcurrency = 4
+)
+
+// Destroy logs its progress with logger and prints results meant for
+// scripts, such as --print-config, with stdoutLogger, so that the two can be
+// redirected separately.
 type Destroy struct {
 	plan                     plan
 	logger                   logger
+	stdoutLogger             logger
 	boshManager              boshManager
 	stateStore               stateStore
+	resultStore              stateStore
 	stateValidator           stateValidator
 	terraformManager         terraformManager
 	networkDeletionValidator NetworkDeletionValidator
+	metricsPusher            metricsPusher
+	credentialRefresher      CredentialRefresher
+	directorRefresher        DirectorCredentialRefresher
+	outputScrubber           outputScrubber
+	leftovers                FilteredDeleter
+	gitStatus                gitStatus
+	diskDeleter              DiskDeleter
+	connectivityChecker      ConnectivityChecker
+	boshClientProvider       boshClientProvider
+	vpcPeeringChecker        VPCPeeringChecker
+	regionalDeleter          RegionalDeleter
+	destroyKeys              destroyKeys
+	vmChecker                VMChecker
+	tombstoneWriter          TombstoneWriter
+	destroySchedule          destroySchedule
+	dnsReferenceChecker      DNSReferenceChecker
+	imageCleaner             ImageCleaner
+	approvalService          ApprovalService
+	kmsCleaner               KMSCleaner
+	regionalSafetyChecker    RegionalSafetyChecker
+	recoveryBundle           recoveryBundle
+	hookRunner               hookRunner
+	routerCleaner            RouterCleaner
+	locationChecker          LocationChecker
+	residualLister           ResidualLister
+
+	// outputs are used instead of fetching them from terraform when set.
+	outputs *terraform.Outputs
+
+	// ctx stops the destroy between phases once it is done.
+	ctx context.Context
+
+	// noConfirm is set when bbl runs with the global --no-confirm.
+	noConfirm bool
+
+	// envDestroyer destroys each env for --all-matching.
+	envDestroyer EnvDestroyer
+
+	eventLog    *destroyEventLog
+	diagnostics *destroyDiagnostics
+	audit       *destroyAudit
 }
 
-type destroyConfig struct {
-	NoConfirm bool
+// directorAuthFailure matches the errors delete-env reports when the director
+// or its agent rejects the credentials it was given.
+var directorAuthFailure = regexp.MustCompile(`(?i)(unauthorized|status code '401'|invalid_token)`)
+
+// iaasCredentialFailure matches the errors the iaas apis, terraform and the
+// cpis report when the iaas credentials expired or were rejected.
+var iaasCredentialFailure = regexp.MustCompile(`(?i)(ExpiredToken|RequestExpired|InvalidClientTokenId|AuthFailure|UnrecognizedClientException|invalid_grant|security token included in the request is expired)`)
+
+type DestroyConfig struct {
+	MetricsPushgateway string
+	CleanersOnly       bool
+	RequireCleanGit    bool
+	ConnectivityCheck  bool
+	LBsBeforeDirector  bool
+	VerifyIdempotent   bool
+	CheckDeployments   bool
+	Force              bool
+	KeepNetwork        bool
+	DirectorDrainDelay time.Duration
+
+	ContinuePastBOSHFailure  bool
+	PrintRequiredPermissions bool
+	DeletePeerings           bool
+	DeleteLeftoverDisks      bool
+	RegionAll                bool
+	IdempotencyKey           string
+	LeaveTombstone           string
+	BOSHDebug                bool
+
+	ScheduleDestroy        bool
+	CancelScheduledDestroy bool
+	CoolOff                time.Duration
+	ScheduleFile           string
+
+	FailOnDNSReference bool
+	TFTemplateFile     string
+	NoScrub            bool
+
+	ApprovalURL          string
+	ApprovalPollInterval time.Duration
+	ApprovalTimeout      time.Duration
+
+	DeleteRouters    bool
+	DeleteImages     bool
+	DeleteKMSKeys    bool
+	KMSPendingWindow int
+	PrintConfig      bool
+
+	RecoveryBundle        string
+	RecoveryBundleKeyFile string
+
+	RepairState bool
+	EventLog    string
+
+	PreDeleteHook     string
+	IgnoreHookFailure bool
+
+	Explain bool
+
+	DiagnosticsOnFailure string
+	PostVerifyEmpty      bool
+
+	InjectFailure string
+
+	ProductionPattern string
+
+	SkipTerraformVersionCheck bool
+
+	ReconcileAfter bool
+	FailOnResidual bool
+
+	Timeout time.Duration
+
+	DryRun     bool
+	PlanFormat string
+
+	MeasureOnly bool
+	PriceList   string
+
+	ConfirmEnvID   string
+	ConfirmMessage string
+
+	MaxRetries int
+
+	AuditLog string
+
+	SkipBOSH bool
+
+	AllMatching string
 }
 
 type NetworkDeletionValidator interface {
 	ValidateSafeToDelete(networkName string, envID string) error
 }
 
-func NewDestroy(plan plan, logger logger, boshManager boshManager, stateStore stateStore,
+type DiskDeleter interface {
+	DeleteDisks(envID string) error
+}
+
+// CredentialRefresher loads the iaas credentials again, after an api-bound
+// phase failed because they expired or were rejected.
+type CredentialRefresher interface {
+	Refresh(storage.State) (storage.State, error)
+}
+
+// DirectorCredentialRefresher reads the director credentials again, after
+// the director rejected the ones in the state.
+type DirectorCredentialRefresher interface {
+	RefreshDirector(storage.State) (storage.State, error)
+}
+
+// ApprovalService is left nil with --no-confirm, which skips the approval
+// like it skips the prompt.
+type ApprovalService interface {
+	Request(approvalURL, envID string) (string, error)
+	Status(requestURL string) (string, error)
+}
+
+type ImageCleaner interface {
+	DeleteImages(envID string) ([]string, error)
+}
+
+type RouterCleaner interface {
+	DeleteRouters(envID string) ([]string, error)
+}
+
+// LocationChecker fails if the region or zone of an env is no longer offered
+// by its iaas.
+type LocationChecker interface {
+	CheckLocation(region, zone string) error
+}
+
+// ResidualLister lists the resources of any supported type that still have
+// the env id in their name or tags.
+type ResidualLister interface {
+	ListResidual(envID string) ([]string, error)
+}
+
+type EnvDestroyer interface {
+	DestroyEnv(stateDir string) error
+}
+
+type KMSCleaner interface {
+	DeleteKMSKeys(envID string, pendingWindowDays int) ([]string, error)
+}
+
+type ConnectivityChecker interface {
+	CheckConnectivity() error
+}
+
+type VPCPeeringChecker interface {
+	ActivePeerings(vpcID string) ([]string, error)
+	DeletePeerings(vpcID string) error
+}
+
+type DNSReferenceChecker interface {
+	LBReferences(targets []string, ownDomain string) ([]string, error)
+}
+
+type TombstoneWriter interface {
+	WriteTombstone(bucket, envID string, contents []byte) error
+}
+
+type VMChecker interface {
+	VMExists(cid string) (bool, error)
+}
+
+type RegionalSafetyChecker interface {
+	Regions() ([]string, error)
+	ValidateSafeToDeleteInRegion(region, envID string) error
+}
+
+type RegionalDeleter interface {
+	Regions() ([]string, error)
+	DeleteInRegion(region, filter string) error
+}
+
+// DestroyDependencies holds the collaborators of destroy beyond the ones
+// every command needs.
+type DestroyDependencies struct {
+	MetricsPusher               metricsPusher
+	DirectorCredentialRefresher DirectorCredentialRefresher
+	GitStatus                   gitStatus
+	BOSHClientProvider          boshClientProvider
+	DestroyKeys                 destroyKeys
+	DestroySchedule             destroySchedule
+	RecoveryBundle              recoveryBundle
+	HookRunner                  hookRunner
+	OutputScrubber              outputScrubber
+
+	// The collaborators below depend on the iaas. They are left nil when the
+	// iaas has none, and the flags that need them fail fast.
+	Leftovers             FilteredDeleter
+	CredentialRefresher   CredentialRefresher
+	DiskDeleter           DiskDeleter
+	ConnectivityChecker   ConnectivityChecker
+	VPCPeeringChecker     VPCPeeringChecker
+	RegionalDeleter       RegionalDeleter
+	VMChecker             VMChecker
+	TombstoneWriter       TombstoneWriter
+	DNSReferenceChecker   DNSReferenceChecker
+	ImageCleaner          ImageCleaner
+	ApprovalService       ApprovalService
+	KMSCleaner            KMSCleaner
+	RegionalSafetyChecker RegionalSafetyChecker
+	RouterCleaner         RouterCleaner
+	LocationChecker       LocationChecker
+	ResidualLister        ResidualLister
+}
+
+func NewDestroy(plan plan, logger logger, stdoutLogger logger, boshManager boshManager, stateStore stateStore,
 	stateValidator stateValidator, terraformManager terraformManager,
-	networkDeletionValidator NetworkDeletionValidator) Destroy {
+	networkDeletionValidator NetworkDeletionValidator, deps DestroyDependencies) Destroy {
 	return Destroy{
 		plan:                     plan,
 		logger:                   logger,
+		stdoutLogger:             stdoutLogger,
 		boshManager:              boshManager,
 		stateStore:               stateStore,
+		resultStore:              stateStore,
 		stateValidator:           stateValidator,
 		terraformManager:         terraformManager,
 		networkDeletionValidator: networkDeletionValidator,
+		metricsPusher:            deps.MetricsPusher,
+		credentialRefresher:      deps.CredentialRefresher,
+		directorRefresher:        deps.DirectorCredentialRefresher,
+		outputScrubber:           deps.OutputScrubber,
+		leftovers:                deps.Leftovers,
+		gitStatus:                deps.GitStatus,
+		diskDeleter:              deps.DiskDeleter,
+		connectivityChecker:      deps.ConnectivityChecker,
+		boshClientProvider:       deps.BOSHClientProvider,
+		vpcPeeringChecker:        deps.VPCPeeringChecker,
+		regionalDeleter:          deps.RegionalDeleter,
+		destroyKeys:              deps.DestroyKeys,
+		vmChecker:                deps.VMChecker,
+		tombstoneWriter:          deps.TombstoneWriter,
+		destroySchedule:          deps.DestroySchedule,
+		dnsReferenceChecker:      deps.DNSReferenceChecker,
+		imageCleaner:             deps.ImageCleaner,
+		approvalService:          deps.ApprovalService,
+		kmsCleaner:               deps.KMSCleaner,
+		regionalSafetyChecker:    deps.RegionalSafetyChecker,
+		recoveryBundle:           deps.RecoveryBundle,
+		hookRunner:               deps.HookRunner,
+		routerCleaner:            deps.RouterCleaner,
+		locationChecker:          deps.LocationChecker,
+		residualLister:           deps.ResidualLister,
 	}
 }
 
 func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) error {
-	err := fastFailBOSHVersion(d.boshManager)
+	config, err := d.ParseArgs(subcommandFlags, state)
 	if err != nil {
 		return err
 	}
 
-	err = d.terraformManager.ValidateVersion()
+	if config.AllMatching != "" {
+		return nil
+	}
+
+	if config.PrintRequiredPermissions {
+		if _, ok := destroyPermissions[state.IAAS]; state.IAAS != "" && !ok {
+			return fmt.Errorf("--print-required-permissions is not supported for iaas %q", state.IAAS)
+		}
+		return nil
+	}
+
+	if config.PrintConfig {
+		return nil
+	}
+
+	if config.IdempotencyKey != "" {
+		completed, err := d.destroyKeys.Completed(config.IdempotencyKey)
+		if err != nil {
+			return err
+		}
+		if completed {
+			return nil
+		}
+	}
+
+	if config.CancelScheduledDestroy {
+		return nil
+	}
+
+	if config.CleanersOnly || config.RegionAll {
+		err = checkExternalServices(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.CleanersOnly || config.ConnectivityCheck {
+		if config.CleanersOnly && d.leftovers == nil {
+			return fmt.Errorf("--cleaners-only is not supported: no cleaners are configured for iaas %q", state.IAAS)
+		}
+
+		if config.ConnectivityCheck && d.connectivityChecker == nil {
+			return fmt.Errorf("--connectivity-check is not supported for iaas %q", state.IAAS)
+		}
+
+		err = d.stateValidator.Validate()
+		if _, ok := err.(NoBBLStateError); ok {
+			d.logger.Println(err.Error())
+			return ExitSuccessfully{}
+		}
+		return err
+	}
+
+	err = fastFailBOSHVersion(d.boshManager)
 	if err != nil {
 		return err
 	}
 
+	if config.SkipTerraformVersionCheck {
+		d.explain(config, "skipping the terraform version check")
+	} else {
+		err = d.terraformManager.ValidateVersion()
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.TFTemplateFile != "" {
+		template, err := readTemplateOverride(config.TFTemplateFile)
+		if err != nil {
+			return err
+		}
+
+		err = d.terraformManager.ValidateTemplate(state, template)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = d.stateValidator.Validate()
 	if _, ok := err.(NoBBLStateError); ok {
 		d.logger.Println(err.Error())
@@ -62,6 +435,78 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 		return err
 	}
 
+	if d.locationChecker != nil {
+		err = d.checkLocation(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.RequireCleanGit {
+		err = d.gitStatus.CheckClean(d.stateStore.GetStateDir(), storage.STATE_FILE)
+		if err != nil {
+			return fmt.Errorf("State directory is not clean: %s", err)
+		}
+	}
+
+	if config.CheckDeployments && !config.Force {
+		err = d.checkDeployments(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.DeletePeerings && d.vpcPeeringChecker == nil {
+		return fmt.Errorf("--delete-peerings is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.DeleteLeftoverDisks && d.diskDeleter == nil {
+		return fmt.Errorf("--delete-leftover-disks is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.DeleteRouters && d.routerCleaner == nil {
+		return fmt.Errorf("--delete-routers is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.DeleteImages && d.imageCleaner == nil {
+		return fmt.Errorf("--delete-images is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.DeleteKMSKeys && d.kmsCleaner == nil {
+		return fmt.Errorf("--delete-kms-keys is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.RegionAll && d.regionalDeleter == nil {
+		return fmt.Errorf("--region-all is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.LeaveTombstone != "" && d.tombstoneWriter == nil {
+		return fmt.Errorf("--leave-tombstone is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.FailOnDNSReference && d.dnsReferenceChecker == nil {
+		return fmt.Errorf("--fail-on-dns-reference is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.RepairState && d.vmChecker == nil {
+		return fmt.Errorf("--repair-state is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.ReconcileAfter && d.residualLister == nil {
+		return fmt.Errorf("--reconcile-after is not supported for iaas %q", state.IAAS)
+	}
+
+	if config.RegionAll && d.regionalSafetyChecker != nil {
+		err = d.checkOtherRegions(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.KeepNetwork && state.IAAS != "gcp" {
+		return fmt.Errorf("--keep-network is not supported for iaas %q", state.IAAS)
+	}
+
 	isPaved, _ := d.terraformManager.IsPaved()
 	if !isPaved {
 		return nil
@@ -72,120 +517,1656 @@ func (d Destroy) CheckFastFails(subcommandFlags []string, state storage.State) e
 		return nil
 	}
 
-	var networkName string
-	switch state.IAAS {
-	case "gcp":
-		networkName = terraformOutputs.GetString("network_name")
-	case "aws":
-		networkName = terraformOutputs.GetString("vpc_id")
-	case "azure":
-		networkName = terraformOutputs.GetString("bosh_network_name")
+	if state.LB.Type != "" && d.dnsReferenceChecker != nil {
+		err = d.checkDNSReferences(state, terraformOutputs, config.FailOnDNSReference)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The network, and the vms and peerings in it, are left alone with
+	// --keep-network, but the load balancers are still deleted.
+	if config.KeepNetwork {
+		return nil
 	}
 
+	networkName := envNetworkName(state.IAAS, terraformOutputs)
 	if networkName == "" {
 		return nil
 	}
 
 	err = d.networkDeletionValidator.ValidateSafeToDelete(networkName, state.EnvID)
 	if err != nil {
+		d.printBlockingVMs(err)
 		return err
 	}
 
+	if state.IAAS == "aws" && d.vpcPeeringChecker != nil && !config.DeletePeerings {
+		err = d.checkPeerings(networkName, config.Force)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
-	proceed := d.logger.Prompt(fmt.Sprintf("Are you sure you want to delete infrastructure for %q? This operation cannot be undone!", state.EnvID))
-	if !proceed {
-		d.logger.Step("exiting")
+// envNetworkName returns the name of the network the env's vms run in, as
+// the network deletion validator expects it.
+func envNetworkName(iaas string, terraformOutputs terraform.Outputs) string {
+	switch iaas {
+	case "gcp":
+		return terraformOutputs.GetString("network_name")
+	case "aws":
+		return terraformOutputs.GetString("vpc_id")
+	case "azure":
+		return terraformOutputs.GetString("bosh_network_name")
+	}
+	return ""
+}
+
+// checkLocation confirms the env's region and zone still exist, so that a
+// retired zone fails here rather than deep inside terraform.
+func (d Destroy) checkLocation(state storage.State) error {
+	var region, zone string
+	switch state.IAAS {
+	case "aws":
+		region = state.AWS.Region
+	case "gcp":
+		region, zone = state.GCP.Region, state.GCP.Zone
+	default:
 		return nil
 	}
 
-	if !d.plan.IsInitialized(state) {
-		planConfig := PlanConfig{
-			Name: state.EnvID,
-			LB:   state.LB,
-		}
+	err := d.locationChecker.CheckLocation(region, zone)
+	if err != nil {
+		return fmt.Errorf("Check region and zone: %s", err)
+	}
 
-		var err error
-		state, err = d.plan.InitializePlan(planConfig, state)
-		if err != nil {
-			return fmt.Errorf("Initialize plan during destroy: %s", err)
+	return nil
+}
+
+// checkDNSReferences looks for dns records outside the env's own domain that
+// still point at its load balancers, since those names stop resolving to
+// anything once the load balancers are gone. It only warns unless asked to
+// fail.
+func (d Destroy) checkDNSReferences(state storage.State, terraformOutputs terraform.Outputs, fail bool) error {
+	var targets []string
+	for key, value := range terraformOutputs.Map {
+		if !strings.HasSuffix(key, "_lb_url") && !strings.HasSuffix(key, "_lb_ip") {
+			continue
+		}
+		if target, ok := value.(string); ok {
+			targets = append(targets, target)
 		}
 	}
+	sort.Strings(targets)
 
-	isPaved, err := d.terraformManager.IsPaved()
+	references, err := d.dnsReferenceChecker.LBReferences(targets, state.LB.Domain)
 	if err != nil {
-		return err
+		if fail {
+			return fmt.Errorf("Check dns records for the load balancers: %s", err)
+		}
+		d.logger.Println(fmt.Sprintf("Warning: could not check dns records for the load balancers: %s", err))
+		return nil
 	}
 
-	if !isPaved {
-		if err := d.stateStore.Set(storage.State{}); err != nil {
-			return err
-		}
+	if len(references) == 0 {
 		return nil
 	}
 
-	terraformOutputs, err := d.terraformManager.GetOutputs()
+	message := fmt.Sprintf("dns records still point at the load balancers: %s", strings.Join(references, ", "))
+	if fail {
+		return errors.New(message)
+	}
+
+	d.logger.Println(fmt.Sprintf("Warning: %s", message))
+	return nil
+}
+
+// checkPeerings refuses to delete a vpc that other vpcs are peered with,
+// since their routes to it would break. With force it only warns.
+func (d Destroy) checkPeerings(vpcID string, force bool) error {
+	peerings, err := d.vpcPeeringChecker.ActivePeerings(vpcID)
 	if err != nil {
 		return err
 	}
 
-	state, err = d.deleteBOSH(state, terraformOutputs)
-	switch err.(type) {
-	case bosh.ManagerDeleteError:
-		mdErr := err.(bosh.ManagerDeleteError)
-		setErr := d.stateStore.Set(mdErr.State())
-		if setErr != nil {
-			errorList := helpers.Errors{}
-			errorList.Add(err)
-			errorList.Add(setErr)
-			return errorList
-		}
-		return err
-	case error:
-		return err
+	if len(peerings) == 0 {
+		return nil
 	}
 
-	if err := d.stateStore.Set(state); err != nil {
-		return err
+	message := fmt.Sprintf("vpc %s is peered with other vpcs through %s, deleting it breaks their routes.", vpcID, strings.Join(peerings, ", "))
+	if !force {
+		return fmt.Errorf("%s Run bbl destroy with --delete-peerings to delete them first, or with --force.", message)
 	}
 
-	if err = d.terraformManager.Setup(state); err != nil {
-		return err
+	d.logger.Println(fmt.Sprintf("Warning: %s", message))
+	return nil
+}
+
+func (d Destroy) ParseArgs(args []string, state storage.State) (DestroyConfig, error) {
+	config, _, err := parseDestroyArgs(args)
+	return config, err
+}
+
+// parseDestroyArgs also returns the parsed flags so that --print-config can
+// show every value, defaults included.
+func parseDestroyArgs(args []string) (DestroyConfig, flags.Flags, error) {
+	var config DestroyConfig
+
+	destroyFlags := flags.New("destroy")
+	destroyFlags.String(&config.MetricsPushgateway, "metrics-pushgateway", "")
+	destroyFlags.Bool(&config.CleanersOnly, "cleaners-only")
+	destroyFlags.Bool(&config.RequireCleanGit, "require-clean-git")
+	destroyFlags.Bool(&config.ConnectivityCheck, "connectivity-check")
+	destroyFlags.Bool(&config.VerifyIdempotent, "verify-idempotent")
+	destroyFlags.Bool(&config.CheckDeployments, "check-deployments")
+	destroyFlags.Bool(&config.Force, "force")
+	destroyFlags.Bool(&config.KeepNetwork, "keep-network")
+	destroyFlags.Duration(&config.DirectorDrainDelay, "director-drain-delay", 0)
+	destroyFlags.String(&config.IdempotencyKey, "idempotency-key", "")
+	destroyFlags.String(&config.LeaveTombstone, "leave-tombstone", "")
+	destroyFlags.Bool(&config.BOSHDebug, "bosh-debug")
+	destroyFlags.Bool(&config.ContinuePastBOSHFailure, "continue-past-bosh-failure")
+	destroyFlags.Bool(&config.PrintRequiredPermissions, "print-required-permissions")
+	destroyFlags.Bool(&config.DeletePeerings, "delete-peerings")
+	destroyFlags.Bool(&config.DeleteLeftoverDisks, "delete-leftover-disks")
+	destroyFlags.Bool(&config.RegionAll, "region-all")
+	destroyFlags.Bool(&config.ScheduleDestroy, "schedule-destroy")
+	destroyFlags.Bool(&config.CancelScheduledDestroy, "cancel-scheduled-destroy")
+	destroyFlags.Duration(&config.CoolOff, "cool-off", 24*time.Hour)
+	destroyFlags.String(&config.ScheduleFile, "schedule-file", "")
+	destroyFlags.Bool(&config.FailOnDNSReference, "fail-on-dns-reference")
+	destroyFlags.String(&config.TFTemplateFile, "tf-template-file", "")
+	destroyFlags.Bool(&config.NoScrub, "no-scrub")
+	destroyFlags.String(&config.ApprovalURL, "approval-url", "")
+	destroyFlags.Duration(&config.ApprovalPollInterval, "approval-poll-interval", 10*time.Second)
+	destroyFlags.Duration(&config.ApprovalTimeout, "approval-timeout", time.Hour)
+	destroyFlags.Bool(&config.DeleteRouters, "delete-routers")
+	destroyFlags.Bool(&config.DeleteImages, "delete-images")
+	destroyFlags.Bool(&config.DeleteKMSKeys, "delete-kms-keys")
+	destroyFlags.Int(&config.KMSPendingWindow, "kms-pending-window", 30)
+	destroyFlags.Bool(&config.PrintConfig, "print-config")
+	destroyFlags.String(&config.RecoveryBundle, "recovery-bundle", "")
+	destroyFlags.String(&config.RecoveryBundleKeyFile, "recovery-bundle-key-file", "")
+	destroyFlags.Bool(&config.RepairState, "repair-state")
+	destroyFlags.String(&config.EventLog, "event-log", "")
+	destroyFlags.String(&config.PreDeleteHook, "pre-delete-hook", "")
+	destroyFlags.Bool(&config.IgnoreHookFailure, "ignore-hook-failure")
+	destroyFlags.Bool(&config.Explain, "explain")
+	destroyFlags.String(&config.DiagnosticsOnFailure, "diagnostics-on-failure", "")
+	destroyFlags.Bool(&config.PostVerifyEmpty, "post-verify-empty")
+	destroyFlags.String(&config.ProductionPattern, "production-pattern", "")
+	destroyFlags.Bool(&config.SkipTerraformVersionCheck, "skip-terraform-version-check")
+	destroyFlags.Bool(&config.ReconcileAfter, "reconcile-after")
+	destroyFlags.Bool(&config.FailOnResidual, "fail-on-residual")
+	destroyFlags.Duration(&config.Timeout, "timeout", 0)
+	destroyFlags.Bool(&config.DryRun, "dry-run")
+	destroyFlags.String(&config.PlanFormat, "plan-format", "text")
+	destroyFlags.Bool(&config.MeasureOnly, "measure-only")
+	destroyFlags.String(&config.PriceList, "price-list", "")
+	destroyFlags.String(&config.ConfirmEnvID, "confirm-env-id", "")
+	destroyFlags.String(&config.ConfirmMessage, "confirm-message", "")
+	destroyFlags.Int(&config.MaxRetries, "max-retries", 0)
+	destroyFlags.String(&config.AuditLog, "audit-log", "")
+	destroyFlags.Bool(&config.SkipBOSH, "skip-bosh")
+	destroyFlags.String(&config.AllMatching, "all-matching", "")
+	if failureInjectionEnabled {
+		destroyFlags.String(&config.InjectFailure, "inject-failure", "")
 	}
 
-	state, err = d.terraformManager.Destroy(state)
+	var directorBeforeLBs bool
+	destroyFlags.Bool(&directorBeforeLBs, "director-before-lbs")
+	destroyFlags.Bool(&config.LBsBeforeDirector, "lbs-before-director")
+
+	err := destroyFlags.Parse(args)
 	if err != nil {
-		return handleTerraformError(err, state, d.stateStore)
+		return DestroyConfig{}, flags.Flags{}, err
 	}
 
-	if err := d.stateStore.Set(storage.State{}); err != nil {
-		return err
+	if directorBeforeLBs && config.LBsBeforeDirector {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--director-before-lbs and --lbs-before-director cannot be used together")
 	}
 
-	return nil
-}
+	if config.AllMatching != "" && config.ConfirmEnvID != "" {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--all-matching and --confirm-env-id cannot be used together")
+	}
 
-func (d Destroy) deleteBOSH(state storage.State, terraformOutputs terraform.Outputs) (storage.State, error) {
-	if state.NoDirector {
-		d.logger.Println("No BOSH director, skipping...")
-		return state, nil
+	if config.KeepNetwork && config.VerifyIdempotent {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--keep-network and --verify-idempotent cannot be used together")
 	}
 
-	err := d.boshManager.DeleteDirector(state, terraformOutputs)
-	if err != nil {
-		return state, err
+	if config.KeepNetwork && config.PostVerifyEmpty {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--keep-network and --post-verify-empty cannot be used together")
 	}
 
-	state.BOSH = storage.BOSH{}
+	if config.ScheduleDestroy && config.CancelScheduledDestroy {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--schedule-destroy and --cancel-scheduled-destroy cannot be used together")
+	}
 
-	err = d.boshManager.DeleteJumpbox(state, terraformOutputs)
-	if err != nil {
-		return state, err
+	if config.DryRun && (config.ScheduleDestroy || config.CancelScheduledDestroy) {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--dry-run cannot be used with --schedule-destroy or --cancel-scheduled-destroy")
 	}
 
-	state.Jumpbox = storage.Jumpbox{}
+	if config.PlanFormat != "text" && config.PlanFormat != "terraform" {
+		return DestroyConfig{}, flags.Flags{}, errors.New(`--plan-format must be "text" or "terraform"`)
+	}
 
-	return state, nil
+	if config.PlanFormat != "text" && !config.DryRun {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--plan-format requires --dry-run")
+	}
+
+	if config.DryRun && config.MeasureOnly {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--dry-run and --measure-only cannot be used together")
+	}
+
+	if config.MeasureOnly && config.PriceList == "" {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--measure-only requires --price-list")
+	}
+
+	if config.PriceList != "" && !config.MeasureOnly {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--price-list requires --measure-only")
+	}
+
+	if config.MaxRetries < 0 {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--max-retries must not be negative")
+	}
+
+	if config.Timeout < 0 {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--timeout must not be negative")
+	}
+
+	if config.KMSPendingWindow < 7 || config.KMSPendingWindow > 30 {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--kms-pending-window must be between 7 and 30 days")
+	}
+
+	if config.IgnoreHookFailure && config.PreDeleteHook == "" {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--ignore-hook-failure requires --pre-delete-hook")
+	}
+
+	if config.RecoveryBundleKeyFile != "" && config.RecoveryBundle == "" {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--recovery-bundle-key-file requires --recovery-bundle")
+	}
+
+	if config.FailOnResidual && !config.ReconcileAfter {
+		return DestroyConfig{}, flags.Flags{}, errors.New("--fail-on-residual requires --reconcile-after")
+	}
+
+	if config.InjectFailure != "" {
+		if err := validateInjectFailure(config.InjectFailure); err != nil {
+			return DestroyConfig{}, flags.Flags{}, err
+		}
+	}
+
+	if _, err := regexp.Compile(config.ProductionPattern); err != nil {
+		return DestroyConfig{}, flags.Flags{}, fmt.Errorf("Invalid --production-pattern: %s", err)
+	}
+
+	return config, destroyFlags, nil
+}
+
+// ExecuteWithOutputs destroys the environment like Execute, using the given
+// terraform outputs instead of fetching them from terraform.
+func (d Destroy) ExecuteWithOutputs(outputs terraform.Outputs, subcommandFlags []string, state storage.State) error {
+	d.outputs = &outputs
+	return d.Execute(subcommandFlags, state)
+}
+
+// WithContext returns a destroy that stops once ctx is done. Cancellation is
+// checked before each phase and region check. A terraform destroy that is
+// running is interrupted, and a bosh delete-env that is running is killed
+// once the ctx deadline passes. The state is saved as it is at that point, so
+// a later destroy picks up where this one stopped.
+func (d Destroy) WithContext(ctx context.Context) Destroy {
+	d.ctx = ctx
+	return d
+}
+
+// WithNoConfirm returns a destroy that knows whether bbl runs with the global
+// --no-confirm, which cannot be combined with --confirm-env-id.
+func (d Destroy) WithNoConfirm(noConfirm bool) Destroy {
+	d.noConfirm = noConfirm
+	return d
+}
+
+// WithEnvDestroyer returns a destroy that can destroy every env matching
+// --all-matching with envDestroyer.
+func (d Destroy) WithEnvDestroyer(envDestroyer EnvDestroyer) Destroy {
+	d.envDestroyer = envDestroyer
+	return d
+}
+
+// WithStoredIAAS returns a destroy for a state whose iaas was overridden with
+// --iaas. Until the destroy succeeds the state is saved with iaas, the one in
+// the state file, so that a failed destroy does not rewrite it.
+func (d Destroy) WithStoredIAAS(iaas string) Destroy {
+	d.stateStore = storedIAASStore{stateStore: d.resultStore, iaas: iaas}
+	return d
+}
+
+// storedIAASStore saves the state with the iaas from the state file in place
+// of an --iaas override.
+type storedIAASStore struct {
+	stateStore
+	iaas string
+}
+
+func (s storedIAASStore) Set(state storage.State) error {
+	if !reflect.DeepEqual(state, storage.State{}) {
+		state.IAAS = s.iaas
+	}
+	return s.stateStore.Set(state)
+}
+
+// cancelled returns an error once the destroy's context is done.
+func (d Destroy) cancelled() error {
+	if d.ctx == nil || d.ctx.Err() == nil {
+		return nil
+	}
+	if d.ctx.Err() == context.DeadlineExceeded {
+		return errors.New("Destroy timed out")
+	}
+	return fmt.Errorf("Destroy cancelled: %s", d.ctx.Err())
+}
+
+// deadline returns when the destroy's context runs out, or the zero time.
+func (d Destroy) deadline() time.Time {
+	if d.ctx == nil {
+		return time.Time{}
+	}
+	deadline, _ := d.ctx.Deadline()
+	return deadline
+}
+
+// saveIfCancelled saves the state reached so far when the destroy's context
+// is done and returns the cancellation.
+func (d Destroy) saveIfCancelled(state storage.State) error {
+	err := d.cancelled()
+	if err == nil {
+		return nil
+	}
+
+	if setErr := d.stateStore.Set(state); setErr != nil {
+		errorList := helpers.Errors{}
+		errorList.Add(err)
+		errorList.Add(setErr)
+		return errorList
+	}
+
+	return err
+}
+
+func (d Destroy) Execute(subcommandFlags []string, state storage.State) error {
+	config, destroyFlags, err := parseDestroyArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if config.ConfirmEnvID != "" && d.noConfirm {
+		return errors.New("--confirm-env-id and --no-confirm cannot be used together")
+	}
+
+	if config.AllMatching != "" {
+		return d.destroyAllMatching(config.AllMatching)
+	}
+
+	if config.PrintConfig {
+		return d.printConfig(destroyFlags, state)
+	}
+
+	if config.EventLog != "" {
+		d.eventLog, err = openDestroyEventLog(config.EventLog, newResolvedDestroyConfig(destroyFlags, state), newSecretScrubber(state))
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.DiagnosticsOnFailure != "" {
+		d.diagnostics = &destroyDiagnostics{}
+	}
+
+	if config.AuditLog != "" {
+		d.audit = &destroyAudit{}
+	}
+
+	if config.NoScrub {
+		err = d.execute(config, state)
+	} else {
+		scrubber := newSecretScrubber(state)
+		d.logger = scrubber.wrap(d.logger)
+		d.stdoutLogger = scrubber.wrap(d.stdoutLogger)
+		d.outputScrubber.Scrub(scrubber.scrub)
+		err = scrubber.scrubError(d.execute(config, state))
+		if flushErr := d.outputScrubber.Flush(); flushErr != nil {
+			d.logger.Printf("Warning: failed to write the output of bosh and terraform: %s\n", flushErr)
+		}
+	}
+
+	if d.eventLog != nil {
+		if logErr := d.eventLog.close(err); logErr != nil {
+			d.logger.Printf("Warning: failed to write the event log to %s: %s\n", config.EventLog, logErr)
+		}
+	}
+
+	if d.audit != nil {
+		if auditErr := d.writeAuditLog(config.AuditLog, state.EnvID, state.IAAS, err, newSecretScrubber(state)); auditErr != nil {
+			d.logger.Printf("Warning: failed to write the audit log to %s: %s\n", config.AuditLog, auditErr)
+		}
+	}
+
+	if err != nil && d.diagnostics != nil {
+		d.writeDiagnostics(config.DiagnosticsOnFailure, newResolvedDestroyConfig(destroyFlags, state), newSecretScrubber(state), err)
+	}
+
+	return err
+}
+
+// writeDiagnostics collects what support needs to look into a failed destroy
+// into dir. Failing to do so only warns, so that the destroy error is the one
+// returned.
+func (d Destroy) writeDiagnostics(dir string, config resolvedDestroyConfig, scrubber secretScrubber, result error) {
+	state, err := d.stateStore.Get()
+	if err == nil {
+		err = d.diagnostics.write(dir, config, state, result, scrubber)
+	}
+	if err != nil {
+		d.logger.Printf("Warning: failed to write diagnostics to %s: %s\n", dir, err)
+		return
+	}
+
+	d.logger.Printf("Wrote diagnostics for the failed destroy to %s\n", dir)
+}
+
+func (d Destroy) execute(config DestroyConfig, state storage.State) error {
+	var err error
+
+	if config.PrintRequiredPermissions {
+		permissions, err := requiredDestroyPermissions(state.IAAS)
+		if err != nil {
+			return err
+		}
+		d.stdoutLogger.Println(permissions)
+		return nil
+	}
+
+	if config.DryRun {
+		return d.dryRun(config, state)
+	}
+
+	if config.MeasureOnly {
+		return d.measure(config, state)
+	}
+
+	if config.IdempotencyKey != "" {
+		completed, err := d.destroyKeys.Completed(config.IdempotencyKey)
+		if err != nil {
+			return err
+		}
+		if completed {
+			d.logger.Println(fmt.Sprintf("destroy already completed for key %s", config.IdempotencyKey))
+			return nil
+		}
+	}
+
+	if config.CancelScheduledDestroy {
+		err = d.destroySchedule.Cancel(d.schedulePath(config))
+		if err != nil {
+			return err
+		}
+		d.logger.Step("cancelled the scheduled destroy of %q", state.EnvID)
+		return nil
+	}
+
+	if config.ScheduleDestroy {
+		due, err := d.scheduleDestroy(config, state)
+		if err != nil || !due {
+			return err
+		}
+	}
+
+	if config.ConnectivityCheck {
+		return d.checkConnectivity(state)
+	}
+
+	if state.EnvClass == "" && isProduction(config.ProductionPattern, state.EnvID) {
+		state.EnvClass = productionEnvClass
+		if err := d.stateStore.Set(state); err != nil {
+			return fmt.Errorf("Save state: %s", err)
+		}
+	}
+
+	if config.ConfirmEnvID != "" {
+		if config.ConfirmEnvID != state.EnvID {
+			return fmt.Errorf("env id %q does not match state env id %q", config.ConfirmEnvID, state.EnvID)
+		}
+		d.audit.confirm(auditConfirmedByEnvID)
+	} else {
+		proceed, err := d.confirm(config, state)
+		if err != nil || !proceed {
+			return err
+		}
+	}
+
+	if config.ApprovalURL != "" && d.approvalService != nil {
+		err = d.waitForApproval(config, state)
+		if err != nil {
+			return err
+		}
+	}
+
+	if config.CleanersOnly {
+		return d.runCleaners(state)
+	}
+
+	if config.RepairState {
+		state, err = d.repairState(state)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The timeout starts once the destroy is confirmed and approved.
+	if config.Timeout > 0 {
+		ctx := d.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var cancel context.CancelFunc
+		d.ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	d.boshManager.SetDeleteEnvDebug(config.BOSHDebug)
+	d.boshManager.SetDeleteEnvDeadline(d.deadline())
+
+	events := destroyEvents{}
+	if d.eventLog != nil {
+		events = append(events, d.eventLog)
+	}
+	if d.diagnostics != nil {
+		events = append(events, d.diagnostics)
+	}
+	if d.audit != nil {
+		events = append(events, d.audit)
+	}
+
+	var destroyMetrics *destroyMetrics
+	if config.MetricsPushgateway != "" {
+		destroyMetrics = newDestroyMetrics(state.EnvID, state.IAAS)
+		events = append(events, destroyMetrics)
+	}
+
+	events.emit(DestroyPhaseAll, DestroyEventStarted, nil)
+	err = d.destroy(config, state, events)
+	if err == nil && config.VerifyIdempotent {
+		err = d.verifyDestroyed()
+	}
+	if err == nil && config.RegionAll {
+		err = d.deleteInOtherRegions(state)
+	}
+	if err == nil && config.ReconcileAfter {
+		err = d.reconcile(state, config.FailOnResidual)
+	}
+	if err == nil && config.LeaveTombstone != "" {
+		err = d.leaveTombstone(config.LeaveTombstone, state)
+	}
+	if err == nil && config.IdempotencyKey != "" {
+		err = d.destroyKeys.Complete(config.IdempotencyKey)
+	}
+	if err == nil && config.ScheduleDestroy {
+		err = d.destroySchedule.Cancel(d.schedulePath(config))
+	}
+	events.finish(DestroyPhaseAll, err)
+
+	if destroyMetrics != nil {
+		pushErr := d.metricsPusher.PushDestroy(config.MetricsPushgateway, destroyMetrics.destroy)
+		if pushErr != nil {
+			d.logger.Printf("Warning: failed to push metrics to %s: %s\n", config.MetricsPushgateway, pushErr)
+		}
+	}
+
+	return err
+}
+
+// confirm asks whether to go on with the destroy, making the operator type
+// the env id back for production environments. Every %s in --confirm-message
+// is replaced with the env id.
+func (d Destroy) confirm(config DestroyConfig, state storage.State) (bool, error) {
+	if err := d.logger.CanPrompt(); err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Are you sure you want to delete infrastructure for %q? This operation cannot be undone!", state.EnvID)
+	if config.ConfirmMessage != "" {
+		message = strings.Replace(config.ConfirmMessage, "%s", state.EnvID, -1)
+	}
+
+	var proceed bool
+	confirmedBy := auditConfirmedByPrompt
+	if state.EnvClass == productionEnvClass {
+		proceed = d.logger.PromptForName(fmt.Sprintf("%q is a production environment. %s", state.EnvID, message), state.EnvID)
+		confirmedBy = auditConfirmedByName
+	} else {
+		proceed = d.logger.Prompt(message)
+	}
+	if d.noConfirm {
+		confirmedBy = auditConfirmedByNoConfirm
+	}
+
+	if !proceed {
+		d.logger.Step("exiting")
+	} else {
+		d.audit.confirm(confirmedBy)
+	}
+
+	return proceed, nil
+}
+
+func (d Destroy) destroy(config DestroyConfig, state storage.State, events destroyEvents) error {
+	if !d.plan.IsInitialized(state) {
+		planConfig := PlanConfig{
+			Name: state.EnvID,
+			LB:   state.LB,
+		}
+
+		var err error
+		state, err = d.plan.InitializePlan(planConfig, state)
+		if err != nil {
+			return fmt.Errorf("Initialize plan during destroy: %s", err)
+		}
+	}
+
+	d.explain(config, "the state is for a %s environment, so the %s templates and clients are used", state.IAAS, state.IAAS)
+
+	isPaved, err := d.terraformManager.IsPaved()
+	if err != nil {
+		return err
+	}
+
+	if !isPaved {
+		d.explain(config, "no terraform state found, skipping the infrastructure because there is nothing for terraform to destroy")
+		events.emit(DestroyPhaseTerraform, DestroyEventSkipped, nil)
+		if err := d.deleteEnvResources(config, state.EnvID, events); err != nil {
+			return err
+		}
+		if err := d.stateStore.Set(storage.State{}); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if err = d.cancelled(); err != nil {
+		return err
+	}
+
+	if config.RecoveryBundle != "" {
+		err = d.writeRecoveryBundle(config, state)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.explain(config, "terraform state found, the infrastructure is destroyed after the director and jumpbox")
+
+	switch {
+	case state.LB.Type == "":
+		d.explain(config, "no load balancers in the state, so there are none to delete")
+	case config.LBsBeforeDirector:
+		d.explain(config, "deleting the %s load balancers before the director because of --lbs-before-director", state.LB.Type)
+	default:
+		d.explain(config, "the %s load balancers are deleted with the rest of the infrastructure", state.LB.Type)
+	}
+
+	if config.LBsBeforeDirector && state.LB.Type != "" {
+		state, err = d.deleteLBs(config, state, events)
+		if err != nil {
+			return err
+		}
+
+		if err = d.saveIfCancelled(state); err != nil {
+			return err
+		}
+	}
+
+	terraformOutputs, err := d.terraformOutputs()
+	if err != nil {
+		return err
+	}
+
+	var boshErr error
+	state, err = d.deleteBOSH(state, terraformOutputs, config, events)
+	switch err.(type) {
+	case bosh.ManagerDeleteError:
+		mdErr := err.(bosh.ManagerDeleteError)
+		setErr := d.stateStore.Set(mdErr.State())
+		if setErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(setErr)
+			return errorList
+		}
+
+		if !config.ContinuePastBOSHFailure {
+			return err
+		}
+
+		d.logger.Println(fmt.Sprintf("Continuing to destroy the infrastructure after bosh failed: %s", err))
+		state = mdErr.State()
+		boshErr = err
+	case error:
+		return err
+	}
+
+	if err := d.stateStore.Set(state); err != nil {
+		return err
+	}
+
+	if config.DeletePeerings {
+		d.logger.Step("deleting vpc peering connections")
+		err = d.vpcPeeringChecker.DeletePeerings(terraformOutputs.GetString("vpc_id"))
+		if err != nil {
+			return fmt.Errorf("Delete vpc peerings: %s", err)
+		}
+	}
+
+	if config.TFTemplateFile != "" {
+		template, err := readTemplateOverride(config.TFTemplateFile)
+		if err != nil {
+			return err
+		}
+
+		if err = d.terraformManager.SetupWithTemplate(state, template); err != nil {
+			return err
+		}
+	} else if err = d.terraformManager.Setup(state); err != nil {
+		return err
+	}
+
+	if err = d.saveIfCancelled(state); err != nil {
+		return err
+	}
+
+	if config.KeepNetwork {
+		d.explain(config, "keeping the network because of --keep-network")
+	}
+
+	iaas, envID := state.IAAS, state.EnvID
+
+	events.emit(DestroyPhaseTerraform, DestroyEventStarted, nil)
+	if err = config.injectedFailure(DestroyPhaseTerraform); err == nil {
+		state, err = d.destroyInfrastructure(config, state)
+		if err != nil && iaasCredentialFailure.MatchString(err.Error()+state.LatestTFOutput) {
+			state, err = d.retryWithRefreshedCredentials(state, "terraform destroy", err, func(state storage.State) (storage.State, error) {
+				return d.destroyInfrastructure(config, state)
+			})
+		}
+	}
+	events.finishDeleted(DestroyPhaseTerraform, terraformDestroyedCount(state.LatestTFOutput), err)
+	if err != nil {
+		return handleTerraformError(err, state, d.stateStore)
+	}
+
+	// The state is kept until the cleanups are done, so that a destroy that
+	// fails here can be run again.
+	if err = d.deleteEnvResources(config, envID, events); err != nil {
+		if setErr := d.stateStore.Set(state); setErr != nil {
+			errorList := helpers.Errors{}
+			errorList.Add(err)
+			errorList.Add(setErr)
+			return errorList
+		}
+		return err
+	}
+
+	if boshErr != nil {
+		d.logger.Println(fmt.Sprintf("Warning: the infrastructure was destroyed but bosh failed to delete: %s. Director or jumpbox vms may have been left behind.", boshErr))
+	}
+
+	if config.KeepNetwork {
+		// The network is still in the terraform state, so keep the bbl
+		// state around for a later up or destroy to pick it up, with the
+		// iaas it was destroyed with.
+		return d.resultStore.Set(state)
+	}
+
+	if err := d.stateStore.Set(storage.State{}); err != nil {
+		return err
+	}
+
+	if config.PostVerifyEmpty {
+		return d.verifyNetworkEmpty(iaas, envID, terraformOutputs)
+	}
+
+	return nil
+}
+
+// verifyNetworkEmpty runs the network deletion validator again once
+// terraform is done, so that vms terraform did not know about and that
+// survived the teardown are reported rather than left running unnoticed.
+func (d Destroy) verifyNetworkEmpty(iaas, envID string, terraformOutputs terraform.Outputs) error {
+	networkName := envNetworkName(iaas, terraformOutputs)
+	if networkName == "" {
+		return nil
+	}
+
+	d.logger.Step("checking that no vms are left in %s", networkName)
+	err := d.networkDeletionValidator.ValidateSafeToDelete(networkName, envID)
+	if err != nil {
+		d.printBlockingVMs(err)
+		return fmt.Errorf("Verify network is empty: %w", err)
+	}
+
+	return nil
+}
+
+// blockingVMsError is returned by network deletion validators that can
+// describe the vms keeping the network from being deleted.
+type blockingVMsError interface {
+	BlockingVMs() []string
+}
+
+// printBlockingVMs lists the vms in the way, one per line, when the
+// validator describes them.
+func (d Destroy) printBlockingVMs(err error) {
+	blocking, ok := err.(blockingVMsError)
+	if !ok {
+		return
+	}
+
+	d.logger.Println("These vms must be deleted before the network can be:")
+	for _, vm := range blocking.BlockingVMs() {
+		d.logger.Println(fmt.Sprintf("  %s", vm))
+	}
+}
+
+type externalService struct {
+	kind string
+	name string
+}
+
+func externalServices(state storage.State) []externalService {
+	var services []externalService
+	if state.BOSH.ExternalDatabase != "" {
+		services = append(services, externalService{kind: "database", name: state.BOSH.ExternalDatabase})
+	}
+	if state.BOSH.ExternalBlobstore != "" {
+		services = append(services, externalService{kind: "blobstore", name: state.BOSH.ExternalBlobstore})
+	}
+	return services
+}
+
+// checkExternalServices refuses to run the cleaners when they would match
+// an external database or blobstore, since they delete everything named
+// after the env id.
+func checkExternalServices(state storage.State) error {
+	if state.EnvID == "" {
+		return nil
+	}
+
+	for _, service := range externalServices(state) {
+		if strings.Contains(service.name, state.EnvID) {
+			return fmt.Errorf("The cleaners would delete the external %s %q since its name contains the env id. Destroy without --cleaners-only and --region-all.", service.kind, service.name)
+		}
+	}
+
+	return nil
+}
+
+// preemptedDirector reports whether a preemptible director whose deletion
+// failed has already been reclaimed by the iaas, in which case there is
+// nothing left to delete. Directors that are not preemptible are never
+// treated as gone.
+func (d Destroy) preemptedDirector(state storage.State) bool {
+	if !state.BOSH.Preemptible || d.vmChecker == nil {
+		return false
+	}
+
+	cid, ok := state.BOSH.State["current_vm_cid"].(string)
+	if !ok || cid == "" {
+		return false
+	}
+
+	exists, err := d.vmChecker.VMExists(cid)
+	if err != nil || exists {
+		return false
+	}
+
+	d.logger.Println(fmt.Sprintf("The preemptible director vm %s is already gone, treating the director as deleted.", cid))
+	return true
+}
+
+func readTemplateOverride(path string) (string, error) {
+	template, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Read terraform template override: %s", err)
+	}
+
+	return string(template), nil
+}
+
+// writeRecoveryBundle archives what is needed to recreate the environment
+// before anything is deleted, so a failed bundle leaves the env untouched.
+func (d Destroy) writeRecoveryBundle(config DestroyConfig, state storage.State) error {
+	var key []byte
+	if config.RecoveryBundleKeyFile != "" {
+		var err error
+		key, err = ioutil.ReadFile(config.RecoveryBundleKeyFile)
+		if err != nil {
+			return fmt.Errorf("Read recovery bundle key: %s", err)
+		}
+	}
+
+	terraformOutputs, err := d.terraformOutputs()
+	if err != nil {
+		return err
+	}
+
+	d.logger.Step("writing a recovery bundle to %s", config.RecoveryBundle)
+	err = d.recoveryBundle.Write(config.RecoveryBundle, state, terraformOutputs.Map, key)
+	if err != nil {
+		return fmt.Errorf("Write recovery bundle: %s", err)
+	}
+
+	return nil
+}
+
+func (d Destroy) terraformOutputs() (terraform.Outputs, error) {
+	if d.outputs != nil {
+		return *d.outputs, nil
+	}
+
+	return d.terraformManager.GetOutputs()
+}
+
+func (d Destroy) deleteBOSH(state storage.State, terraformOutputs terraform.Outputs, config DestroyConfig, events destroyEvents) (storage.State, error) {
+	if state.NoDirector {
+		d.explain(config, "the env was created with --no-director, so there is no director or jumpbox to delete")
+		d.logger.Println("No BOSH director, skipping...")
+		events.emit(DestroyPhaseDirector, DestroyEventSkipped, nil)
+		events.emit(DestroyPhaseJumpbox, DestroyEventSkipped, nil)
+		return state, nil
+	}
+
+	var err error
+	if config.SkipBOSH {
+		d.explain(config, "not deleting the director because of --skip-bosh")
+		d.logger.Step("skipping bosh director deletion as requested")
+		events.emit(DestroyPhaseDirector, DestroyEventSkipped, nil)
+		state.BOSH = storage.BOSH{}
+	} else {
+		state, err = d.deleteDirector(state, terraformOutputs, config, events)
+		if err != nil {
+			return state, err
+		}
+	}
+
+	if config.DeleteLeftoverDisks {
+		d.logger.Step("deleting leftover director disks")
+		err = d.diskDeleter.DeleteDisks(state.EnvID)
+		if err != nil {
+			return state, fmt.Errorf("Delete director disks: %s", err)
+		}
+	}
+
+	if err = d.saveIfCancelled(state); err != nil {
+		return state, err
+	}
+
+	events.emit(DestroyPhaseJumpbox, DestroyEventStarted, nil)
+	deleted := 0
+	if err = config.injectedFailure(DestroyPhaseJumpbox); err != nil {
+		err = bosh.NewManagerDeleteError(state, err)
+	} else {
+		err = d.boshManager.DeleteJumpbox(state, terraformOutputs)
+		if err != nil && iaasCredentialFailure.MatchString(err.Error()) {
+			state, err = d.retryWithRefreshedCredentials(state, "the jumpbox delete-env", err, func(state storage.State) (storage.State, error) {
+				return state, d.boshManager.DeleteJumpbox(state, terraformOutputs)
+			})
+		}
+		if err == nil {
+			deleted = 1
+		}
+	}
+	events.finishDeleted(DestroyPhaseJumpbox, deleted, err)
+	if err != nil {
+		return state, err
+	}
+
+	state.Jumpbox = storage.Jumpbox{}
+
+	return state, nil
+}
+
+func (d Destroy) deleteDirector(state storage.State, terraformOutputs terraform.Outputs, config DestroyConfig, events destroyEvents) (storage.State, error) {
+	d.explain(config, "a director is in the state, deleting it before the jumpbox it is reached through")
+
+	if config.DirectorDrainDelay > 0 {
+		if err := d.drainDirector(config.DirectorDrainDelay); err != nil {
+			return state, err
+		}
+	}
+
+	for _, service := range externalServices(state) {
+		d.logger.Step("keeping the external %s %q, it was not created by bbl", service.kind, service.name)
+	}
+
+	var err error
+	if config.PreDeleteHook != "" {
+		err = d.runPreDeleteHook(config, state)
+		if err != nil {
+			return state, err
+		}
+	}
+
+	events.emit(DestroyPhaseDirector, DestroyEventStarted, nil)
+	deleted := 0
+	if err = config.injectedFailure(DestroyPhaseDirector); err != nil {
+		err = bosh.NewManagerDeleteError(state, err)
+	} else {
+		err = d.boshManager.DeleteDirector(state, terraformOutputs)
+		if err != nil && directorAuthFailure.MatchString(err.Error()) {
+			state, err = d.retryDeleteDirector(state, terraformOutputs, err)
+		}
+		if err != nil && iaasCredentialFailure.MatchString(err.Error()) {
+			state, err = d.retryWithRefreshedCredentials(state, "the director delete-env", err, func(state storage.State) (storage.State, error) {
+				return state, d.boshManager.DeleteDirector(state, terraformOutputs)
+			})
+		}
+		if err == nil {
+			deleted = 1
+		} else if d.preemptedDirector(state) {
+			err = nil
+		}
+	}
+	events.finishDeleted(DestroyPhaseDirector, deleted, err)
+	if err != nil {
+		return state, err
+	}
+
+	state.BOSH = storage.BOSH{}
+
+	return state, nil
+}
+
+// explain logs why destroy takes a branch when run with --explain. It never
+// changes what destroy does.
+func (d Destroy) explain(config DestroyConfig, format string, a ...interface{}) {
+	if config.Explain {
+		d.logger.Step("explain: "+format, a...)
+	}
+}
+
+// productionEnvClass is the classification, set with --env-class on up and
+// plan or recorded by --production-pattern, that makes destroy ask for the env
+// id to be typed back instead of y/N.
+const productionEnvClass = "production"
+
+// isProduction reports whether the env id matches --production-pattern.
+func isProduction(pattern, envID string) bool {
+	if pattern == "" {
+		return false
+	}
+	return regexp.MustCompile(pattern).MatchString(envID)
+}
+
+// retryDeleteDirector deals with a director that rejected the credentials in
+// the state, most likely because they were rotated after the state was last
+// written. The credential refresher gets one chance to fetch fresh ones
+// before delete-env is retried. Without a refresher the failure is returned
+// as it was, with a hint.
+func (d Destroy) retryDeleteDirector(state storage.State, terraformOutputs terraform.Outputs, deleteErr error) (storage.State, error) {
+	if d.directorRefresher == nil {
+		d.logger.Println("The director rejected the credentials in the state. They may have been rotated since the state was written.")
+		return state, deleteErr
+	}
+
+	d.logger.Step("the director rejected its credentials, refreshing them and retrying")
+	refreshedState, err := d.directorRefresher.RefreshDirector(state)
+	if err != nil {
+		d.logger.Println(fmt.Sprintf("Warning: could not refresh the director credentials: %s", err))
+		return state, deleteErr
+	}
+
+	return refreshedState, d.boshManager.DeleteDirector(refreshedState, terraformOutputs)
+}
+
+// runPreDeleteHook runs the site-specific executable given with
+// --pre-delete-hook before the director is deleted, logging what it prints.
+func (d Destroy) runPreDeleteHook(config DestroyConfig, state storage.State) error {
+	d.logger.Step("running the pre-delete hook %s", config.PreDeleteHook)
+	output, err := d.hookRunner.Run(config.PreDeleteHook, []string{
+		"BBL_ENV_ID=" + state.EnvID,
+		"BBL_DIRECTOR_ADDRESS=" + state.BOSH.DirectorAddress,
+	})
+	if output != "" {
+		d.logger.Println(strings.TrimRight(output, "\n"))
+	}
+	if err == nil {
+		return nil
+	}
+
+	if config.IgnoreHookFailure {
+		d.logger.Println(fmt.Sprintf("Warning: the pre-delete hook failed, deleting the director anyway: %s", err))
+		return nil
+	}
+
+	return fmt.Errorf("Pre-delete hook: %s", err)
+}
+
+// drainDirector waits before the director is deleted so that in-flight tasks
+// can finish. An interrupt during the wait aborts the destroy.
+func (d Destroy) drainDirector(delay time.Duration) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	d.logger.Step("waiting %s before deleting the director, interrupt to abort", delay)
+	for remaining := delay; remaining > 0; {
+		wait := drainCountdownInterval
+		if remaining < wait {
+			wait = remaining
+		}
+
+		select {
+		case <-interrupt:
+			return errors.New("Destroy aborted while waiting to delete the director")
+		case <-d.done():
+			return d.cancelled()
+		case <-time.After(wait):
+		}
+
+		remaining -= wait
+		if remaining > 0 {
+			d.logger.Step("deleting the director in %s", remaining)
+		}
+	}
+
+	return nil
+}
+
+// done is closed once the destroy's context is done, and never without one.
+func (d Destroy) done() <-chan struct{} {
+	if d.ctx == nil {
+		return nil
+	}
+	return d.ctx.Done()
+}
+
+// context returns the destroy's context, or a background context when it was
+// not given one.
+func (d Destroy) context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// verifyDestroyed reads the state back after a destroy and fails if anything
+// was left behind, which would mean a field was not cleared.
+func (d Destroy) verifyDestroyed() error {
+	state, err := d.stateStore.Get()
+	if err != nil {
+		return fmt.Errorf("Verify destroy: %s", err)
+	}
+
+	if !reflect.DeepEqual(state, storage.State{}) {
+		return fmt.Errorf("Verify destroy: state for %q remains in %s", state.EnvID, d.stateStore.GetStateDir())
+	}
+
+	return nil
+}
+
+// deleteLBs applies the terraform templates without the load balancers so
+// that they are gone before the director is deleted. Everything else is left
+// for the final terraform destroy.
+func (d Destroy) deleteLBs(config DestroyConfig, state storage.State, events destroyEvents) (storage.State, error) {
+	state.LB = storage.LB{}
+
+	err := d.terraformManager.Setup(state)
+	if err != nil {
+		return state, err
+	}
+
+	events.emit(DestroyPhaseLBs, DestroyEventStarted, nil)
+	if err = config.injectedFailure(DestroyPhaseLBs); err == nil {
+		state, err = d.terraformManager.Apply(state)
+	}
+	events.finishDeleted(DestroyPhaseLBs, terraformDestroyedCount(state.LatestTFOutput), err)
+	if err != nil {
+		return state, handleTerraformError(err, state, d.stateStore)
+	}
+
+	err = d.stateStore.Set(state)
+	if err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+// runCleaners deletes every resource whose name matches the env id without
+// consulting terraform or the director. The bbl state is left untouched so
+// that a regular destroy can still be attempted afterwards.
+func (d Destroy) runCleaners(state storage.State) error {
+	d.logger.Step("skipping bosh and terraform, cleaning up resources matching %q", state.EnvID)
+
+	err := d.leftovers.Delete(state.EnvID)
+	if err != nil {
+		return fmt.Errorf("Clean up resources: %s", err)
+	}
+
+	d.logger.Step("finished cleaning up resources matching %q", state.EnvID)
+	return nil
+}
+
+type resolvedDestroyConfig struct {
+	IAAS  string            `json:"iaas"`
+	EnvID string            `json:"env_id"`
+	Flags map[string]string `json:"flags"`
+}
+
+// newResolvedDestroyConfig collects the flags destroy runs with, defaults
+// included, along with what it takes from the state. Passwords in urls are
+// redacted; the credentials in the state are left to the secret scrubber.
+func newResolvedDestroyConfig(destroyFlags flags.Flags, state storage.State) resolvedDestroyConfig {
+	values := destroyFlags.Values()
+	for name, value := range values {
+		values[name] = redactURLPassword(value)
+	}
+
+	return resolvedDestroyConfig{
+		IAAS:  state.IAAS,
+		EnvID: state.EnvID,
+		Flags: values,
+	}
+}
+
+// printConfig prints the resolved destroy configuration with the credentials
+// in the state redacted.
+func (d Destroy) printConfig(destroyFlags flags.Flags, state storage.State) error {
+	contents, err := json.MarshalIndent(newResolvedDestroyConfig(destroyFlags, state), "", "  ")
+	if err != nil {
+		return err // not tested
+	}
+
+	d.stdoutLogger.Println(newSecretScrubber(state).scrub(string(contents)))
+	return nil
+}
+
+func redactURLPassword(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.User == nil {
+		return value
+	}
+
+	password, ok := parsed.User.Password()
+	if !ok || password == "" {
+		return value
+	}
+
+	return strings.Replace(value, ":"+password+"@", ":"+scrubbedSecret+"@", 1)
+}
+
+// waitForApproval asks the approval service to approve the destroy and
+// polls until it is approved, denied or the timeout passes.
+func (d Destroy) waitForApproval(config DestroyConfig, state storage.State) error {
+	requestURL, err := d.approvalService.Request(config.ApprovalURL, state.EnvID)
+	if err != nil {
+		return fmt.Errorf("Request approval: %s", err)
+	}
+	d.logger.Step("requested approval to destroy %q at %s", state.EnvID, requestURL)
+
+	timeout := time.After(config.ApprovalTimeout)
+	for {
+		status, err := d.approvalService.Status(requestURL)
+		if err != nil {
+			return fmt.Errorf("Check approval: %s", err)
+		}
+
+		switch status {
+		case approval.StatusApproved:
+			d.logger.Step("destroy approved")
+			return nil
+		case approval.StatusDenied:
+			return errors.New("Destroy was denied by the approval service")
+		}
+
+		d.logger.Step("approval is %s, checking again in %s", status, config.ApprovalPollInterval)
+		select {
+		case <-timeout:
+			return fmt.Errorf("Timed out after %s waiting for approval", config.ApprovalTimeout)
+		case <-time.After(config.ApprovalPollInterval):
+		}
+	}
+}
+
+// deleteRouters removes the Cloud Routers and their NAT configs that
+// terraform does not own and that are left once terraform destroy is done.
+func (d Destroy) deleteRouters(envID string) (int, error) {
+	deleted, err := d.routerCleaner.DeleteRouters(envID)
+	for _, router := range deleted {
+		d.logger.Step("deleted cloud router %s and its nat configs", router)
+	}
+	if err != nil {
+		return len(deleted), fmt.Errorf("Delete routers: %s", err)
+	}
+
+	return len(deleted), nil
+}
+
+// deleteImages removes the images baked for the environment outside of
+// terraform, along with their snapshots.
+func (d Destroy) deleteImages(envID string) (int, error) {
+	deleted, err := d.imageCleaner.DeleteImages(envID)
+	for _, image := range deleted {
+		d.logger.Step("deleted image %s", image)
+	}
+	if err != nil {
+		return len(deleted), fmt.Errorf("Delete images: %s", err)
+	}
+
+	return len(deleted), nil
+}
+
+// deleteEnvResources runs the cleanups that were asked for, of resources
+// the environment created outside of terraform, as a phase of its own.
+func (d Destroy) deleteEnvResources(config DestroyConfig, envID string, events destroyEvents) error {
+	if !config.DeleteRouters && !config.DeleteImages && !config.DeleteKMSKeys {
+		return nil
+	}
+
+	events.emit(DestroyPhaseResources, DestroyEventStarted, nil)
+	deleted, err := d.runEnvResourceCleanups(config, envID)
+	events.finishDeleted(DestroyPhaseResources, deleted, err)
+
+	return err
+}
+
+// runEnvResourceCleanups returns how many resources the cleanups deleted,
+// including the ones deleted before a cleanup failed.
+func (d Destroy) runEnvResourceCleanups(config DestroyConfig, envID string) (int, error) {
+	total := 0
+
+	if config.DeleteRouters {
+		deleted, err := d.deleteRouters(envID)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+	}
+
+	if config.DeleteImages {
+		deleted, err := d.deleteImages(envID)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+	}
+
+	if config.DeleteKMSKeys {
+		deleted, err := d.deleteKMSKeys(envID, config.KMSPendingWindow)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// deleteKMSKeys schedules the deletion of the kms keys the environment
+// provisioned outside of terraform.
+func (d Destroy) deleteKMSKeys(envID string, pendingWindowDays int) (int, error) {
+	deleted, err := d.kmsCleaner.DeleteKMSKeys(envID, pendingWindowDays)
+	for _, key := range deleted {
+		d.logger.Step("scheduled kms key %s for deletion in %d days", key, pendingWindowDays)
+	}
+	if err != nil {
+		return len(deleted), fmt.Errorf("Delete kms keys: %s", err)
+	}
+
+	return len(deleted), nil
+}
+
+// checkOtherRegions checks that the env is safe to delete in every region
+// besides its own, a few regions at a time, and fails if any is not. The
+// unsafe regions are listed by name so the error reads the same however the
+// checks interleave.
+func (d Destroy) checkOtherRegions(state storage.State) error {
+	regions, err := d.regionalSafetyChecker.Regions()
+	if err != nil {
+		return fmt.Errorf("List regions: %s", err)
+	}
+
+	var others []string
+	for _, region := range regions {
+		if region != state.AWS.Region {
+			others = append(others, region)
+		}
+	}
+	sort.Strings(others)
+
+	errs := make([]error, len(others))
+	semaphore := make(chan struct{}, regionalCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, region := range others {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if errs[i] = d.cancelled(); errs[i] != nil {
+				return
+			}
+			errs[i] = d.regionalSafetyChecker.ValidateSafeToDeleteInRegion(region, state.EnvID)
+		}(i, region)
+	}
+	wg.Wait()
+
+	var unsafe []string
+	for i, err := range errs {
+		if err != nil {
+			unsafe = append(unsafe, fmt.Sprintf("  %s: %s", others[i], err))
+		}
+	}
+	if len(unsafe) > 0 {
+		return fmt.Errorf("Not safe to delete in every region:\n%s", strings.Join(unsafe, "\n"))
+	}
+
+	return nil
+}
+
+// reconcile reports the resources that survived the teardown with the env id
+// in their name or tags, including the ones bbl does not manage. They are
+// only warned about unless failOnResidual is set.
+func (d Destroy) reconcile(state storage.State, failOnResidual bool) error {
+	d.logger.Step("checking for resources left with %s in their name or tags", state.EnvID)
+
+	residual, err := d.residualLister.ListResidual(state.EnvID)
+	if err != nil {
+		if failOnResidual {
+			return fmt.Errorf("Reconcile after destroy: %s", err)
+		}
+		d.logger.Printf("Warning: some resources could not be checked: %s\n", err)
+	}
+
+	if len(residual) == 0 {
+		return nil
+	}
+
+	for _, resource := range residual {
+		d.logger.Println(fmt.Sprintf("Warning: %s was left behind", resource))
+	}
+
+	if failOnResidual {
+		return fmt.Errorf("%d resources with %q in their name or tags were left behind", len(residual), state.EnvID)
+	}
+
+	return nil
+}
+
+// deleteInOtherRegions looks for resources matching the env id in every
+// region other than the configured one and deletes them, reporting how each
+// region went.
+func (d Destroy) deleteInOtherRegions(state storage.State) error {
+	regions, err := d.regionalDeleter.Regions()
+	if err != nil {
+		return fmt.Errorf("List regions: %s", err)
+	}
+
+	var findings, failed []string
+	for _, region := range regions {
+		if region == state.AWS.Region {
+			continue
+		}
+
+		d.logger.Step("cleaning up resources matching %q in %s", state.EnvID, region)
+		err := d.regionalDeleter.DeleteInRegion(region, state.EnvID)
+		if err != nil {
+			findings = append(findings, fmt.Sprintf("  %s: failed: %s", region, err))
+			failed = append(failed, region)
+			continue
+		}
+		findings = append(findings, fmt.Sprintf("  %s: cleaned up", region))
+	}
+
+	d.logger.Println(fmt.Sprintf("Other regions:\n%s", strings.Join(findings, "\n")))
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to clean up resources in %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+func (d Destroy) schedulePath(config DestroyConfig) string {
+	if config.ScheduleFile != "" {
+		return config.ScheduleFile
+	}
+	return filepath.Join(d.stateStore.GetStateDir(), storage.DESTROY_SCHEDULE_FILE)
+}
+
+// scheduleDestroy records the intent to destroy on the first run and reports
+// on later runs whether the cool-off since then has passed.
+func (d Destroy) scheduleDestroy(config DestroyConfig, state storage.State) (bool, error) {
+	path := d.schedulePath(config)
+
+	scheduledAt, scheduled, err := d.destroySchedule.Scheduled(path)
+	if err != nil {
+		return false, err
+	}
+
+	if !scheduled {
+		now := time.Now().UTC()
+		err = d.destroySchedule.Schedule(path, now)
+		if err != nil {
+			return false, err
+		}
+
+		d.logger.Println(fmt.Sprintf("Scheduled the destroy of %q. Run bbl destroy --schedule-destroy again after %s to destroy it.",
+			state.EnvID, now.Add(config.CoolOff).Format(time.RFC3339)))
+		return false, nil
+	}
+
+	due := scheduledAt.Add(config.CoolOff)
+	if time.Now().Before(due) {
+		return false, fmt.Errorf("The destroy of %q was scheduled at %s and cannot run before %s",
+			state.EnvID, scheduledAt.UTC().Format(time.RFC3339), due.UTC().Format(time.RFC3339))
+	}
+
+	return true, nil
+}
+
+type tombstone struct {
+	EnvID       string    `json:"env_id"`
+	IAAS        string    `json:"iaas"`
+	StateID     string    `json:"state_id,omitempty"`
+	DestroyedAt time.Time `json:"destroyed_at"`
+}
+
+// leaveTombstone records in the bucket that the environment existed and was
+// destroyed, for teams that need to audit past environments.
+func (d Destroy) leaveTombstone(bucket string, state storage.State) error {
+	contents, err := json.MarshalIndent(tombstone{
+		EnvID:       state.EnvID,
+		IAAS:        state.IAAS,
+		StateID:     state.ID,
+		DestroyedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err // not tested
+	}
+
+	d.logger.Step("leaving a tombstone for %s in %s", state.EnvID, bucket)
+	err = d.tombstoneWriter.WriteTombstone(bucket, state.EnvID, contents)
+	if err != nil {
+		return fmt.Errorf("Leave tombstone: %s", err)
+	}
+
+	return nil
+}
+
+// checkDeployments refuses to continue while the director still manages
+// deployments, since deleting it would orphan their vms. A director that
+// cannot be reached is not checked.
+func (d Destroy) checkDeployments(state storage.State) error {
+	if state.NoDirector || state.BOSH.DirectorAddress == "" {
+		return nil
+	}
+
+	boshClient, err := d.boshClientProvider.Client(state.Jumpbox, state.BOSH.DirectorAddress,
+		state.BOSH.DirectorUsername, state.BOSH.DirectorPassword, state.BOSH.DirectorSSLCA)
+	if err != nil {
+		d.logger.Println(fmt.Sprintf("Skipping the deployments check, the director could not be reached: %s", err))
+		return nil
+	}
+
+	deployments, err := boshClient.Deployments()
+	if err != nil {
+		d.logger.Println(fmt.Sprintf("Skipping the deployments check, the director could not be reached: %s", err))
+		return nil
+	}
+
+	if len(deployments) > 0 {
+		return fmt.Errorf("The director still has deployments: %s. Delete them first or run bbl destroy with --force.", strings.Join(deployments, ", "))
+	}
+
+	return nil
+}
+
+// checkConnectivity makes a minimal authenticated call against the IAAS API
+// and reports how long it took. Nothing is deleted.
+func (d Destroy) checkConnectivity(state storage.State) error {
+	d.logger.Step("checking connectivity to %s", state.IAAS)
+
+	start := time.Now()
+	err := d.connectivityChecker.CheckConnectivity()
+	latency := time.Since(start)
+	if err != nil {
+		return fmt.Errorf("Connectivity check failed after %s: %s", latency, err)
+	}
+
+	d.logger.Println(fmt.Sprintf("Reached the %s API in %s", state.IAAS, latency))
+	return nil
+}
+
+// retryWithRefreshedCredentials runs an api-bound phase once more with the
+// iaas credentials loaded again, after it failed because they expired or were
+// rejected. Without a refresher the credentials are used as-is and the
+// failure is returned.
+func (d Destroy) retryWithRefreshedCredentials(state storage.State, phase string, failure error, run func(storage.State) (storage.State, error)) (storage.State, error) {
+	if d.credentialRefresher == nil {
+		return state, failure
+	}
+
+	d.logger.Step("the %s credentials were rejected during %s, refreshing them and retrying", state.IAAS, phase)
+	refreshedState, err := d.credentialRefresher.Refresh(state)
+	if err != nil {
+		d.logger.Println(fmt.Sprintf("Warning: could not refresh the %s credentials: %s", state.IAAS, err))
+		return state, failure
+	}
+
+	return run(refreshedState)
 }
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type matchingEnv struct {
+	envID    string
+	stateDir string
+}
+
+// destroyAllMatching destroys every env under the state dir whose env id
+// starts with prefix, one after the other. Each env saves the state it
+// reached when it fails, like a destroy of its own, and the envs after it
+// are still attempted.
+func (d Destroy) destroyAllMatching(prefix string) error {
+	if d.envDestroyer == nil {
+		return errors.New("--all-matching is not supported: no env destroyer is configured")
+	}
+
+	root := d.stateStore.GetStateDir()
+	envs, err := d.matchingEnvs(root, prefix)
+	if err != nil {
+		return err
+	}
+
+	if len(envs) == 0 {
+		d.logger.Step("no env ids under %s start with %q", root, prefix)
+		return nil
+	}
+
+	var (
+		summary   []string
+		failures  helpers.Errors
+		destroyed int
+	)
+	for i, env := range envs {
+		if err := d.cancelled(); err != nil {
+			for _, skipped := range envs[i:] {
+				summary = append(summary, fmt.Sprintf("  %s: not attempted", skipped.envID))
+			}
+			failures.Add(err)
+			break
+		}
+
+		d.logger.Step("destroying %s in %s", env.envID, env.stateDir)
+		err := d.envDestroyer.DestroyEnv(env.stateDir)
+		if err != nil {
+			summary = append(summary, fmt.Sprintf("  %s: failed: %s", env.envID, err))
+			failures.Add(fmt.Errorf("Destroy %s: %s", env.envID, err))
+			continue
+		}
+
+		summary = append(summary, fmt.Sprintf("  %s: destroyed", env.envID))
+		destroyed++
+	}
+
+	d.logger.Println(fmt.Sprintf("Destroyed %d of %d environments matching %q:", destroyed, len(envs), prefix))
+	for _, line := range summary {
+		d.logger.Println(line)
+	}
+
+	if destroyed < len(envs) {
+		return failures
+	}
+
+	return nil
+}
+
+// matchingEnvs finds the state files under root whose env id starts with
+// prefix. A state file that cannot be read is skipped with a warning, so
+// that it does not keep the other envs from being destroyed.
+func (d Destroy) matchingEnvs(root, prefix string) ([]matchingEnv, error) {
+	var envs []matchingEnv
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != storage.STATE_FILE {
+			return nil
+		}
+
+		var state storage.State
+		contents, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(contents, &state)
+		}
+		if err != nil {
+			d.logger.Printf("Warning: skipping %s: %s\n", path, err)
+			return nil
+		}
+
+		if state.EnvID != "" && strings.HasPrefix(state.EnvID, prefix) {
+			envs = append(envs, matchingEnv{envID: state.EnvID, stateDir: filepath.Dir(path)})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Find states under %s: %s", root, err)
+	}
+
+	return envs, nil
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	auditConfirmedByPrompt    = "prompt"
+	auditConfirmedByName      = "typed env id"
+	auditConfirmedByEnvID     = "--confirm-env-id"
+	auditConfirmedByNoConfirm = "--no-confirm"
+)
+
+// destroyAudit collects what the audit log records about a destroy: who
+// confirmed it and how, and the phase that failed, if any.
+type destroyAudit struct {
+	confirmedBy string
+	failedPhase string
+}
+
+func (a *destroyAudit) Consume(event DestroyEvent) {
+	if event.Status == DestroyEventFailed && event.Phase != DestroyPhaseAll {
+		a.failedPhase = event.Phase
+	}
+}
+
+func (a *destroyAudit) confirm(confirmedBy string) {
+	if a != nil {
+		a.confirmedBy = confirmedBy
+	}
+}
+
+type destroyAuditEntry struct {
+	Time        time.Time `json:"time"`
+	EnvID       string    `json:"env_id"`
+	IAAS        string    `json:"iaas"`
+	User        string    `json:"user"`
+	ConfirmedBy string    `json:"confirmed_by,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	FailedPhase string    `json:"failed_phase,omitempty"`
+	Remaining   []string  `json:"remaining,omitempty"`
+}
+
+// writeAuditLog appends one json line for the destroy to path. A destroy that
+// stopped before it was confirmed, such as a dry run or a declined prompt, is
+// recorded as not confirmed. A failed destroy records what is left in the
+// state.
+func (d Destroy) writeAuditLog(path, envID, iaas string, result error, scrubber secretScrubber) error {
+	entry := destroyAuditEntry{
+		Time:        time.Now().UTC(),
+		EnvID:       envID,
+		IAAS:        iaas,
+		User:        os.Getenv("USER"),
+		ConfirmedBy: d.audit.confirmedBy,
+		Outcome:     "succeeded",
+	}
+
+	switch {
+	case result != nil:
+		entry.Outcome = "failed"
+		entry.Error = scrubber.scrub(result.Error())
+		entry.FailedPhase = d.audit.failedPhase
+		entry.Remaining = d.remaining()
+	case entry.ConfirmedBy == "":
+		entry.Outcome = "not confirmed"
+	}
+
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return err // not tested
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("Open audit log: %s", err)
+	}
+
+	_, err = file.Write(append(contents, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// remaining lists what the saved state still holds after a failed destroy.
+// It is best effort, so that the audit entry is written regardless.
+func (d Destroy) remaining() []string {
+	var remaining []string
+
+	state, err := d.stateStore.Get()
+	if err == nil {
+		if !state.BOSH.IsEmpty() {
+			remaining = append(remaining, "director")
+		}
+		if !state.Jumpbox.IsEmpty() {
+			remaining = append(remaining, "jumpbox")
+		}
+		if state.LB.Type != "" {
+			remaining = append(remaining, fmt.Sprintf("%s load balancers", state.LB.Type))
+		}
+	}
+
+	if isPaved, err := d.terraformManager.IsPaved(); err == nil && isPaved {
+		remaining = append(remaining, "infrastructure")
+	}
+
+	return remaining
+}
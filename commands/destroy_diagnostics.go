@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// destroyDiagnostics keeps every destroy event so that, when the destroy
+// fails, they can be written out with the state and the latest terraform
+// output for a bug report. Nothing is written when the destroy succeeds.
+type destroyDiagnostics struct {
+	events []DestroyEvent
+}
+
+func (d *destroyDiagnostics) Consume(event DestroyEvent) {
+	d.events = append(d.events, event)
+}
+
+// write puts the events, the saved state and the latest terraform output in
+// dir, with the credentials known from the state redacted.
+func (d *destroyDiagnostics) write(dir string, config resolvedDestroyConfig, state storage.State, result error, scrubber secretScrubber) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("Create diagnostics directory: %s", err)
+	}
+
+	eventLog, err := openDestroyEventLog(filepath.Join(dir, "events.jsonl"), config, scrubber)
+	if err != nil {
+		return err
+	}
+	for _, event := range d.events {
+		eventLog.Consume(event)
+	}
+	err = eventLog.close(result)
+	if err != nil {
+		return fmt.Errorf("Write event log: %s", err)
+	}
+
+	contents, err := scrubbedStateJSON(state, scrubber)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, storage.STATE_FILE), contents, 0600)
+	if err != nil {
+		return fmt.Errorf("Write state: %s", err)
+	}
+
+	if state.LatestTFOutput != "" {
+		err = ioutil.WriteFile(filepath.Join(dir, "terraform-output.txt"), []byte(scrubber.scrub(state.LatestTFOutput)), 0600)
+		if err != nil {
+			return fmt.Errorf("Write terraform output: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// scrubbedStateJSON redacts every string in the state rather than the
+// encoded json, since secrets such as service account keys are escaped once
+// encoded and would no longer match.
+func scrubbedStateJSON(state storage.State, scrubber secretScrubber) ([]byte, error) {
+	contents, err := json.Marshal(state)
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	var fields interface{}
+	err = json.Unmarshal(contents, &fields)
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	return json.MarshalIndent(scrubValues(fields, scrubber), "", "  ")
+}
+
+func scrubValues(value interface{}, scrubber secretScrubber) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return scrubber.scrub(typed)
+	case map[string]interface{}:
+		for key, field := range typed {
+			typed[key] = scrubValues(field, scrubber)
+		}
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = scrubValues(item, scrubber)
+		}
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// dryRun logs what destroy would do with the state it was given, taking the
+// same decisions as a real destroy. It only reads from the iaas and never
+// deletes anything or writes the state.
+func (d Destroy) dryRun(config DestroyConfig, state storage.State) error {
+	would := func(format string, a ...interface{}) {
+		d.logger.Step("would "+format, a...)
+	}
+
+	if config.CleanersOnly {
+		return d.dryRunCleaners(state, would)
+	}
+
+	isPaved, err := d.terraformManager.IsPaved()
+	if err != nil {
+		return err
+	}
+
+	if !isPaved {
+		would("skip the infrastructure, there is no terraform state")
+		would("clear the bbl state")
+		return nil
+	}
+
+	if config.LBsBeforeDirector && state.LB.Type != "" {
+		would("delete the %s load balancers", state.LB.Type)
+	}
+
+	if state.NoDirector {
+		would("skip the director and jumpbox, the env was created with --no-director")
+	} else {
+		for _, service := range externalServices(state) {
+			would("keep the external %s %q, it was not created by bbl", service.kind, service.name)
+		}
+		if config.SkipBOSH {
+			would("clear the bosh director from the state without deleting it")
+		} else {
+			if config.PreDeleteHook != "" {
+				would("run the pre-delete hook %s", config.PreDeleteHook)
+			}
+			if !state.BOSH.IsEmpty() {
+				would("delete the bosh director %s", state.BOSH.DirectorName)
+			}
+		}
+		if config.DeleteLeftoverDisks {
+			would("delete leftover director disks")
+		}
+		if !state.Jumpbox.IsEmpty() {
+			would("delete the jumpbox")
+		}
+	}
+
+	if config.DeletePeerings {
+		would("delete the vpc peering connections")
+	}
+
+	if config.KeepNetwork {
+		would("run terraform destroy, keeping the network")
+	} else {
+		would("run terraform destroy")
+	}
+
+	if config.PlanFormat == "terraform" {
+		addresses, err := d.terraformManager.DestroyAddresses(state, config.KeepNetwork)
+		if err != nil {
+			return err
+		}
+		d.stdoutLogger.Println(terraformDestroyPlan(addresses))
+	}
+
+	if config.DeleteRouters {
+		would("delete the cloud routers for %s", state.EnvID)
+	}
+
+	if config.DeleteImages {
+		would("delete the images for %s", state.EnvID)
+	}
+
+	if config.DeleteKMSKeys {
+		would("schedule the kms keys for %s for deletion in %d days", state.EnvID, config.KMSPendingWindow)
+	}
+
+	if config.KeepNetwork {
+		would("keep the bbl state for the network")
+	} else {
+		would("clear the bbl state")
+	}
+
+	if config.PostVerifyEmpty {
+		would("check that no vms are left in the network")
+	}
+
+	if config.RegionAll {
+		would("delete the resources with %s in their name in every other region", state.EnvID)
+	}
+
+	if config.ReconcileAfter {
+		would("check for resources left with %s in their name or tags", state.EnvID)
+	}
+
+	if config.LeaveTombstone != "" {
+		would("leave a tombstone in %s", config.LeaveTombstone)
+	}
+
+	return nil
+}
+
+// terraformDestroyPlan renders the resources terraform destroy would delete
+// the way terraform plan -destroy lists them.
+func terraformDestroyPlan(addresses []string) string {
+	lines := []string{"Terraform will perform the following actions:", ""}
+	for _, address := range addresses {
+		lines = append(lines, "  - "+address, "")
+	}
+	lines = append(lines, fmt.Sprintf("Plan: 0 to add, 0 to change, %d to destroy.", len(addresses)))
+
+	return strings.Join(lines, "\n")
+}
+
+// dryRunCleaners lists the resources the cleaners would delete when the iaas
+// can list them without deleting.
+func (d Destroy) dryRunCleaners(state storage.State, would func(string, ...interface{})) error {
+	if d.residualLister == nil {
+		would("delete the resources matching %q", state.EnvID)
+		return nil
+	}
+
+	resources, err := d.residualLister.ListResidual(state.EnvID)
+	if err != nil {
+		return fmt.Errorf("List resources: %s", err)
+	}
+
+	for _, resource := range resources {
+		would("delete %s", resource)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/metrics"
+)
+
+const (
+	DestroyPhaseAll       = "destroy"
+	DestroyPhaseDirector  = "delete-director"
+	DestroyPhaseJumpbox   = "delete-jumpbox"
+	DestroyPhaseLBs       = "delete-lbs"
+	DestroyPhaseTerraform = "terraform-destroy"
+	DestroyPhaseResources = "delete-env-resources"
+
+	DestroyEventStarted  = "started"
+	DestroyEventFinished = "finished"
+	DestroyEventSkipped  = "skipped"
+	DestroyEventFailed   = "failed"
+)
+
+// DestroyEvent is a change in the status of a destroy phase. Deleted is the
+// number of resources a phase deleted, reported when it finishes or fails.
+type DestroyEvent struct {
+	Phase   string
+	Status  string
+	Error   error
+	Deleted int
+	Time    time.Time
+}
+
+type destroyEventConsumer interface {
+	Consume(DestroyEvent)
+}
+
+type destroyEvents []destroyEventConsumer
+
+func (e destroyEvents) emit(phase, status string, err error) {
+	e.emitDeleted(phase, status, 0, err)
+}
+
+func (e destroyEvents) emitDeleted(phase, status string, deleted int, err error) {
+	event := DestroyEvent{
+		Phase:   phase,
+		Status:  status,
+		Error:   err,
+		Deleted: deleted,
+		Time:    time.Now(),
+	}
+
+	for _, consumer := range e {
+		consumer.Consume(event)
+	}
+}
+
+func (e destroyEvents) finish(phase string, err error) {
+	e.finishDeleted(phase, 0, err)
+}
+
+// finishDeleted is finish for a phase that counts the resources it deleted,
+// which a failed phase may have done too before it failed.
+func (e destroyEvents) finishDeleted(phase string, deleted int, err error) {
+	if err != nil {
+		e.emitDeleted(phase, DestroyEventFailed, deleted, err)
+		return
+	}
+	e.emitDeleted(phase, DestroyEventFinished, deleted, nil)
+}
+
+var terraformDestroyed = regexp.MustCompile(`Resources: (?:\d+ added, \d+ changed, )?(\d+) destroyed`)
+
+// terraformDestroyedCount reads the number of resources terraform destroyed
+// from the summary line of its output.
+func terraformDestroyedCount(output string) int {
+	matches := terraformDestroyed.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+
+	count, err := strconv.Atoi(matches[len(matches)-1][1])
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+type destroyMetrics struct {
+	destroy metrics.Destroy
+	started time.Time
+}
+
+func newDestroyMetrics(envID, iaas string) *destroyMetrics {
+	return &destroyMetrics{
+		destroy: metrics.Destroy{EnvID: envID, IAAS: iaas},
+	}
+}
+
+func (m *destroyMetrics) Consume(event DestroyEvent) {
+	if event.Phase == DestroyPhaseAll {
+		switch event.Status {
+		case DestroyEventStarted:
+			m.started = event.Time
+		case DestroyEventFinished, DestroyEventFailed:
+			m.destroy.Duration = event.Time.Sub(m.started)
+			m.destroy.Success = event.Status == DestroyEventFinished
+		}
+		return
+	}
+
+	m.destroy.ResourcesDeleted += event.Deleted
+}
+
+// destroyEventLog writes the resolved config, every destroy event and the
+// result as json lines, with the credentials in the state redacted, so that
+// a run can be attached to a bug report.
+type destroyEventLog struct {
+	file     *os.File
+	encoder  *json.Encoder
+	scrubber secretScrubber
+	err      error
+}
+
+type destroyEventLogLine struct {
+	Time   time.Time              `json:"time"`
+	Type   string                 `json:"type"`
+	Config *resolvedDestroyConfig `json:"config,omitempty"`
+	Phase  string                 `json:"phase,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+func openDestroyEventLog(path string, config resolvedDestroyConfig, scrubber secretScrubber) (*destroyEventLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Open event log: %s", err)
+	}
+
+	eventLog := &destroyEventLog{
+		file:     file,
+		encoder:  json.NewEncoder(file),
+		scrubber: scrubber,
+	}
+	eventLog.write(destroyEventLogLine{
+		Time:   time.Now(),
+		Type:   "config",
+		Config: &config,
+	})
+
+	return eventLog, nil
+}
+
+func (l *destroyEventLog) Consume(event DestroyEvent) {
+	l.write(destroyEventLogLine{
+		Time:   event.Time,
+		Type:   "event",
+		Phase:  event.Phase,
+		Status: event.Status,
+		Error:  l.errorMessage(event.Error),
+	})
+}
+
+// close writes the result of the destroy and returns the first error hit
+// while writing the log.
+func (l *destroyEventLog) close(result error) error {
+	l.write(destroyEventLogLine{
+		Time:  time.Now(),
+		Type:  "result",
+		Error: l.errorMessage(result),
+	})
+
+	err := l.file.Close()
+	if l.err == nil {
+		l.err = err
+	}
+
+	return l.err
+}
+
+func (l *destroyEventLog) write(line destroyEventLogLine) {
+	if l.err != nil {
+		return
+	}
+
+	if line.Config != nil {
+		flags := map[string]string{}
+		for name, value := range line.Config.Flags {
+			flags[name] = l.scrubber.scrub(value)
+		}
+		line.Config.Flags = flags
+	}
+
+	l.err = l.encoder.Encode(line)
+}
+
+func (l *destroyEventLog) errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return l.scrubber.scrub(err.Error())
+}
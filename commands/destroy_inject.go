@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// failureInjectionEnabled is only set in binaries built with the
+// failureinjection tag, so release builds never accept --inject-failure.
+var failureInjectionEnabled = false
+
+var injectablePhases = []string{
+	DestroyPhaseDirector,
+	DestroyPhaseJumpbox,
+	DestroyPhaseLBs,
+	DestroyPhaseTerraform,
+}
+
+func validateInjectFailure(phase string) error {
+	for _, injectable := range injectablePhases {
+		if phase == injectable {
+			return nil
+		}
+	}
+	return fmt.Errorf("--inject-failure must be one of: %s", strings.Join(injectablePhases, ", "))
+}
+
+// injectedFailure returns a synthetic error for the phase named with
+// --inject-failure, so that the partial state saving after a failed phase
+// can be exercised without a real iaas failure.
+func (c DestroyConfig) injectedFailure(phase string) error {
+	if c.InjectFailure != phase {
+		return nil
+	}
+	return fmt.Errorf("Injected failure in %s", phase)
+}
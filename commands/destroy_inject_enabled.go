@@ -0,0 +1,8 @@
+//go:build failureinjection
+// +build failureinjection
+
+package commands
+
+func init() {
+	failureInjectionEnabled = true
+}
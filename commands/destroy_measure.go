@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+)
+
+// billableResourceTypes are the terraform resource types --measure-only
+// reports: instances, disks, load balancers and public ips.
+var billableResourceTypes = map[string]bool{
+	"aws_instance":                          true,
+	"aws_ebs_volume":                        true,
+	"aws_elb":                               true,
+	"aws_lb":                                true,
+	"aws_eip":                               true,
+	"aws_nat_gateway":                       true,
+	"google_compute_instance":               true,
+	"google_compute_disk":                   true,
+	"google_compute_forwarding_rule":        true,
+	"google_compute_global_forwarding_rule": true,
+	"google_compute_address":                true,
+	"google_compute_global_address":         true,
+	"azurerm_virtual_machine":               true,
+	"azurerm_managed_disk":                  true,
+	"azurerm_lb":                            true,
+	"azurerm_public_ip":                     true,
+}
+
+type billableResource struct {
+	name string
+	kind string
+}
+
+// measure prints the billable resources destroy would delete, worked out the
+// same way as --dry-run, and what they cost a month according to
+// --price-list. Nothing is deleted and the state is not written.
+func (d Destroy) measure(config DestroyConfig, state storage.State) error {
+	prices, err := readPriceList(config.PriceList)
+	if err != nil {
+		return err
+	}
+
+	var resources []billableResource
+	if !state.NoDirector {
+		if !config.SkipBOSH && !state.BOSH.IsEmpty() {
+			resources = append(resources, billableResource{name: "bosh director " + state.BOSH.DirectorName, kind: "director"})
+		}
+		if !state.Jumpbox.IsEmpty() {
+			resources = append(resources, billableResource{name: "jumpbox", kind: "jumpbox"})
+		}
+	}
+
+	isPaved, err := d.terraformManager.IsPaved()
+	if err != nil {
+		return err
+	}
+
+	if isPaved {
+		addresses, err := d.terraformManager.DestroyAddresses(state, config.KeepNetwork)
+		if err != nil {
+			return err
+		}
+		for _, address := range addresses {
+			resourceType, _ := terraform.ResourceType(address)
+			if billableResourceTypes[resourceType] {
+				resources = append(resources, billableResource{name: address, kind: resourceType})
+			}
+		}
+	}
+
+	var (
+		lines []string
+		total float64
+	)
+	for _, resource := range resources {
+		price, ok := prices[resource.kind]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("%s: %s is not in the price list", resource.name, resource.kind))
+			continue
+		}
+		total += price
+		lines = append(lines, fmt.Sprintf("%s: $%.2f a month", resource.name, price))
+	}
+	lines = append(lines, fmt.Sprintf("Destroying %s would save about $%.2f a month", state.EnvID, total))
+
+	d.stdoutLogger.Println(strings.Join(lines, "\n"))
+	return nil
+}
+
+// readPriceList reads the monthly price of each kind of billable resource,
+// keyed by terraform resource type, or director and jumpbox for the vms bosh
+// creates.
+func readPriceList(path string) (map[string]float64, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Read price list: %s", err)
+	}
+
+	var prices map[string]float64
+	if err := json.Unmarshal(contents, &prices); err != nil {
+		return nil, fmt.Errorf("Parse price list: %s", err)
+	}
+
+	return prices, nil
+}
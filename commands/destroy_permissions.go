@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// destroyPermissions lists, per IAAS, the API calls that bbl makes itself
+// during destroy and the permissions the bosh cpi needs to delete the
+// director and jumpbox.
+var destroyPermissions = map[string]map[string][]string{
+	"aws": {
+		"bbl": {
+			"ec2:DeleteSnapshot",
+			"ec2:DeleteVpcPeeringConnection",
+			"ec2:DeregisterImage",
+			"ec2:DescribeImages",
+			"ec2:DescribeInstances",
+			"ec2:DescribeRegions",
+			"ec2:DescribeVpcPeeringConnections",
+			"ec2:DescribeVpcs",
+			"kms:DeleteAlias",
+			"kms:DescribeKey",
+			"kms:ListAliases",
+			"kms:ListKeys",
+			"kms:ListResourceTags",
+			"kms:ScheduleKeyDeletion",
+			"route53:ListHostedZones",
+			"route53:ListResourceRecordSets",
+			"s3:PutObject",
+			"s3:PutObjectTagging",
+		},
+		"bosh": {
+			"ec2:DeleteSnapshot",
+			"ec2:DeleteVolume",
+			"ec2:DeregisterImage",
+			"ec2:DescribeImages",
+			"ec2:DescribeInstances",
+			"ec2:DescribeVolumes",
+			"ec2:DetachVolume",
+			"ec2:TerminateInstances",
+		},
+	},
+	"azure": {
+		"bbl": {
+			"Microsoft.Compute/virtualMachines/read",
+		},
+		"bosh": {
+			"Microsoft.Compute/disks/delete",
+			"Microsoft.Compute/disks/read",
+			"Microsoft.Compute/images/delete",
+			"Microsoft.Compute/images/read",
+			"Microsoft.Compute/virtualMachines/delete",
+			"Microsoft.Compute/virtualMachines/read",
+			"Microsoft.Network/networkInterfaces/delete",
+			"Microsoft.Network/networkInterfaces/read",
+		},
+	},
+	"gcp": {
+		"bbl": {
+			"compute.disks.delete",
+			"compute.disks.list",
+			"compute.images.delete",
+			"compute.images.list",
+			"compute.instances.list",
+			"compute.projects.get",
+			"compute.regions.get",
+			"compute.routers.delete",
+			"compute.routers.list",
+			"compute.zones.get",
+			"compute.zones.list",
+		},
+		"bosh": {
+			"compute.disks.delete",
+			"compute.disks.get",
+			"compute.images.delete",
+			"compute.images.get",
+			"compute.instances.delete",
+			"compute.instances.detachDisk",
+			"compute.instances.get",
+		},
+	},
+}
+
+// terraformDestroyPermissions maps each resource type in the bbl terraform
+// templates to what terraform needs to refresh and then delete it.
+var terraformDestroyPermissions = map[string]map[string][]string{
+	"aws": {
+		"aws_cloudwatch_log_group":       {"logs:DeleteLogGroup", "logs:DescribeLogGroups", "logs:ListTagsLogGroup"},
+		"aws_default_security_group":     {"ec2:DescribeSecurityGroups"},
+		"aws_eip":                        {"ec2:DescribeAddresses", "ec2:DisassociateAddress", "ec2:ReleaseAddress"},
+		"aws_elb":                        {"elasticloadbalancing:DeleteLoadBalancer", "elasticloadbalancing:DescribeLoadBalancerAttributes", "elasticloadbalancing:DescribeLoadBalancers", "elasticloadbalancing:DescribeTags"},
+		"aws_flow_log":                   {"ec2:DeleteFlowLogs", "ec2:DescribeFlowLogs"},
+		"aws_iam_instance_profile":       {"iam:DeleteInstanceProfile", "iam:GetInstanceProfile", "iam:RemoveRoleFromInstanceProfile"},
+		"aws_iam_policy":                 {"iam:DeletePolicy", "iam:GetPolicy", "iam:GetPolicyVersion", "iam:ListPolicyVersions"},
+		"aws_iam_role":                   {"iam:DeleteRole", "iam:GetRole", "iam:ListAttachedRolePolicies", "iam:ListInstanceProfilesForRole"},
+		"aws_iam_role_policy":            {"iam:DeleteRolePolicy", "iam:GetRolePolicy"},
+		"aws_iam_role_policy_attachment": {"iam:DetachRolePolicy", "iam:ListAttachedRolePolicies"},
+		"aws_iam_server_certificate":     {"iam:DeleteServerCertificate", "iam:GetServerCertificate"},
+		"aws_instance":                   {"ec2:DescribeInstanceAttribute", "ec2:DescribeInstances", "ec2:DescribeVolumes", "ec2:TerminateInstances"},
+		"aws_internet_gateway":           {"ec2:DeleteInternetGateway", "ec2:DescribeInternetGateways", "ec2:DetachInternetGateway"},
+		"aws_key_pair":                   {"ec2:DeleteKeyPair", "ec2:DescribeKeyPairs"},
+		"aws_kms_key":                    {"kms:DescribeKey", "kms:GetKeyPolicy", "kms:GetKeyRotationStatus", "kms:ListResourceTags", "kms:ScheduleKeyDeletion"},
+		"aws_lb":                         {"elasticloadbalancing:DeleteLoadBalancer", "elasticloadbalancing:DescribeLoadBalancerAttributes", "elasticloadbalancing:DescribeLoadBalancers", "elasticloadbalancing:DescribeTags"},
+		"aws_lb_listener":                {"elasticloadbalancing:DeleteListener", "elasticloadbalancing:DescribeListeners"},
+		"aws_lb_target_group":            {"elasticloadbalancing:DeleteTargetGroup", "elasticloadbalancing:DescribeTargetGroupAttributes", "elasticloadbalancing:DescribeTargetGroups"},
+		"aws_route":                      {"ec2:DeleteRoute", "ec2:DescribeRouteTables"},
+		"aws_route53_record":             {"route53:ChangeResourceRecordSets", "route53:GetChange", "route53:ListResourceRecordSets"},
+		"aws_route53_zone":               {"route53:DeleteHostedZone", "route53:GetChange", "route53:GetHostedZone", "route53:ListResourceRecordSets", "route53:ListTagsForResource"},
+		"aws_route_table":                {"ec2:DeleteRouteTable", "ec2:DescribeRouteTables"},
+		"aws_route_table_association":    {"ec2:DescribeRouteTables", "ec2:DisassociateRouteTable"},
+		"aws_security_group":             {"ec2:DeleteSecurityGroup", "ec2:DescribeSecurityGroups", "ec2:RevokeSecurityGroupEgress", "ec2:RevokeSecurityGroupIngress"},
+		"aws_security_group_rule":        {"ec2:DescribeSecurityGroups", "ec2:RevokeSecurityGroupEgress", "ec2:RevokeSecurityGroupIngress"},
+		"aws_subnet":                     {"ec2:DeleteSubnet", "ec2:DescribeSubnets"},
+		"aws_vpc":                        {"ec2:DeleteVpc", "ec2:DescribeVpcAttribute", "ec2:DescribeVpcs"},
+		"tls_private_key":                {},
+	},
+	"azure": {
+		"azurerm_application_gateway":     {"Microsoft.Network/applicationGateways/delete", "Microsoft.Network/applicationGateways/read"},
+		"azurerm_dns_a_record":            {"Microsoft.Network/dnsZones/A/delete", "Microsoft.Network/dnsZones/A/read"},
+		"azurerm_dns_zone":                {"Microsoft.Network/dnsZones/delete", "Microsoft.Network/dnsZones/read"},
+		"azurerm_lb":                      {"Microsoft.Network/loadBalancers/delete", "Microsoft.Network/loadBalancers/read"},
+		"azurerm_lb_backend_address_pool": {"Microsoft.Network/loadBalancers/read", "Microsoft.Network/loadBalancers/write"},
+		"azurerm_lb_probe":                {"Microsoft.Network/loadBalancers/read", "Microsoft.Network/loadBalancers/write"},
+		"azurerm_lb_rule":                 {"Microsoft.Network/loadBalancers/read", "Microsoft.Network/loadBalancers/write"},
+		"azurerm_network_security_group":  {"Microsoft.Network/networkSecurityGroups/delete", "Microsoft.Network/networkSecurityGroups/read"},
+		"azurerm_network_security_rule":   {"Microsoft.Network/networkSecurityGroups/securityRules/delete", "Microsoft.Network/networkSecurityGroups/securityRules/read"},
+		"azurerm_public_ip":               {"Microsoft.Network/publicIPAddresses/delete", "Microsoft.Network/publicIPAddresses/read"},
+		"azurerm_resource_group":          {"Microsoft.Resources/subscriptions/resourceGroups/delete", "Microsoft.Resources/subscriptions/resourceGroups/read"},
+		"azurerm_storage_account":         {"Microsoft.Storage/storageAccounts/delete", "Microsoft.Storage/storageAccounts/listKeys/action", "Microsoft.Storage/storageAccounts/read"},
+		"azurerm_storage_container":       {"Microsoft.Storage/storageAccounts/blobServices/containers/delete", "Microsoft.Storage/storageAccounts/blobServices/containers/read", "Microsoft.Storage/storageAccounts/listKeys/action"},
+		"azurerm_subnet":                  {"Microsoft.Network/virtualNetworks/subnets/delete", "Microsoft.Network/virtualNetworks/subnets/read"},
+		"azurerm_virtual_network":         {"Microsoft.Network/virtualNetworks/delete", "Microsoft.Network/virtualNetworks/read"},
+		"random_string":                   {},
+		"tls_private_key":                 {},
+	},
+	"gcp": {
+		"google_compute_address":                {"compute.addresses.delete", "compute.addresses.get"},
+		"google_compute_firewall":               {"compute.firewalls.delete", "compute.firewalls.get"},
+		"google_compute_forwarding_rule":        {"compute.forwardingRules.delete", "compute.forwardingRules.get"},
+		"google_compute_global_address":         {"compute.globalAddresses.delete", "compute.globalAddresses.get"},
+		"google_compute_global_forwarding_rule": {"compute.globalForwardingRules.delete", "compute.globalForwardingRules.get"},
+		"google_compute_health_check":           {"compute.healthChecks.delete", "compute.healthChecks.get"},
+		"google_compute_http_health_check":      {"compute.httpHealthChecks.delete", "compute.httpHealthChecks.get"},
+		"google_compute_network":                {"compute.networks.delete", "compute.networks.get"},
+		"google_compute_ssl_certificate":        {"compute.sslCertificates.delete", "compute.sslCertificates.get"},
+		"google_compute_subnetwork":             {"compute.subnetworks.delete", "compute.subnetworks.get"},
+		"google_compute_target_http_proxy":      {"compute.targetHttpProxies.delete", "compute.targetHttpProxies.get"},
+		"google_compute_target_https_proxy":     {"compute.targetHttpsProxies.delete", "compute.targetHttpsProxies.get"},
+		"google_compute_target_pool":            {"compute.targetPools.delete", "compute.targetPools.get"},
+		"google_compute_url_map":                {"compute.urlMaps.delete", "compute.urlMaps.get"},
+		"google_dns_managed_zone":               {"dns.managedZones.delete", "dns.managedZones.get"},
+		"google_dns_record_set":                 {"dns.changes.create", "dns.changes.get", "dns.resourceRecordSets.list"},
+	},
+}
+
+type requiredPermissions struct {
+	BBL       []string `json:"bbl"`
+	BOSH      []string `json:"bosh"`
+	Terraform []string `json:"terraform"`
+	All       []string `json:"all"`
+}
+
+// requiredDestroyPermissions returns the permissions destroy needs on the
+// given iaas, or on every supported iaas when it is empty.
+func requiredDestroyPermissions(iaas string) (string, error) {
+	permissions := map[string]requiredPermissions{}
+	for name, calls := range destroyPermissions {
+		if iaas != "" && name != iaas {
+			continue
+		}
+
+		var terraform []string
+		for _, actions := range terraformDestroyPermissions[name] {
+			terraform = append(terraform, actions...)
+		}
+
+		permissions[name] = requiredPermissions{
+			BBL:       uniqueSorted(calls["bbl"]),
+			BOSH:      uniqueSorted(calls["bosh"]),
+			Terraform: uniqueSorted(terraform),
+			All:       uniqueSorted(append(append(append([]string{}, calls["bbl"]...), calls["bosh"]...), terraform...)),
+		}
+	}
+
+	contents, err := json.MarshalIndent(permissions, "", "  ")
+	if err != nil {
+		return "", err // not tested
+	}
+
+	return string(contents), nil
+}
+
+func uniqueSorted(list []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, item := range list {
+		if !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
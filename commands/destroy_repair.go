@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// repairState clears references in the state to director and jumpbox vms
+// that no longer exist, so that delete-env does not fail looking for them.
+// The repaired state is saved before the destroy goes on.
+func (d Destroy) repairState(state storage.State) (storage.State, error) {
+	d.logger.Step("checking the state against the iaas")
+
+	boshState, boshRepaired, err := d.clearMissingVM("director", state.BOSH.State)
+	if err != nil {
+		return state, err
+	}
+
+	jumpboxState, jumpboxRepaired, err := d.clearMissingVM("jumpbox", state.Jumpbox.State)
+	if err != nil {
+		return state, err
+	}
+
+	if !boshRepaired && !jumpboxRepaired {
+		return state, nil
+	}
+
+	state.BOSH.State = boshState
+	state.Jumpbox.State = jumpboxState
+
+	err = d.stateStore.Set(state)
+	if err != nil {
+		return state, fmt.Errorf("Save repaired state: %s", err)
+	}
+
+	return state, nil
+}
+
+// clearMissingVM returns a copy of the create-env state without its vm
+// when that vm no longer exists.
+func (d Destroy) clearMissingVM(name string, vmState map[string]interface{}) (map[string]interface{}, bool, error) {
+	cid, ok := vmState["current_vm_cid"].(string)
+	if !ok || cid == "" {
+		return vmState, false, nil
+	}
+
+	exists, err := d.vmChecker.VMExists(cid)
+	if err != nil {
+		return vmState, false, fmt.Errorf("Check %s vm %s: %s", name, cid, err)
+	}
+	if exists {
+		return vmState, false, nil
+	}
+
+	repaired := map[string]interface{}{}
+	for key, value := range vmState {
+		if key != "current_vm_cid" {
+			repaired[key] = value
+		}
+	}
+
+	d.logger.Step("repaired state: the %s vm %s no longer exists, clearing it", name, cid)
+
+	return repaired, true, nil
+}
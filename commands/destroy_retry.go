@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var terraformRetryBackoff = 10 * time.Second
+
+// transientTerraformError matches the rate limits, server errors and dropped
+// connections that iaas apis report while terraform destroys, which tend to go
+// away when terraform is run again. Credential and validation errors do not
+// match.
+var transientTerraformError = regexp.MustCompile(`(?i)(error 5\d\d|error 429|status code: 5\d\d|status code: 429|rateLimitExceeded|rate limit exceeded|RequestLimitExceeded|Throttling|ServiceUnavailable|backendError|connection reset by peer|i/o timeout|TLS handshake timeout)`)
+
+// destroyInfrastructure runs terraform destroy, running it again up to
+// --max-retries times when it fails with a transient error. The wait doubles
+// after each attempt, and the state is saved in between so that the terraform
+// state left by a failed attempt is not lost if bbl is stopped.
+func (d Destroy) destroyInfrastructure(config DestroyConfig, state storage.State) (storage.State, error) {
+	for attempt := 0; ; attempt++ {
+		var err error
+		if config.KeepNetwork {
+			state, err = d.terraformManager.DestroyKeepingNetwork(d.context(), state)
+		} else {
+			state, err = d.terraformManager.Destroy(d.context(), state)
+		}
+
+		if err == nil || attempt == config.MaxRetries {
+			return state, err
+		}
+
+		if !transientTerraformError.MatchString(err.Error()) && !transientTerraformError.MatchString(state.LatestTFOutput) {
+			return state, err
+		}
+
+		if setErr := d.stateStore.Set(state); setErr != nil {
+			return state, setErr
+		}
+
+		backoff := terraformRetryBackoff * time.Duration(1<<uint(attempt))
+		d.logger.Step("terraform destroy failed with a transient error, retrying in %s (%d of %d)", backoff, attempt+1, config.MaxRetries)
+
+		select {
+		case <-d.done():
+			return state, d.cancelled()
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const scrubbedSecret = "<redacted>"
+
+// secretScrubber redacts the credentials in the bbl state from what destroy
+// logs and returns, since terraform and the cpi can echo them back in
+// their errors.
+type secretScrubber struct {
+	replacer *strings.Replacer
+}
+
+func newSecretScrubber(state storage.State) secretScrubber {
+	var secrets []string
+	for _, secret := range []string{
+		state.AWS.SecretAccessKey,
+		state.AWS.SessionToken,
+		state.Azure.ClientSecret,
+		state.GCP.ServiceAccountKey,
+		state.OpenStack.Password,
+		state.OpenStack.PrivateKey,
+		state.BOSH.DirectorPassword,
+		state.BOSH.DirectorSSLPrivateKey,
+		state.LB.Key,
+	} {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	// Longer secrets go first so that a secret containing another is
+	// redacted whole.
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+
+	var pairs []string
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, scrubbedSecret)
+	}
+
+	return secretScrubber{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (s secretScrubber) scrub(message string) string {
+	return s.replacer.Replace(message)
+}
+
+func (s secretScrubber) scrubError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := s.scrub(err.Error())
+	if message == err.Error() {
+		return err
+	}
+
+	return errors.New(message)
+}
+
+// scrubArguments redacts the string and error arguments of a format call,
+// leaving the ones without secrets as they were.
+func (s secretScrubber) scrubArguments(arguments []interface{}) []interface{} {
+	scrubbed := make([]interface{}, len(arguments))
+	for i, argument := range arguments {
+		scrubbed[i] = argument
+
+		var text string
+		switch value := argument.(type) {
+		case string:
+			text = value
+		case error:
+			text = value.Error()
+		case fmt.Stringer:
+			text = value.String()
+		default:
+			continue
+		}
+
+		if redacted := s.scrub(text); redacted != text {
+			scrubbed[i] = redacted
+		}
+	}
+	return scrubbed
+}
+
+func (s secretScrubber) wrap(logger logger) logger {
+	return scrubbingLogger{logger: logger, scrubber: s}
+}
+
+type scrubbingLogger struct {
+	logger
+	scrubber secretScrubber
+}
+
+func (l scrubbingLogger) Step(message string, a ...interface{}) {
+	l.logger.Step(l.scrubber.scrub(message), l.scrubber.scrubArguments(a)...)
+}
+
+func (l scrubbingLogger) Printf(message string, a ...interface{}) {
+	l.logger.Printf(l.scrubber.scrub(message), l.scrubber.scrubArguments(a)...)
+}
+
+func (l scrubbingLogger) Println(message string) {
+	l.logger.Println(l.scrubber.scrub(message))
+}
+
+func (l scrubbingLogger) Prompt(message string) bool {
+	return l.logger.Prompt(l.scrubber.scrub(message))
+}
+
+func (l scrubbingLogger) PromptForName(message, name string) bool {
+	return l.logger.PromptForName(l.scrubber.scrub(message), name)
+}
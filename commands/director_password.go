@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// DirectorPassword prints the director password once the operator confirms,
+// like destroy does. The prompt goes to stderr so that only the password is
+// written to stdout; --no-confirm skips it.
+type DirectorPassword struct {
+	logger         logger
+	stderrLogger   logger
+	stateValidator stateValidator
+}
+
+func NewDirectorPassword(logger, stderrLogger logger, stateValidator stateValidator) DirectorPassword {
+	return DirectorPassword{
+		logger:         logger,
+		stderrLogger:   stderrLogger,
+		stateValidator: stateValidator,
+	}
+}
+
+func (d DirectorPassword) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := d.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if state.NoDirector {
+		return errors.New("Error BBL does not manage this director.")
+	}
+
+	return nil
+}
+
+func (d DirectorPassword) Execute(subcommandFlags []string, state storage.State) error {
+	if state.BOSH.DirectorPassword == "" {
+		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", DirectorPasswordPropertyName)
+	}
+
+	if err := d.stderrLogger.CanPrompt(); err != nil {
+		return err
+	}
+
+	proceed := d.stderrLogger.Prompt(fmt.Sprintf("Are you sure you want to print the director password for %q?", state.EnvID))
+	if !proceed {
+		d.stderrLogger.Step("exiting")
+		return nil
+	}
+
+	d.logger.Println(state.BOSH.DirectorPassword)
+	return nil
+}
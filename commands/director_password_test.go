@@ -0,0 +1,113 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DirectorPassword", func() {
+	var (
+		command commands.DirectorPassword
+
+		state storage.State
+
+		stateValidator *fakes.StateValidator
+		logger         *fakes.Logger
+		stderrLogger   *fakes.Logger
+	)
+
+	BeforeEach(func() {
+		state = storage.State{
+			EnvID: "some-env-id",
+			BOSH: storage.BOSH{
+				DirectorPassword: "some-director-password",
+			},
+		}
+
+		stateValidator = &fakes.StateValidator{}
+		logger = &fakes.Logger{}
+		stderrLogger = &fakes.Logger{}
+		stderrLogger.PromptCall.Returns.Proceed = true
+
+		command = commands.NewDirectorPassword(logger, stderrLogger, stateValidator)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
+		})
+
+		Context("when state validator fails", func() {
+			It("returns an error", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("state validator failed")
+
+				err := command.CheckFastFails([]string{}, state)
+				Expect(err).To(MatchError("state validator failed"))
+			})
+		})
+
+		Context("when bbl does not manage the director", func() {
+			It("returns an error", func() {
+				state.NoDirector = true
+
+				err := command.CheckFastFails([]string{}, state)
+				Expect(err).To(MatchError("Error BBL does not manage this director."))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints the director password once confirmed", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stderrLogger.PromptCall.CallCount).To(Equal(1))
+			Expect(stderrLogger.PromptCall.Receives.Message).To(Equal(`Are you sure you want to print the director password for "some-env-id"?`))
+			Expect(logger.PromptCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"some-director-password"}))
+		})
+
+		Context("when the prompt is declined", func() {
+			It("does not print the password", func() {
+				stderrLogger.PromptCall.Returns.Proceed = false
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stderrLogger.StepCall.Messages).To(Equal([]string{"exiting"}))
+				Expect(logger.PrintlnCall.Messages).To(BeEmpty())
+			})
+		})
+
+		Context("when there is no terminal to prompt on", func() {
+			It("returns an error", func() {
+				stderrLogger.CanPromptCall.Returns.Error = errors.New("no terminal")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("no terminal"))
+
+				Expect(stderrLogger.PromptCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(BeEmpty())
+			})
+		})
+
+		Context("when the state has no director password", func() {
+			It("returns an error without prompting", func() {
+				state.BOSH.DirectorPassword = ""
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Could not retrieve director password, please make sure you are targeting the proper state dir."))
+
+				Expect(stderrLogger.PromptCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+})
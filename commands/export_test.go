@@ -0,0 +1,15 @@
+package commands
+
+import "time"
+
+func SetFailureInjectionEnabled(enabled bool) {
+	failureInjectionEnabled = enabled
+}
+
+func SetTerraformRetryBackoff(d time.Duration) {
+	terraformRetryBackoff = d
+}
+
+func ResetTerraformRetryBackoff() {
+	terraformRetryBackoff = 10 * time.Second
+}
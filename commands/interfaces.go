@@ -1,7 +1,12 @@
 package commands
 
 import (
+	"context"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/metrics"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
@@ -24,10 +29,14 @@ type terraformManager interface {
 	ValidateVersion() error
 	GetOutputs() (terraform.Outputs, error)
 	Setup(storage.State) error
+	SetupWithTemplate(bblState storage.State, template string) error
+	ValidateTemplate(bblState storage.State, template string) error
 	Init(storage.State) error
 	Apply(storage.State) (storage.State, error)
 	Validate(storage.State) (storage.State, error)
-	Destroy(storage.State) (storage.State, error)
+	Destroy(context.Context, storage.State) (storage.State, error)
+	DestroyKeepingNetwork(context.Context, storage.State) (storage.State, error)
+	DestroyAddresses(bblState storage.State, keepNetwork bool) ([]string, error)
 	IsPaved() (bool, error)
 }
 
@@ -38,6 +47,8 @@ type boshManager interface {
 	CreateJumpbox(bblState storage.State, terraformOutputs terraform.Outputs) (storage.State, error)
 	DeleteDirector(bblState storage.State, terraformOutputs terraform.Outputs) error
 	DeleteJumpbox(bblState storage.State, terraformOutputs terraform.Outputs) error
+	SetDeleteEnvDebug(debug bool)
+	SetDeleteEnvDeadline(deadline time.Time)
 	GetDirectorDeploymentVars(bblState storage.State, terraformOutputs terraform.Outputs) string
 	GetJumpboxDeploymentVars(bblState storage.State, terraformOutputs terraform.Outputs) string
 	Path() string
@@ -59,6 +70,7 @@ type terraformManagerError interface {
 
 type vpcStatusChecker interface {
 	ValidateSafeToDelete(vpcID string, envID string) error
+	VPCPeeringChecker
 }
 
 type certificateDeleter interface {
@@ -90,10 +102,14 @@ type logger interface {
 	Printf(string, ...interface{})
 	Println(string)
 	Prompt(string) bool
+	PromptForName(message, name string) bool
+	CanPrompt() error
 }
 
 type stateStore interface {
 	Set(state storage.State) error
+	Get() (storage.State, error)
+	GetStateDir() string
 	GetOldBblDir() string
 	GetVarsDir() (string, error)
 	GetCloudConfigDir() (string, error)
@@ -108,6 +124,42 @@ type cloudConfigManager interface {
 	IsPresentCloudConfigVars() bool
 }
 
+type metricsPusher interface {
+	PushDestroy(gatewayURL string, destroy metrics.Destroy) error
+}
+
+type outputScrubber interface {
+	Scrub(func(string) string)
+	Flush() error
+}
+
+type boshClientProvider interface {
+	Client(jumpbox storage.Jumpbox, directorAddress, directorUsername, directorPassword, directorCACert string) (bosh.ConfigUpdater, error)
+}
+
+type destroyKeys interface {
+	Completed(key string) (bool, error)
+	Complete(key string) error
+}
+
+type recoveryBundle interface {
+	Write(path string, state storage.State, outputs map[string]interface{}, key []byte) error
+}
+
+type destroySchedule interface {
+	Scheduled(path string) (time.Time, bool, error)
+	Schedule(path string, at time.Time) error
+	Cancel(path string) error
+}
+
+type hookRunner interface {
+	Run(path string, env []string) (string, error)
+}
+
+type gitStatus interface {
+	CheckClean(dir, file string) error
+}
+
 type runtimeConfigManager interface {
 	Update(state storage.State) error
 }
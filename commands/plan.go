@@ -25,8 +25,9 @@ type Plan struct {
 }
 
 type PlanConfig struct {
-	Name string
-	LB   storage.LB
+	Name     string
+	EnvClass string
+	LB       storage.LB
 }
 
 func NewPlan(boshManager boshManager,
@@ -80,6 +81,7 @@ func (p Plan) ParseArgs(args []string, state storage.State) (PlanConfig, error)
 	)
 	planFlags := flags.New("up")
 	planFlags.String(&config.Name, "name", os.Getenv("BBL_ENV_NAME"))
+	planFlags.String(&config.EnvClass, "env-class", "")
 	planFlags.String(&lbArgs.LBType, "lb-type", "")
 	planFlags.String(&lbArgs.CertPath, "lb-cert", "")
 	planFlags.String(&lbArgs.KeyPath, "lb-key", "")
@@ -118,6 +120,9 @@ func (p Plan) InitializePlan(config PlanConfig, state storage.State) (storage.St
 	state.BBLVersion = p.bblVersion
 	state.LB = config.LB
 	state.NoDirector = false
+	if config.EnvClass != "" {
+		state.EnvClass = config.EnvClass
+	}
 
 	var err error
 	state, err = p.envIDManager.Sync(state, config.Name)
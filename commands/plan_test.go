@@ -132,6 +132,24 @@ var _ = Describe("Plan", func() {
 			})
 		})
 
+		Context("when --env-class is passed", func() {
+			It("records the classification in the state", func() {
+				err := command.Execute([]string{"--env-class", "production"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.EnvClass).To(Equal("production"))
+			})
+
+			It("keeps the classification already in the state when it is not passed", func() {
+				state.EnvClass = "production"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(envIDManager.SyncCall.Receives.State.EnvClass).To(Equal("production"))
+			})
+		})
+
 		Describe("failure cases", func() {
 			It("returns an error if state store set fails", func() {
 				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("peach")}}
@@ -18,6 +18,16 @@ Global Options:
   --debug      [-d]        Prints debugging output                                                       env:"BBL_DEBUG"
   --version    [-v]        Prints version
   --no-confirm [-n]        No confirm
+  --force-tty              Treat stdout as a terminal even when it is not
+  --no-tty                 Print timestamped steps and require --no-confirm for destroy, as without a terminal
+  --http-timeout           Timeout for each IAAS API call, e.g. 90s                                      env:"BBL_HTTP_TIMEOUT"
+  --http-max-retries       Number of times a failed IAAS API call is retried                             env:"BBL_HTTP_MAX_RETRIES"
+  --ca-cert-file           Also trust the CA certificates in this file for IAAS API calls                env:"BBL_CA_CERT_FILE"
+  --tf-workspace           Terraform workspace to use, each with its own terraform state                 env:"BBL_TF_WORKSPACE"
+  --record-interactions    Write the AWS and GCP API calls bbl makes to a file
+  --replay-interactions    Only run the checks of a command, answering API calls from a recorded file
+  --discover-iaas          Let destroy find the iaas of a state without one by looking for its network
+  --log-format             Set to json to write each step and message as a json object. Defaults to text
 %s
 `
 	CommandUsage = `
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type awsCredentialsSetter interface {
+	SetCredentials(storage.AWS)
+}
+
+// CredentialRefresher loads the iaas credentials again the way bbl loaded
+// them when it started: from the flags and environment variables, the gcp
+// service account key file and, with --aws-default-credentials, the default
+// aws credential chain. A long destroy uses it to pick up credentials that
+// were rotated, or that expired and were renewed, while it ran.
+type CredentialRefresher struct {
+	globalFlags    GlobalFlags
+	stateBootstrap StateBootstrap
+	merger         merger
+	awsClient      awsCredentialsSetter
+}
+
+// NewCredentialRefresher returns a refresher that also hands refreshed aws
+// credentials to awsClient, when there is one, so that the sdk clients bbl
+// built when it started sign their next requests with them.
+func NewCredentialRefresher(globalFlags GlobalFlags, stateBootstrap StateBootstrap, merger merger, awsClient awsCredentialsSetter) CredentialRefresher {
+	return CredentialRefresher{
+		globalFlags:    globalFlags,
+		stateBootstrap: stateBootstrap,
+		merger:         merger,
+		awsClient:      awsClient,
+	}
+}
+
+// Refresh returns the state with its iaas credentials loaded again. The rest
+// of the state is left as the destroy has it.
+func (r CredentialRefresher) Refresh(state storage.State) (storage.State, error) {
+	stored, err := r.stateBootstrap.GetState(r.globalFlags.StateDir)
+	if err != nil {
+		return state, fmt.Errorf("Read the state: %s", err)
+	}
+
+	// The credentials are not written to the state file, so merging the
+	// flags into it loads them from scratch.
+	stored.IAAS = state.IAAS
+	loaded, err := r.merger.MergeGlobalFlagsToState(r.globalFlags, stored)
+	if err != nil {
+		return state, err
+	}
+
+	state.AWS.AccessKeyID = loaded.AWS.AccessKeyID
+	state.AWS.SecretAccessKey = loaded.AWS.SecretAccessKey
+	state.AWS.SessionToken = loaded.AWS.SessionToken
+
+	state.Azure.ClientID = loaded.Azure.ClientID
+	state.Azure.ClientSecret = loaded.Azure.ClientSecret
+	state.Azure.SubscriptionID = loaded.Azure.SubscriptionID
+	state.Azure.TenantID = loaded.Azure.TenantID
+
+	state.GCP.ServiceAccountKey = loaded.GCP.ServiceAccountKey
+	state.GCP.ServiceAccountKeyPath = loaded.GCP.ServiceAccountKeyPath
+	state.GCP.ProjectID = loaded.GCP.ProjectID
+
+	state.VSphere.VCenterUser = loaded.VSphere.VCenterUser
+	state.VSphere.VCenterPassword = loaded.VSphere.VCenterPassword
+
+	state.OpenStack.Username = loaded.OpenStack.Username
+	state.OpenStack.Password = loaded.OpenStack.Password
+
+	if r.awsClient != nil {
+		r.awsClient.SetCredentials(state.AWS)
+	}
+
+	return state, nil
+}
+
+// RefreshDirector returns the state with the director credentials read again
+// from the state file, where a rotation since the destroy started would have
+// written them. Only the director credentials are replaced.
+func (r CredentialRefresher) RefreshDirector(state storage.State) (storage.State, error) {
+	stored, err := r.stateBootstrap.GetState(r.globalFlags.StateDir)
+	if err != nil {
+		return state, fmt.Errorf("Read the state: %s", err)
+	}
+
+	if stored.BOSH.DirectorPassword == "" {
+		return state, errors.New("The state has no director credentials")
+	}
+
+	state.BOSH.DirectorUsername = stored.BOSH.DirectorUsername
+	state.BOSH.DirectorPassword = stored.BOSH.DirectorPassword
+	state.BOSH.DirectorSSLCA = stored.BOSH.DirectorSSLCA
+
+	return state, nil
+}
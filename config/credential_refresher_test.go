@@ -0,0 +1,179 @@
+package config_test
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/cloudfoundry/bosh-bootloader/aws"
+	"github.com/cloudfoundry/bosh-bootloader/config"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CredentialRefresher", func() {
+	var (
+		stateBootstrap     *fakes.StateBootstrap
+		awsCredentialChain *fakes.AWSCredentialChain
+		globals            config.GlobalFlags
+		state              storage.State
+
+		refresher config.CredentialRefresher
+	)
+
+	BeforeEach(func() {
+		stateBootstrap = &fakes.StateBootstrap{}
+		stateBootstrap.GetStateCall.Returns.State = storage.State{
+			IAAS:  "aws",
+			EnvID: "some-env-id",
+			AWS:   storage.AWS{Region: "some-region"},
+		}
+
+		awsCredentialChain = &fakes.AWSCredentialChain{}
+		awsCredentialChain.GetCall.Returns.Value = credentials.Value{
+			AccessKeyID:     "renewed-access-key-id",
+			SecretAccessKey: "renewed-secret-key",
+			SessionToken:    "renewed-session-token",
+		}
+
+		globals = config.GlobalFlags{
+			StateDir:              "some-state-dir",
+			AWSDefaultCredentials: true,
+			AWSRegion:             "some-region",
+		}
+
+		state = storage.State{
+			IAAS:  "aws",
+			EnvID: "some-env-id",
+			AWS: storage.AWS{
+				AccessKeyID:     "expired-access-key-id",
+				SecretAccessKey: "expired-secret-key",
+				SessionToken:    "expired-session-token",
+				Region:          "some-region",
+			},
+			BOSH: storage.BOSH{DirectorName: "some-director"},
+		}
+
+		refresher = config.NewCredentialRefresher(globals, stateBootstrap, config.NewMerger(&fakes.FileIO{}, awsCredentialChain), nil)
+	})
+
+	Describe("Refresh", func() {
+		It("loads the credentials again", func() {
+			refreshed, err := refresher.Refresh(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateBootstrap.GetStateCall.Receives.Dir).To(Equal("some-state-dir"))
+			Expect(awsCredentialChain.ExpireCall.CallCount).To(Equal(1))
+			Expect(refreshed.AWS).To(Equal(storage.AWS{
+				AccessKeyID:     "renewed-access-key-id",
+				SecretAccessKey: "renewed-secret-key",
+				SessionToken:    "renewed-session-token",
+				Region:          "some-region",
+			}))
+		})
+
+		It("keeps the rest of the state", func() {
+			refreshed, err := refresher.Refresh(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(refreshed.BOSH).To(Equal(state.BOSH))
+		})
+
+		It("takes the credentials from the flags when they are given", func() {
+			globals.AWSAccessKeyID = "some-access-key-id"
+			globals.AWSSecretAccessKey = "some-secret-key"
+			refresher = config.NewCredentialRefresher(globals, stateBootstrap, config.NewMerger(&fakes.FileIO{}, awsCredentialChain), nil)
+
+			refreshed, err := refresher.Refresh(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsCredentialChain.GetCall.CallCount).To(Equal(0))
+			Expect(refreshed.AWS.AccessKeyID).To(Equal("some-access-key-id"))
+			Expect(refreshed.AWS.SecretAccessKey).To(Equal("some-secret-key"))
+		})
+
+		It("never copies the director credentials from the state file", func() {
+			stateBootstrap.GetStateCall.Returns.State.BOSH = storage.BOSH{
+				DirectorName:     "some-director",
+				DirectorUsername: "admin",
+				DirectorPassword: "some-password",
+			}
+
+			refreshed, err := refresher.Refresh(storage.State{IAAS: "aws", AWS: state.AWS})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(refreshed.BOSH).To(Equal(storage.BOSH{}))
+		})
+
+		It("hands the credentials to the aws client", func() {
+			awsClient := aws.NewClient(state.AWS, helpers.HTTPSettings{}, &fakes.Logger{})
+			refresher = config.NewCredentialRefresher(globals, stateBootstrap, config.NewMerger(&fakes.FileIO{}, awsCredentialChain), awsClient)
+
+			_, err := refresher.Refresh(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(awsClient.Credentials()).To(Equal(storage.AWS{
+				AccessKeyID:     "renewed-access-key-id",
+				SecretAccessKey: "renewed-secret-key",
+				SessionToken:    "renewed-session-token",
+				Region:          "some-region",
+			}))
+		})
+
+		Context("when the state cannot be read", func() {
+			It("returns an error", func() {
+				stateBootstrap.GetStateCall.Returns.Error = errors.New("permission denied")
+
+				_, err := refresher.Refresh(state)
+				Expect(err).To(MatchError("Read the state: permission denied"))
+			})
+		})
+
+		Context("when the credentials cannot be loaded", func() {
+			It("returns an error", func() {
+				awsCredentialChain.GetCall.Returns.Error = errors.New("NoCredentialProviders")
+
+				_, err := refresher.Refresh(state)
+				Expect(err).To(MatchError("Load AWS credentials from the default credential chain: NoCredentialProviders"))
+			})
+		})
+	})
+
+	Describe("RefreshDirector", func() {
+		It("reads the director credentials from the state file", func() {
+			stateBootstrap.GetStateCall.Returns.State.BOSH = storage.BOSH{
+				DirectorName:     "some-director",
+				DirectorUsername: "admin",
+				DirectorPassword: "rotated-password",
+				DirectorSSLCA:    "some-ca",
+			}
+
+			refreshed, err := refresher.RefreshDirector(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateBootstrap.GetStateCall.Receives.Dir).To(Equal("some-state-dir"))
+			Expect(refreshed.BOSH.DirectorUsername).To(Equal("admin"))
+			Expect(refreshed.BOSH.DirectorPassword).To(Equal("rotated-password"))
+			Expect(refreshed.BOSH.DirectorSSLCA).To(Equal("some-ca"))
+			Expect(refreshed.AWS).To(Equal(state.AWS))
+		})
+
+		Context("when the state file has no director credentials", func() {
+			It("returns an error", func() {
+				_, err := refresher.RefreshDirector(state)
+				Expect(err).To(MatchError("The state has no director credentials"))
+			})
+		})
+
+		Context("when the state cannot be read", func() {
+			It("returns an error", func() {
+				stateBootstrap.GetStateCall.Returns.Error = errors.New("permission denied")
+
+				_, err := refresher.RefreshDirector(state)
+				Expect(err).To(MatchError("Read the state: permission denied"))
+			})
+		})
+	})
+})
@@ -1,19 +1,37 @@
 package config
 
+import "time"
+
 type GlobalFlags struct {
 	Help        bool   `short:"h" long:"help"`
 	Debug       bool   `short:"d" long:"debug"        env:"BBL_DEBUG"`
 	Version     bool   `short:"v" long:"version"`
 	NoConfirm   bool   `short:"n" long:"no-confirm"`
+	ForceTTY    bool   `          long:"force-tty"`
+	NoTTY       bool   `          long:"no-tty"`
 	StateDir    string `short:"s" long:"state-dir"    env:"BBL_STATE_DIRECTORY"`
 	StateBucket string `          long:"state-bucket" env:"BBL_STATE_BUCKET"`
 	EnvID       string `          long:"name"`
 	IAAS        string `          long:"iaas"         env:"BBL_IAAS"`
+	TFWorkspace string `          long:"tf-workspace" env:"BBL_TF_WORKSPACE"`
+
+	HTTPTimeout    time.Duration `long:"http-timeout"     env:"BBL_HTTP_TIMEOUT"`
+	HTTPMaxRetries int           `long:"http-max-retries" env:"BBL_HTTP_MAX_RETRIES"`
+	CACertFile     string        `long:"ca-cert-file"     env:"BBL_CA_CERT_FILE"`
+
+	RecordInteractions string `long:"record-interactions"`
+	ReplayInteractions string `long:"replay-interactions"`
+
+	DiscoverIAAS bool `long:"discover-iaas"`
+
+	LogFormat string `long:"log-format" default:"text"`
 
 	AWSAccessKeyID     string `long:"aws-access-key-id"       env:"BBL_AWS_ACCESS_KEY_ID"`
 	AWSSecretAccessKey string `long:"aws-secret-access-key"   env:"BBL_AWS_SECRET_ACCESS_KEY"`
 	AWSRegion          string `long:"aws-region"              env:"BBL_AWS_REGION"`
 
+	AWSDefaultCredentials bool `long:"aws-default-credentials" env:"BBL_AWS_DEFAULT_CREDENTIALS"`
+
 	AzureClientID       string `long:"azure-client-id"        env:"BBL_AZURE_CLIENT_ID"`
 	AzureClientSecret   string `long:"azure-client-secret"    env:"BBL_AZURE_CLIENT_SECRET"`
 	AzureRegion         string `long:"azure-region"           env:"BBL_AZURE_REGION"`
@@ -1,12 +1,16 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	flags "github.com/jessevdk/go-flags"
 )
@@ -31,6 +35,10 @@ type downloader interface {
 	DownloadAndPrepareState(globalflags GlobalFlags) error
 }
 
+type networkClientProvider interface {
+	Client(state storage.State) (helpers.NetworkClient, error)
+}
+
 type fs interface {
 	fileio.Stater
 	fileio.TempFiler
@@ -38,24 +46,26 @@ type fs interface {
 	fileio.FileWriter
 }
 
-func NewConfig(bootstrap StateBootstrap, migrator migrator, merger merger, downloader downloader, logger logger, fs fs) Config {
+func NewConfig(bootstrap StateBootstrap, migrator migrator, merger merger, downloader downloader, logger logger, fs fs, networkClientProvider networkClientProvider) Config {
 	return Config{
-		stateBootstrap: bootstrap,
-		migrator:       migrator,
-		merger:         merger,
-		downloader:     downloader,
-		logger:         logger,
-		fs:             fs,
+		stateBootstrap:        bootstrap,
+		migrator:              migrator,
+		merger:                merger,
+		downloader:            downloader,
+		logger:                logger,
+		fs:                    fs,
+		networkClientProvider: networkClientProvider,
 	}
 }
 
 type Config struct {
-	stateBootstrap StateBootstrap
-	migrator       migrator
-	merger         merger
-	downloader     downloader
-	logger         logger
-	fs             fs
+	stateBootstrap        StateBootstrap
+	migrator              migrator
+	merger                merger
+	downloader            downloader
+	logger                logger
+	fs                    fs
+	networkClientProvider networkClientProvider
 }
 
 func ParseArgs(args []string) (GlobalFlags, []string, error) {
@@ -67,6 +77,18 @@ func ParseArgs(args []string) (GlobalFlags, []string, error) {
 		return GlobalFlags{}, remainingArgs, err
 	}
 
+	if globals.ForceTTY && globals.NoTTY {
+		return GlobalFlags{}, remainingArgs, errors.New("--force-tty and --no-tty cannot be used together")
+	}
+
+	if globals.LogFormat != "text" && globals.LogFormat != "json" {
+		return GlobalFlags{}, remainingArgs, fmt.Errorf("--log-format %q is not supported. Use one of: text, json.", globals.LogFormat)
+	}
+
+	if globals.RecordInteractions != "" && globals.ReplayInteractions != "" {
+		return GlobalFlags{}, remainingArgs, errors.New("--record-interactions and --replay-interactions cannot be used together")
+	}
+
 	if globals.StateBucket != "" && globals.StateDir == "" {
 		tempDir, err := ioutil.TempDir("", "bbl-state")
 		if err != nil {
@@ -131,6 +153,25 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 		}, nil
 	}
 
+	// destroy --all-matching destroys the envs under the state dir each
+	// with its own state, so the state dir itself is not loaded.
+	if (command == "destroy" || command == "down") && destroysAllMatching(remainingArgs[1:]) {
+		if globalFlags.StateBucket != "" {
+			return application.Configuration{}, errors.New("--all-matching cannot be used with --state-bucket")
+		}
+
+		return application.Configuration{
+			Global: application.GlobalConfiguration{
+				Debug:    globalFlags.Debug,
+				StateDir: globalFlags.StateDir,
+				DryRun:   globalFlags.ReplayInteractions != "",
+			},
+			Command:              command,
+			SubcommandFlags:      remainingArgs[1:],
+			CommandModifiesState: modifiesState(command),
+		}, nil
+	}
+
 	if !modifiesState(command) && globalFlags.StateBucket != "" {
 		err := c.downloader.DownloadAndPrepareState(globalFlags)
 		if err != nil {
@@ -148,6 +189,22 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 		return application.Configuration{}, err
 	}
 
+	storedIAAS, iaasOverridden := state.IAAS, false
+	if command == "destroy" || command == "down" {
+		state, err = c.overrideIAAS(globalFlags.IAAS, state)
+		if err != nil {
+			return application.Configuration{}, err
+		}
+		iaasOverridden = state.IAAS != storedIAAS
+
+		if globalFlags.DiscoverIAAS && globalFlags.IAAS == "" && state.IAAS == "" {
+			state.IAAS, err = c.discoverIAAS(globalFlags, state)
+			if err != nil {
+				return application.Configuration{}, err
+			}
+		}
+	}
+
 	state, err = c.merger.MergeGlobalFlagsToState(globalFlags, state)
 	if err != nil {
 		return application.Configuration{}, err
@@ -162,18 +219,116 @@ func (c Config) Bootstrap(globalFlags GlobalFlags, remainingArgs []string, argsL
 
 	return application.Configuration{
 		Global: application.GlobalConfiguration{
-			Debug:    globalFlags.Debug,
-			StateDir: globalFlags.StateDir,
-			Name:     globalFlags.EnvID,
+			Debug:       globalFlags.Debug,
+			StateDir:    globalFlags.StateDir,
+			Name:        globalFlags.EnvID,
+			TFWorkspace: globalFlags.TFWorkspace,
+			DryRun:      globalFlags.ReplayInteractions != "",
 		},
 		State:                state,
 		Command:              command,
 		SubcommandFlags:      remainingArgs[1:],
 		ShowCommandHelp:      false,
 		CommandModifiesState: modifiesState(command),
+		StoredIAAS:           storedIAAS,
+		IAASOverridden:       iaasOverridden,
 	}, nil
 }
 
+// overrideIAAS lets destroy pick the iaas from --iaas or BBL_IAAS when the
+// state was written with the wrong one, or with none. Only the loaded state is
+// changed; the state file keeps its iaas until the destroy succeeds.
+func (c Config) overrideIAAS(iaas string, state storage.State) (storage.State, error) {
+	if iaas == "" || iaas == state.IAAS {
+		return state, nil
+	}
+
+	if !isSupportedIAAS(iaas) {
+		return storage.State{}, fmt.Errorf("--iaas %q is not supported. Use one of: %s.", iaas, strings.Join(supportedIAASes, ", "))
+	}
+
+	switch {
+	case state.IAAS != "":
+		c.logger.Println(fmt.Sprintf("Overriding the iaas %s from the state with %s.", state.IAAS, iaas))
+	case state.EnvID != "":
+		c.logger.Println(fmt.Sprintf("The state has no iaas, using %s.", iaas))
+	}
+	state.IAAS = iaas
+
+	return state, nil
+}
+
+// discoverIAASes are the iaases --discover-iaas looks for the environment's
+// network on.
+var discoverIAASes = []string{"aws", "gcp"}
+
+// discoverIAAS finds which iaas a state without one was created on by looking
+// for the environment's network with each set of credentials provided.
+func (c Config) discoverIAAS(globalFlags GlobalFlags, state storage.State) (string, error) {
+	if state.EnvID == "" {
+		return "", errors.New("--discover-iaas needs an env id in the state to look for")
+	}
+
+	var probed, found []string
+	for _, iaas := range discoverIAASes {
+		candidate := state
+		candidate.IAAS = iaas
+
+		candidate, err := c.merger.MergeGlobalFlagsToState(globalFlags, candidate)
+		if err != nil {
+			return "", fmt.Errorf("Discover iaas: %s", err)
+		}
+
+		if ValidateIAAS(candidate) != nil {
+			continue
+		}
+		probed = append(probed, iaas)
+
+		client, err := c.networkClientProvider.Client(candidate)
+		if err != nil {
+			return "", fmt.Errorf("Discover iaas: %s", err)
+		}
+
+		exists, err := client.CheckExists(discoveryNetworkName(iaas, state.EnvID))
+		if err != nil {
+			return "", fmt.Errorf("Discover iaas on %s: %s", iaas, err)
+		}
+		if exists {
+			found = append(found, iaas)
+		}
+	}
+
+	switch {
+	case len(probed) == 0:
+		return "", fmt.Errorf("--discover-iaas needs credentials for one of: %s", strings.Join(discoverIAASes, ", "))
+	case len(found) == 0:
+		return "", fmt.Errorf("Could not find resources for %q on %s. Provide --iaas instead.", state.EnvID, strings.Join(probed, " or "))
+	case len(found) > 1:
+		return "", fmt.Errorf("Found resources for %q on %s. Provide --iaas to choose one.", state.EnvID, strings.Join(found, " and "))
+	}
+
+	c.logger.Println(fmt.Sprintf("Found resources for %q on %s, destroying it as a %s environment.", state.EnvID, found[0], found[0]))
+
+	return found[0], nil
+}
+
+func discoveryNetworkName(iaas, envID string) string {
+	if iaas == "aws" {
+		return envID + "-vpc"
+	}
+	return envID + "-network"
+}
+
+func destroysAllMatching(subcommandFlags []string) bool {
+	for _, flag := range subcommandFlags {
+		if flag == "--all-matching" || flag == "-all-matching" ||
+			strings.HasPrefix(flag, "--all-matching=") || strings.HasPrefix(flag, "-all-matching=") {
+			return true
+		}
+	}
+	return false
+}
+
 func modifiesState(command string) bool {
 	_, ok := map[string]struct{}{ // membership in this is untested
 		"up":                {},
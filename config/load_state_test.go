@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/config"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -31,6 +33,9 @@ var _ = Describe("LoadState", func() {
 		fakeFileIO         *fakes.FileIO
 		fakeDownloader     *fakes.Downloader
 		c                  config.Config
+
+		fakeNetworkClientProvider *fakes.NetworkClientProvider
+		fakeAWSCredentialChain    *fakes.AWSCredentialChain
 	)
 
 	BeforeEach(func() {
@@ -39,9 +44,11 @@ var _ = Describe("LoadState", func() {
 		fakeStateMigrator = &fakes.StateMigrator{}
 		fakeFileIO = &fakes.FileIO{}
 		fakeDownloader = &fakes.Downloader{}
+		fakeNetworkClientProvider = &fakes.NetworkClientProvider{}
+		fakeAWSCredentialChain = &fakes.AWSCredentialChain{}
 		os.Clearenv()
 
-		c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, config.NewMerger(fakeFileIO), fakeDownloader, fakeLogger, fakeFileIO)
+		c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, config.NewMerger(fakeFileIO, fakeAWSCredentialChain), fakeDownloader, fakeLogger, fakeFileIO, fakeNetworkClientProvider)
 	})
 
 	AfterEach(func() {
@@ -115,6 +122,7 @@ var _ = Describe("LoadState", func() {
 					"bbl", "print-env",
 					"--debug",
 					"--state-dir", "some-state-dir",
+					"--tf-workspace", "some-workspace",
 				}
 
 				appConfig, err := c.Bootstrap(bootstrapArgs(args))
@@ -123,6 +131,7 @@ var _ = Describe("LoadState", func() {
 				Expect(appConfig.Command).To(Equal("print-env"))
 				Expect(appConfig.Global.Debug).To(BeTrue())
 				Expect(appConfig.Global.StateDir).To(Equal(fullStateDirPath))
+				Expect(appConfig.Global.TFWorkspace).To(Equal("some-workspace"))
 			})
 
 			Context("when --help is passed in after a command", func() {
@@ -178,6 +187,62 @@ var _ = Describe("LoadState", func() {
 				})
 			})
 
+			It("parses the http settings", func() {
+				globals, _, err := config.ParseArgs([]string{
+					"bbl", "destroy",
+					"--http-timeout", "90s",
+					"--http-max-retries", "5",
+					"--ca-cert-file", "/path/to/ca.pem",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(globals.HTTPTimeout).To(Equal(90 * time.Second))
+				Expect(globals.HTTPMaxRetries).To(Equal(5))
+				Expect(globals.CACertFile).To(Equal("/path/to/ca.pem"))
+			})
+
+			It("parses the tty overrides", func() {
+				globals, _, err := config.ParseArgs([]string{"bbl", "destroy", "--no-tty"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(globals.NoTTY).To(BeTrue())
+				Expect(globals.ForceTTY).To(BeFalse())
+			})
+
+			Context("when both --record-interactions and --replay-interactions are passed", func() {
+				It("returns an error", func() {
+					_, _, err := config.ParseArgs([]string{"bbl", "destroy", "--record-interactions", "a.json", "--replay-interactions", "b.json"})
+
+					Expect(err).To(MatchError("--record-interactions and --replay-interactions cannot be used together"))
+				})
+			})
+
+			Context("when both --force-tty and --no-tty are passed", func() {
+				It("returns an error", func() {
+					_, _, err := config.ParseArgs([]string{"bbl", "destroy", "--force-tty", "--no-tty"})
+
+					Expect(err).To(MatchError("--force-tty and --no-tty cannot be used together"))
+				})
+			})
+
+			It("parses the log format", func() {
+				globals, _, err := config.ParseArgs([]string{"bbl", "destroy"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(globals.LogFormat).To(Equal("text"))
+
+				globals, _, err = config.ParseArgs([]string{"bbl", "destroy", "--log-format", "json"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(globals.LogFormat).To(Equal("json"))
+			})
+
+			Context("when the log format is not supported", func() {
+				It("returns an error", func() {
+					_, _, err := config.ParseArgs([]string{"bbl", "destroy", "--log-format", "xml"})
+
+					Expect(err).To(MatchError(`--log-format "xml" is not supported. Use one of: text, json.`))
+				})
+			})
+
 			Context("when an external bbl-state is specified", func() {
 				It("downloads the bbl state", func() {
 					_, err := c.Bootstrap(bootstrapArgs([]string{
@@ -540,6 +605,47 @@ var _ = Describe("LoadState", func() {
 					Entry("returns an error for non-matching IAAS", []string{"bbl", "up", "--iaas", "gcp"},
 						"The iaas type cannot be changed for an existing environment. The current iaas type is openstack."),
 				)
+
+				Context("when destroying with a different iaas", func() {
+					It("overrides the iaas from the state", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "destroy",
+							"--iaas", "aws",
+							"--aws-access-key-id", "some-access-key-id",
+							"--aws-secret-access-key", "some-secret-access-key",
+							"--aws-region", "some-region",
+						}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(appConfig.State.IAAS).To(Equal("aws"))
+						Expect(appConfig.State.EnvID).To(Equal("some-env-id"))
+						Expect(appConfig.StoredIAAS).To(Equal("openstack"))
+						Expect(appConfig.IAASOverridden).To(BeTrue())
+						Expect(fakeLogger.PrintlnCall.Messages).To(ContainElement("Overriding the iaas openstack from the state with aws."))
+					})
+
+					It("reads the override from BBL_IAAS", func() {
+						os.Setenv("BBL_IAAS", "aws")
+
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+							"bbl", "destroy",
+							"--aws-access-key-id", "some-access-key-id",
+							"--aws-secret-access-key", "some-secret-access-key",
+							"--aws-region", "some-region",
+						}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(appConfig.State.IAAS).To(Equal("aws"))
+					})
+
+					Context("when the iaas is not supported", func() {
+						It("returns an error", func() {
+							_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "destroy", "--iaas", "cloudstack"}))
+
+							Expect(err).To(MatchError(`--iaas "cloudstack" is not supported. Use one of: gcp, aws, azure, vsphere, openstack.`))
+						})
+					})
+				})
 			})
 		})
 
@@ -808,6 +914,87 @@ var _ = Describe("LoadState", func() {
 						Expect(appConfig.Command).To(Equal("up"))
 					})
 				})
+
+				Context("when --aws-default-credentials is provided", func() {
+					var args []string
+
+					BeforeEach(func() {
+						args = []string{
+							"bbl",
+							"--iaas", "aws",
+							"--aws-default-credentials",
+							"--aws-region", "some-region",
+							"up",
+						}
+
+						fakeAWSCredentialChain.GetCall.Returns.Value = credentials.Value{
+							AccessKeyID:     "some-chain-access-key-id",
+							SecretAccessKey: "some-chain-secret-key",
+							ProviderName:    "SharedCredentialsProvider",
+						}
+					})
+
+					It("loads the keys from the default credential chain", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs(args))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(appConfig.State.AWS.AccessKeyID).To(Equal("some-chain-access-key-id"))
+						Expect(appConfig.State.AWS.SecretAccessKey).To(Equal("some-chain-secret-key"))
+						Expect(appConfig.State.AWS.Region).To(Equal("some-region"))
+					})
+
+					It("prefers keys that are passed in", func() {
+						appConfig, err := c.Bootstrap(bootstrapArgs(append([]string{
+							"bbl",
+							"--aws-access-key-id", "some-access-key-id",
+							"--aws-secret-access-key", "some-secret-key",
+						}, args[1:]...)))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeAWSCredentialChain.GetCall.CallCount).To(Equal(0))
+						Expect(appConfig.State.AWS.AccessKeyID).To(Equal("some-access-key-id"))
+					})
+
+					It("reads the option from BBL_AWS_DEFAULT_CREDENTIALS", func() {
+						os.Setenv("BBL_AWS_DEFAULT_CREDENTIALS", "true")
+
+						appConfig, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "--iaas", "aws", "--aws-region", "some-region", "up"}))
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(appConfig.State.AWS.AccessKeyID).To(Equal("some-chain-access-key-id"))
+					})
+
+					Context("when the chain has no credentials", func() {
+						It("returns an error", func() {
+							fakeAWSCredentialChain.GetCall.Returns.Error = errors.New("NoCredentialProviders")
+
+							_, err := c.Bootstrap(bootstrapArgs(args))
+							Expect(err).To(MatchError("Load AWS credentials from the default credential chain: NoCredentialProviders"))
+						})
+					})
+
+					Context("when the chain returns temporary credentials", func() {
+						It("keeps their session token", func() {
+							fakeAWSCredentialChain.GetCall.Returns.Value.SessionToken = "some-session-token"
+							fakeAWSCredentialChain.GetCall.Returns.Value.ProviderName = "EC2RoleProvider"
+
+							appConfig, err := c.Bootstrap(bootstrapArgs(args))
+							Expect(err).NotTo(HaveOccurred())
+
+							Expect(appConfig.State.AWS.AccessKeyID).To(Equal("some-chain-access-key-id"))
+							Expect(appConfig.State.AWS.SessionToken).To(Equal("some-session-token"))
+						})
+					})
+				})
+
+				Context("without --aws-default-credentials", func() {
+					It("does not look at the default credential chain", func() {
+						_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "--iaas", "aws", "--aws-region", "some-region", "up"}))
+						Expect(err).To(HaveOccurred())
+
+						Expect(fakeAWSCredentialChain.GetCall.CallCount).To(Equal(0))
+					})
+				})
 			})
 
 			Context("when a previous state exists", func() {
@@ -1245,11 +1432,145 @@ var _ = Describe("LoadState", func() {
 			})
 		})
 
+		Context("when destroying with --all-matching", func() {
+			It("does not load the state in the state dir", func() {
+				appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+					"bbl", "--state-dir", "/some/root", "destroy", "--all-matching", "eph-",
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.Command).To(Equal("destroy"))
+				Expect(appConfig.Global.StateDir).To(Equal("/some/root"))
+				Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--all-matching", "eph-"}))
+				Expect(appConfig.State).To(Equal(storage.State{}))
+				Expect(fakeStateBootstrap.GetStateCall.CallCount).To(Equal(0))
+			})
+
+			Context("when the state is in a bucket", func() {
+				It("returns an error", func() {
+					_, err := c.Bootstrap(bootstrapArgs([]string{
+						"bbl", "--state-bucket", "some-bucket", "down", "--all-matching=eph-",
+					}))
+					Expect(err).To(MatchError("--all-matching cannot be used with --state-bucket"))
+				})
+			})
+		})
+
+		Context("when destroying a state without an iaas with --iaas", func() {
+			It("uses the iaas and remembers the state had none", func() {
+				fakeStateMigrator.MigrateCall.Returns.State = storage.State{EnvID: "some-env-id"}
+
+				appConfig, err := c.Bootstrap(bootstrapArgs([]string{
+					"bbl", "destroy",
+					"--iaas", "aws",
+					"--aws-access-key-id", "some-access-key-id",
+					"--aws-secret-access-key", "some-secret-access-key",
+					"--aws-region", "some-region",
+				}))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.State.IAAS).To(Equal("aws"))
+				Expect(appConfig.StoredIAAS).To(BeEmpty())
+				Expect(appConfig.IAASOverridden).To(BeTrue())
+				Expect(fakeLogger.PrintlnCall.Messages).To(ContainElement("The state has no iaas, using aws."))
+			})
+		})
+
+		Context("when destroying a state without an iaas with --discover-iaas", func() {
+			var (
+				awsNetworkClient *fakes.NetworkClient
+				gcpNetworkClient *fakes.NetworkClient
+				args             []string
+			)
+
+			BeforeEach(func() {
+				fakeStateMigrator.MigrateCall.Returns.State = storage.State{EnvID: "some-env-id"}
+
+				awsNetworkClient = &fakes.NetworkClient{}
+				gcpNetworkClient = &fakes.NetworkClient{}
+				fakeNetworkClientProvider.ClientCall.Returns.Clients = map[string]*fakes.NetworkClient{
+					"aws": awsNetworkClient,
+					"gcp": gcpNetworkClient,
+				}
+
+				tempFile, err := ioutil.TempFile("", "temp")
+				Expect(err).NotTo(HaveOccurred())
+				fakeFileIO.StatCall.Returns.Error = errors.New("no file found")
+				fakeFileIO.TempFileCall.Returns.File = tempFile
+
+				args = []string{
+					"bbl", "--discover-iaas", "destroy",
+					"--aws-access-key-id", "some-access-key-id",
+					"--aws-secret-access-key", "some-secret-access-key",
+					"--aws-region", "some-region",
+					"--gcp-service-account-key", `{"project_id": "some-project-id"}`,
+					"--gcp-region", "some-region",
+				}
+			})
+
+			It("uses the iaas the env's network is found on", func() {
+				gcpNetworkClient.CheckExistsCall.Returns.Exists = true
+
+				appConfig, err := c.Bootstrap(bootstrapArgs(args))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.State.IAAS).To(Equal("gcp"))
+				Expect(appConfig.State.GCP.ProjectID).To(Equal("some-project-id"))
+				Expect(awsNetworkClient.CheckExistsCall.Receives.Name).To(Equal("some-env-id-vpc"))
+				Expect(gcpNetworkClient.CheckExistsCall.Receives.Name).To(Equal("some-env-id-network"))
+				Expect(fakeNetworkClientProvider.ClientCall.Receives.States[0].AWS.AccessKeyID).To(Equal("some-access-key-id"))
+				Expect(fakeLogger.PrintlnCall.Messages).To(ContainElement(`Found resources for "some-env-id" on gcp, destroying it as a gcp environment.`))
+			})
+
+			It("only looks on the iaases it has credentials for", func() {
+				awsNetworkClient.CheckExistsCall.Returns.Exists = true
+
+				appConfig, err := c.Bootstrap(bootstrapArgs(args[:9]))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.State.IAAS).To(Equal("aws"))
+				Expect(gcpNetworkClient.CheckExistsCall.CallCount).To(Equal(0))
+			})
+
+			Context("when resources are found on more than one iaas", func() {
+				It("returns an error", func() {
+					awsNetworkClient.CheckExistsCall.Returns.Exists = true
+					gcpNetworkClient.CheckExistsCall.Returns.Exists = true
+
+					_, err := c.Bootstrap(bootstrapArgs(args))
+					Expect(err).To(MatchError(`Found resources for "some-env-id" on aws and gcp. Provide --iaas to choose one.`))
+				})
+			})
+
+			Context("when no resources are found", func() {
+				It("returns an error", func() {
+					_, err := c.Bootstrap(bootstrapArgs(args))
+					Expect(err).To(MatchError(`Could not find resources for "some-env-id" on aws or gcp. Provide --iaas instead.`))
+				})
+			})
+
+			Context("when no credentials are provided", func() {
+				It("returns an error", func() {
+					_, err := c.Bootstrap(bootstrapArgs([]string{"bbl", "--discover-iaas", "destroy"}))
+					Expect(err).To(MatchError("--discover-iaas needs credentials for one of: aws, gcp"))
+				})
+			})
+
+			Context("when looking for the network fails", func() {
+				It("returns an error", func() {
+					awsNetworkClient.CheckExistsCall.Returns.Error = errors.New("access denied")
+
+					_, err := c.Bootstrap(bootstrapArgs(args))
+					Expect(err).To(MatchError("Discover iaas on aws: access denied"))
+				})
+			})
+		})
+
 		Context("when the updated, migrated configuration is invalid", func() {
 			var fakeMerger *fakes.Merger
 			BeforeEach(func() {
 				fakeMerger = &fakes.Merger{}
-				c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, fakeMerger, fakeDownloader, fakeLogger, fakeFileIO)
+				c = config.NewConfig(fakeStateBootstrap, fakeStateMigrator, fakeMerger, fakeDownloader, fakeLogger, fakeFileIO, fakeNetworkClientProvider)
 
 				fakeMerger.MergeCall.Returns.State = storage.State{
 					IAAS:  "gcp",
@@ -6,15 +6,27 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
+// awsCredentialChain is the aws sdk's default chain of environment variables,
+// shared credentials file and instance profile.
+type awsCredentialChain interface {
+	Get() (credentials.Value, error)
+	Expire()
+}
+
 type Merger struct {
-	fs fs
+	fs                 fs
+	awsCredentialChain awsCredentialChain
 }
 
-func NewMerger(fs fs) Merger {
-	return Merger{fs: fs}
+func NewMerger(fs fs, awsCredentialChain awsCredentialChain) Merger {
+	return Merger{
+		fs:                 fs,
+		awsCredentialChain: awsCredentialChain,
+	}
 }
 
 func (m Merger) MergeGlobalFlagsToState(globalFlags GlobalFlags, state storage.State) (storage.State, error) {
@@ -113,6 +125,14 @@ func (m Merger) updateAWSState(globalFlags GlobalFlags, state storage.State) (st
 	copyFlagToState(globalFlags.AWSAccessKeyID, &state.AWS.AccessKeyID)
 	copyFlagToState(globalFlags.AWSSecretAccessKey, &state.AWS.SecretAccessKey)
 
+	if globalFlags.AWSDefaultCredentials && state.AWS.AccessKeyID == "" && state.AWS.SecretAccessKey == "" {
+		var err error
+		state.AWS, err = m.loadDefaultAWSCredentials(state.AWS)
+		if err != nil {
+			return storage.State{}, err
+		}
+	}
+
 	if globalFlags.AWSRegion != "" {
 		if state.AWS.Region != "" && globalFlags.AWSRegion != state.AWS.Region {
 			return storage.State{}, fmt.Errorf("The region cannot be changed for an existing environment. The current region is %s.", state.AWS.Region)
@@ -123,6 +143,26 @@ func (m Merger) updateAWSState(globalFlags GlobalFlags, state storage.State) (st
 	return state, nil
 }
 
+// loadDefaultAWSCredentials fills in the keys from the default credential
+// chain, along with the session token of temporary credentials.
+func (m Merger) loadDefaultAWSCredentials(creds storage.AWS) (storage.AWS, error) {
+	// The chain keeps what it found until it expires, which keys from the
+	// environment or shared credentials file never do. Expiring it makes
+	// each load read them again.
+	m.awsCredentialChain.Expire()
+
+	value, err := m.awsCredentialChain.Get()
+	if err != nil {
+		return storage.AWS{}, fmt.Errorf("Load AWS credentials from the default credential chain: %s", err)
+	}
+
+	creds.AccessKeyID = value.AccessKeyID
+	creds.SecretAccessKey = value.SecretAccessKey
+	creds.SessionToken = value.SessionToken
+
+	return creds, nil
+}
+
 func (m Merger) updateAzureState(globalFlags GlobalFlags, state storage.State) (storage.State, error) {
 	copyFlagToState(globalFlags.AzureClientID, &state.Azure.ClientID)
 	copyFlagToState(globalFlags.AzureClientSecret, &state.Azure.ClientSecret)
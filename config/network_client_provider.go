@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	awsclient "github.com/cloudfoundry/bosh-bootloader/aws"
+	gcpclient "github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stepLogger interface {
+	Step(string, ...interface{})
+}
+
+// NetworkClientProvider builds the clients --discover-iaas uses to look for
+// an environment's network on each iaas.
+type NetworkClientProvider struct {
+	httpSettings helpers.HTTPSettings
+	logger       stepLogger
+}
+
+func NewNetworkClientProvider(httpSettings helpers.HTTPSettings, logger stepLogger) NetworkClientProvider {
+	return NetworkClientProvider{
+		httpSettings: httpSettings,
+		logger:       logger,
+	}
+}
+
+func (p NetworkClientProvider) Client(state storage.State) (helpers.NetworkClient, error) {
+	switch state.IAAS {
+	case "aws":
+		return awsclient.NewClient(state.AWS, p.httpSettings, p.logger), nil
+	case "gcp":
+		return gcpclient.NewClient(state.GCP, p.httpSettings, "")
+	}
+
+	return nil, fmt.Errorf("Discovering the iaas is not supported for iaas %q", state.IAAS) // not tested
+}
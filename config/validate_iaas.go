@@ -7,6 +7,17 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
+var supportedIAASes = []string{"gcp", "aws", "azure", "vsphere", "openstack"}
+
+func isSupportedIAAS(iaas string) bool {
+	for _, supported := range supportedIAASes {
+		if iaas == supported {
+			return true
+		}
+	}
+	return false
+}
+
 func ValidateIAAS(state storage.State) error {
 	var err error
 	switch state.IAAS {
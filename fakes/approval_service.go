@@ -0,0 +1,49 @@
+package fakes
+
+type ApprovalService struct {
+	RequestCall struct {
+		CallCount int
+		Receives  struct {
+			ApprovalURL string
+			EnvID       string
+		}
+		Returns struct {
+			RequestURL string
+			Error      error
+		}
+	}
+
+	StatusCall struct {
+		CallCount int
+		Receives  struct {
+			RequestURL string
+		}
+		Returns struct {
+			Statuses []string
+			Error    error
+		}
+	}
+}
+
+func (a *ApprovalService) Request(approvalURL, envID string) (string, error) {
+	a.RequestCall.CallCount++
+	a.RequestCall.Receives.ApprovalURL = approvalURL
+	a.RequestCall.Receives.EnvID = envID
+
+	return a.RequestCall.Returns.RequestURL, a.RequestCall.Returns.Error
+}
+
+// Status returns the statuses in order, repeating the last one.
+func (a *ApprovalService) Status(requestURL string) (string, error) {
+	a.StatusCall.CallCount++
+	a.StatusCall.Receives.RequestURL = requestURL
+
+	statuses := a.StatusCall.Returns.Statuses
+	if len(statuses) == 0 {
+		return "", a.StatusCall.Returns.Error
+	}
+	if a.StatusCall.CallCount > len(statuses) {
+		return statuses[len(statuses)-1], a.StatusCall.Returns.Error
+	}
+	return statuses[a.StatusCall.CallCount-1], a.StatusCall.Returns.Error
+}
@@ -0,0 +1,26 @@
+package fakes
+
+import "github.com/aws/aws-sdk-go/aws/credentials"
+
+type AWSCredentialChain struct {
+	GetCall struct {
+		CallCount int
+		Returns   struct {
+			Value credentials.Value
+			Error error
+		}
+	}
+	ExpireCall struct {
+		CallCount int
+	}
+}
+
+func (a *AWSCredentialChain) Get() (credentials.Value, error) {
+	a.GetCall.CallCount++
+
+	return a.GetCall.Returns.Value, a.GetCall.Returns.Error
+}
+
+func (a *AWSCredentialChain) Expire() {
+	a.ExpireCall.CallCount++
+}
@@ -34,6 +34,69 @@ type AWSEC2Client struct {
 			Error  error
 		}
 	}
+
+	DescribeRegionsCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.DescribeRegionsInput
+		}
+		Returns struct {
+			Output *awsec2.DescribeRegionsOutput
+			Error  error
+		}
+	}
+
+	DescribeVpcPeeringConnectionsCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awsec2.DescribeVpcPeeringConnectionsInput
+		}
+		Returns struct {
+			Outputs []*awsec2.DescribeVpcPeeringConnectionsOutput
+			Error   error
+		}
+	}
+
+	DeleteVpcPeeringConnectionCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awsec2.DeleteVpcPeeringConnectionInput
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	DescribeImagesCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awsec2.DescribeImagesInput
+		}
+		Returns struct {
+			Output *awsec2.DescribeImagesOutput
+			Error  error
+		}
+	}
+
+	DeregisterImageCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awsec2.DeregisterImageInput
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	DeleteSnapshotCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awsec2.DeleteSnapshotInput
+		}
+		Returns struct {
+			Error error
+		}
+	}
 }
 
 func (c *AWSEC2Client) DescribeAvailabilityZones(input *awsec2.DescribeAvailabilityZonesInput) (*awsec2.DescribeAvailabilityZonesOutput, error) {
@@ -53,3 +116,53 @@ func (c *AWSEC2Client) DescribeVpcs(input *awsec2.DescribeVpcsInput) (*awsec2.De
 
 	return c.DescribeVpcsCall.Returns.Output, c.DescribeVpcsCall.Returns.Error
 }
+
+func (c *AWSEC2Client) DescribeRegions(input *awsec2.DescribeRegionsInput) (*awsec2.DescribeRegionsOutput, error) {
+	c.DescribeRegionsCall.CallCount++
+	c.DescribeRegionsCall.Receives.Input = input
+
+	return c.DescribeRegionsCall.Returns.Output, c.DescribeRegionsCall.Returns.Error
+}
+
+func (c *AWSEC2Client) DescribeVpcPeeringConnections(input *awsec2.DescribeVpcPeeringConnectionsInput) (*awsec2.DescribeVpcPeeringConnectionsOutput, error) {
+	c.DescribeVpcPeeringConnectionsCall.CallCount++
+	c.DescribeVpcPeeringConnectionsCall.Receives.Inputs = append(c.DescribeVpcPeeringConnectionsCall.Receives.Inputs, input)
+
+	output := &awsec2.DescribeVpcPeeringConnectionsOutput{}
+	if len(c.DescribeVpcPeeringConnectionsCall.Returns.Outputs) >= c.DescribeVpcPeeringConnectionsCall.CallCount {
+		output = c.DescribeVpcPeeringConnectionsCall.Returns.Outputs[c.DescribeVpcPeeringConnectionsCall.CallCount-1]
+	}
+
+	return output, c.DescribeVpcPeeringConnectionsCall.Returns.Error
+}
+
+func (c *AWSEC2Client) DeleteVpcPeeringConnection(input *awsec2.DeleteVpcPeeringConnectionInput) (*awsec2.DeleteVpcPeeringConnectionOutput, error) {
+	c.DeleteVpcPeeringConnectionCall.CallCount++
+	c.DeleteVpcPeeringConnectionCall.Receives.Inputs = append(c.DeleteVpcPeeringConnectionCall.Receives.Inputs, input)
+
+	return &awsec2.DeleteVpcPeeringConnectionOutput{}, c.DeleteVpcPeeringConnectionCall.Returns.Error
+}
+
+func (c *AWSEC2Client) DescribeImages(input *awsec2.DescribeImagesInput) (*awsec2.DescribeImagesOutput, error) {
+	c.DescribeImagesCall.CallCount++
+	c.DescribeImagesCall.Receives.Input = input
+
+	if c.DescribeImagesCall.Returns.Output == nil {
+		return &awsec2.DescribeImagesOutput{}, c.DescribeImagesCall.Returns.Error
+	}
+	return c.DescribeImagesCall.Returns.Output, c.DescribeImagesCall.Returns.Error
+}
+
+func (c *AWSEC2Client) DeregisterImage(input *awsec2.DeregisterImageInput) (*awsec2.DeregisterImageOutput, error) {
+	c.DeregisterImageCall.CallCount++
+	c.DeregisterImageCall.Receives.Inputs = append(c.DeregisterImageCall.Receives.Inputs, input)
+
+	return &awsec2.DeregisterImageOutput{}, c.DeregisterImageCall.Returns.Error
+}
+
+func (c *AWSEC2Client) DeleteSnapshot(input *awsec2.DeleteSnapshotInput) (*awsec2.DeleteSnapshotOutput, error) {
+	c.DeleteSnapshotCall.CallCount++
+	c.DeleteSnapshotCall.Receives.Inputs = append(c.DeleteSnapshotCall.Receives.Inputs, input)
+
+	return &awsec2.DeleteSnapshotOutput{}, c.DeleteSnapshotCall.Returns.Error
+}
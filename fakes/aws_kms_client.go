@@ -0,0 +1,133 @@
+package fakes
+
+import (
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+)
+
+type AWSKMSClient struct {
+	ListKeysCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awskms.ListKeysInput
+		}
+		Returns struct {
+			Outputs []*awskms.ListKeysOutput
+			Error   error
+		}
+	}
+
+	ListResourceTagsCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awskms.ListResourceTagsInput
+		}
+		Returns struct {
+			Outputs map[string]*awskms.ListResourceTagsOutput
+			Error   error
+		}
+	}
+
+	DescribeKeyCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awskms.DescribeKeyInput
+		}
+		Returns struct {
+			Outputs map[string]*awskms.DescribeKeyOutput
+			Error   error
+		}
+	}
+
+	ListAliasesCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awskms.ListAliasesInput
+		}
+		Returns struct {
+			Outputs []*awskms.ListAliasesOutput
+			Error   error
+		}
+	}
+
+	DeleteAliasCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awskms.DeleteAliasInput
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	ScheduleKeyDeletionCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awskms.ScheduleKeyDeletionInput
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (c *AWSKMSClient) ListKeys(input *awskms.ListKeysInput) (*awskms.ListKeysOutput, error) {
+	c.ListKeysCall.CallCount++
+	c.ListKeysCall.Receives.Inputs = append(c.ListKeysCall.Receives.Inputs, input)
+
+	output := &awskms.ListKeysOutput{}
+	if len(c.ListKeysCall.Returns.Outputs) >= c.ListKeysCall.CallCount {
+		output = c.ListKeysCall.Returns.Outputs[c.ListKeysCall.CallCount-1]
+	}
+
+	return output, c.ListKeysCall.Returns.Error
+}
+
+func (c *AWSKMSClient) ListResourceTags(input *awskms.ListResourceTagsInput) (*awskms.ListResourceTagsOutput, error) {
+	c.ListResourceTagsCall.CallCount++
+	c.ListResourceTagsCall.Receives.Inputs = append(c.ListResourceTagsCall.Receives.Inputs, input)
+
+	output, ok := c.ListResourceTagsCall.Returns.Outputs[*input.KeyId]
+	if !ok {
+		output = &awskms.ListResourceTagsOutput{}
+	}
+
+	return output, c.ListResourceTagsCall.Returns.Error
+}
+
+func (c *AWSKMSClient) DescribeKey(input *awskms.DescribeKeyInput) (*awskms.DescribeKeyOutput, error) {
+	c.DescribeKeyCall.CallCount++
+	c.DescribeKeyCall.Receives.Inputs = append(c.DescribeKeyCall.Receives.Inputs, input)
+
+	output, ok := c.DescribeKeyCall.Returns.Outputs[*input.KeyId]
+	if !ok {
+		output = &awskms.DescribeKeyOutput{}
+	}
+
+	return output, c.DescribeKeyCall.Returns.Error
+}
+
+func (c *AWSKMSClient) ListAliases(input *awskms.ListAliasesInput) (*awskms.ListAliasesOutput, error) {
+	c.ListAliasesCall.CallCount++
+	c.ListAliasesCall.Receives.Inputs = append(c.ListAliasesCall.Receives.Inputs, input)
+
+	output := &awskms.ListAliasesOutput{}
+	if len(c.ListAliasesCall.Returns.Outputs) >= c.ListAliasesCall.CallCount {
+		output = c.ListAliasesCall.Returns.Outputs[c.ListAliasesCall.CallCount-1]
+	}
+
+	return output, c.ListAliasesCall.Returns.Error
+}
+
+func (c *AWSKMSClient) DeleteAlias(input *awskms.DeleteAliasInput) (*awskms.DeleteAliasOutput, error) {
+	c.DeleteAliasCall.CallCount++
+	c.DeleteAliasCall.Receives.Inputs = append(c.DeleteAliasCall.Receives.Inputs, input)
+
+	return &awskms.DeleteAliasOutput{}, c.DeleteAliasCall.Returns.Error
+}
+
+func (c *AWSKMSClient) ScheduleKeyDeletion(input *awskms.ScheduleKeyDeletionInput) (*awskms.ScheduleKeyDeletionOutput, error) {
+	c.ScheduleKeyDeletionCall.CallCount++
+	c.ScheduleKeyDeletionCall.Receives.Inputs = append(c.ScheduleKeyDeletionCall.Receives.Inputs, input)
+
+	return &awskms.ScheduleKeyDeletionOutput{}, c.ScheduleKeyDeletionCall.Returns.Error
+}
@@ -14,6 +14,28 @@ type AWSRoute53Client struct {
 			Error  error
 		}
 	}
+
+	ListHostedZonesCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awsroute53.ListHostedZonesInput
+		}
+		Returns struct {
+			Outputs []*awsroute53.ListHostedZonesOutput
+			Error   error
+		}
+	}
+
+	ListResourceRecordSetsCall struct {
+		CallCount int
+		Receives  struct {
+			Inputs []*awsroute53.ListResourceRecordSetsInput
+		}
+		Returns struct {
+			Outputs []*awsroute53.ListResourceRecordSetsOutput
+			Error   error
+		}
+	}
 }
 
 func (c *AWSRoute53Client) ListHostedZonesByName(input *awsroute53.ListHostedZonesByNameInput) (*awsroute53.ListHostedZonesByNameOutput, error) {
@@ -21,3 +43,25 @@ func (c *AWSRoute53Client) ListHostedZonesByName(input *awsroute53.ListHostedZon
 
 	return c.ListHostedZonesByNameCall.Returns.Output, c.ListHostedZonesByNameCall.Returns.Error
 }
+
+func (c *AWSRoute53Client) ListHostedZones(input *awsroute53.ListHostedZonesInput) (*awsroute53.ListHostedZonesOutput, error) {
+	c.ListHostedZonesCall.CallCount++
+	c.ListHostedZonesCall.Receives.Inputs = append(c.ListHostedZonesCall.Receives.Inputs, input)
+
+	if c.ListHostedZonesCall.Returns.Error != nil {
+		return nil, c.ListHostedZonesCall.Returns.Error
+	}
+
+	return c.ListHostedZonesCall.Returns.Outputs[c.ListHostedZonesCall.CallCount-1], nil
+}
+
+func (c *AWSRoute53Client) ListResourceRecordSets(input *awsroute53.ListResourceRecordSetsInput) (*awsroute53.ListResourceRecordSetsOutput, error) {
+	c.ListResourceRecordSetsCall.CallCount++
+	c.ListResourceRecordSetsCall.Receives.Inputs = append(c.ListResourceRecordSetsCall.Receives.Inputs, input)
+
+	if c.ListResourceRecordSetsCall.Returns.Error != nil {
+		return nil, c.ListResourceRecordSetsCall.Returns.Error
+	}
+
+	return c.ListResourceRecordSetsCall.Returns.Outputs[c.ListResourceRecordSetsCall.CallCount-1], nil
+}
@@ -0,0 +1,25 @@
+package fakes
+
+import (
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+)
+
+type AWSS3Client struct {
+	PutObjectCall struct {
+		CallCount int
+		Receives  struct {
+			Input *awss3.PutObjectInput
+		}
+		Returns struct {
+			Output *awss3.PutObjectOutput
+			Error  error
+		}
+	}
+}
+
+func (c *AWSS3Client) PutObject(input *awss3.PutObjectInput) (*awss3.PutObjectOutput, error) {
+	c.PutObjectCall.CallCount++
+	c.PutObjectCall.Receives.Input = input
+
+	return c.PutObjectCall.Returns.Output, c.PutObjectCall.Returns.Error
+}
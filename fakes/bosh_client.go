@@ -41,6 +41,14 @@ type BOSHClient struct {
 			Error error
 		}
 	}
+
+	DeploymentsCall struct {
+		CallCount int
+		Returns   struct {
+			Deployments []string
+			Error       error
+		}
+	}
 }
 
 func (c *BOSHClient) UpdateRuntimeConfig(yaml []byte, name string) error {
@@ -65,3 +73,8 @@ func (c *BOSHClient) Info() (bosh.Info, error) {
 	c.InfoCall.CallCount++
 	return c.InfoCall.Returns.Info, c.InfoCall.Returns.Error
 }
+
+func (c *BOSHClient) Deployments() ([]string, error) {
+	c.DeploymentsCall.CallCount++
+	return c.DeploymentsCall.Returns.Deployments, c.DeploymentsCall.Returns.Error
+}
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
@@ -68,6 +70,7 @@ type BOSHManager struct {
 		Returns struct {
 			Error error
 		}
+		Stub func(storage.State) error
 	}
 	DeleteJumpboxCall struct {
 		CallCount int
@@ -79,6 +82,18 @@ type BOSHManager struct {
 			Error error
 		}
 	}
+	SetDeleteEnvDebugCall struct {
+		CallCount int
+		Receives  struct {
+			Debug bool
+		}
+	}
+	SetDeleteEnvDeadlineCall struct {
+		CallCount int
+		Receives  struct {
+			Deadline time.Time
+		}
+	}
 	GetDirectorDeploymentVarsCall struct {
 		CallCount int
 		Receives  struct {
@@ -131,6 +146,9 @@ func (b *BOSHManager) DeleteDirector(state storage.State, terraformOutputs terra
 	b.DeleteDirectorCall.CallCount++
 	b.DeleteDirectorCall.Receives.State = state
 	b.DeleteDirectorCall.Receives.TerraformOutputs = terraformOutputs
+	if b.DeleteDirectorCall.Stub != nil {
+		return b.DeleteDirectorCall.Stub(state)
+	}
 	return b.DeleteDirectorCall.Returns.Error
 }
 
@@ -141,6 +159,16 @@ func (b *BOSHManager) DeleteJumpbox(state storage.State, terraformOutputs terraf
 	return b.DeleteJumpboxCall.Returns.Error
 }
 
+func (b *BOSHManager) SetDeleteEnvDebug(debug bool) {
+	b.SetDeleteEnvDebugCall.CallCount++
+	b.SetDeleteEnvDebugCall.Receives.Debug = debug
+}
+
+func (b *BOSHManager) SetDeleteEnvDeadline(deadline time.Time) {
+	b.SetDeleteEnvDeadlineCall.CallCount++
+	b.SetDeleteEnvDeadlineCall.Receives.Deadline = deadline
+}
+
 func (b *BOSHManager) GetDirectorDeploymentVars(state storage.State, terraformOutputs terraform.Outputs) string {
 	b.GetDirectorDeploymentVarsCall.CallCount++
 	b.GetDirectorDeploymentVarsCall.Receives.State = state
@@ -0,0 +1,16 @@
+package fakes
+
+type ConnectivityChecker struct {
+	CheckConnectivityCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (c *ConnectivityChecker) CheckConnectivity() error {
+	c.CheckConnectivityCall.CallCount++
+
+	return c.CheckConnectivityCall.Returns.Error
+}
@@ -0,0 +1,50 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type CredentialRefresher struct {
+	RefreshCall struct {
+		CallCount int
+		Stub      func(storage.State) (storage.State, error)
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+	RefreshDirectorCall struct {
+		CallCount int
+		Stub      func(storage.State) (storage.State, error)
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			State storage.State
+			Error error
+		}
+	}
+}
+
+func (c *CredentialRefresher) Refresh(state storage.State) (storage.State, error) {
+	c.RefreshCall.CallCount++
+	c.RefreshCall.Receives.State = state
+
+	if c.RefreshCall.Stub != nil {
+		return c.RefreshCall.Stub(state)
+	}
+
+	return c.RefreshCall.Returns.State, c.RefreshCall.Returns.Error
+}
+
+func (c *CredentialRefresher) RefreshDirector(state storage.State) (storage.State, error) {
+	c.RefreshDirectorCall.CallCount++
+	c.RefreshDirectorCall.Receives.State = state
+
+	if c.RefreshDirectorCall.Stub != nil {
+		return c.RefreshDirectorCall.Stub(state)
+	}
+
+	return c.RefreshDirectorCall.Returns.State, c.RefreshDirectorCall.Returns.Error
+}
@@ -0,0 +1,38 @@
+package fakes
+
+type DestroyKeys struct {
+	CompletedCall struct {
+		CallCount int
+		Receives  struct {
+			Key string
+		}
+		Returns struct {
+			Completed bool
+			Error     error
+		}
+	}
+
+	CompleteCall struct {
+		CallCount int
+		Receives  struct {
+			Key string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (d *DestroyKeys) Completed(key string) (bool, error) {
+	d.CompletedCall.CallCount++
+	d.CompletedCall.Receives.Key = key
+
+	return d.CompletedCall.Returns.Completed, d.CompletedCall.Returns.Error
+}
+
+func (d *DestroyKeys) Complete(key string) error {
+	d.CompleteCall.CallCount++
+	d.CompleteCall.Receives.Key = key
+
+	return d.CompleteCall.Returns.Error
+}
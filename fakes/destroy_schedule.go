@@ -0,0 +1,60 @@
+package fakes
+
+import "time"
+
+type DestroySchedule struct {
+	ScheduledCall struct {
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			At        time.Time
+			Scheduled bool
+			Error     error
+		}
+	}
+
+	ScheduleCall struct {
+		CallCount int
+		Receives  struct {
+			Path string
+			At   time.Time
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	CancelCall struct {
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (d *DestroySchedule) Scheduled(path string) (time.Time, bool, error) {
+	d.ScheduledCall.CallCount++
+	d.ScheduledCall.Receives.Path = path
+
+	return d.ScheduledCall.Returns.At, d.ScheduledCall.Returns.Scheduled, d.ScheduledCall.Returns.Error
+}
+
+func (d *DestroySchedule) Schedule(path string, at time.Time) error {
+	d.ScheduleCall.CallCount++
+	d.ScheduleCall.Receives.Path = path
+	d.ScheduleCall.Receives.At = at
+
+	return d.ScheduleCall.Returns.Error
+}
+
+func (d *DestroySchedule) Cancel(path string) error {
+	d.CancelCall.CallCount++
+	d.CancelCall.Receives.Path = path
+
+	return d.CancelCall.Returns.Error
+}
@@ -0,0 +1,20 @@
+package fakes
+
+type DiskDeleter struct {
+	DeleteDisksCall struct {
+		CallCount int
+		Receives  struct {
+			EnvID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (d *DiskDeleter) DeleteDisks(envID string) error {
+	d.DeleteDisksCall.CallCount++
+	d.DeleteDisksCall.Receives.EnvID = envID
+
+	return d.DeleteDisksCall.Returns.Error
+}
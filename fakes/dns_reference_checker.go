@@ -0,0 +1,23 @@
+package fakes
+
+type DNSReferenceChecker struct {
+	LBReferencesCall struct {
+		CallCount int
+		Receives  struct {
+			Targets   []string
+			OwnDomain string
+		}
+		Returns struct {
+			References []string
+			Error      error
+		}
+	}
+}
+
+func (d *DNSReferenceChecker) LBReferences(targets []string, ownDomain string) ([]string, error) {
+	d.LBReferencesCall.CallCount++
+	d.LBReferencesCall.Receives.Targets = targets
+	d.LBReferencesCall.Receives.OwnDomain = ownDomain
+
+	return d.LBReferencesCall.Returns.References, d.LBReferencesCall.Returns.Error
+}
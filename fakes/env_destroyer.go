@@ -0,0 +1,25 @@
+package fakes
+
+type EnvDestroyer struct {
+	DestroyEnvCall struct {
+		CallCount int
+		Receives  struct {
+			StateDirs []string
+		}
+		Returns struct {
+			Errors map[string]error
+		}
+		Stub func(stateDir string) error
+	}
+}
+
+func (e *EnvDestroyer) DestroyEnv(stateDir string) error {
+	e.DestroyEnvCall.CallCount++
+	e.DestroyEnvCall.Receives.StateDirs = append(e.DestroyEnvCall.Receives.StateDirs, stateDir)
+
+	if e.DestroyEnvCall.Stub != nil {
+		return e.DestroyEnvCall.Stub(stateDir)
+	}
+
+	return e.DestroyEnvCall.Returns.Errors[stateDir]
+}
@@ -139,6 +139,28 @@ type FileIO struct {
 			Error error
 		}
 	}
+
+	OpenCall struct {
+		CallCount int
+		Receives  struct {
+			Name string
+		}
+		Returns struct {
+			File  afero.File
+			Error error
+		}
+	}
+
+	ChmodCall struct {
+		CallCount int
+		Receives  struct {
+			Name string
+			Mode os.FileMode
+		}
+		Returns struct {
+			Error error
+		}
+	}
 }
 
 type WriteFileReceive struct {
@@ -280,3 +302,16 @@ func (f *FileIO) MkdirAll(dir string, perm os.FileMode) error {
 	f.MkdirAllCall.Receives.Perm = perm
 	return f.MkdirAllCall.Returns.Error
 }
+
+func (f *FileIO) Open(name string) (afero.File, error) {
+	f.OpenCall.CallCount++
+	f.OpenCall.Receives.Name = name
+	return f.OpenCall.Returns.File, f.OpenCall.Returns.Error
+}
+
+func (f *FileIO) Chmod(name string, mode os.FileMode) error {
+	f.ChmodCall.CallCount++
+	f.ChmodCall.Receives.Name = name
+	f.ChmodCall.Receives.Mode = mode
+	return f.ChmodCall.Returns.Error
+}
@@ -58,6 +58,110 @@ type GCPComputeClient struct {
 			Error       error
 		}
 	}
+	GetProjectCall struct {
+		CallCount int
+		Receives  struct {
+			ProjectID string
+		}
+		Returns struct {
+			Project *compute.Project
+			Error   error
+		}
+	}
+	ListDisksCall struct {
+		CallCount int
+		Receives  struct {
+			ProjectID string
+			Zone      string
+		}
+		Returns struct {
+			DiskList *compute.DiskList
+			Error    error
+		}
+	}
+	ListRegionDisksCall struct {
+		CallCount int
+		Receives  struct {
+			ProjectID string
+			Region    string
+		}
+		Returns struct {
+			DiskList *compute.DiskList
+			Error    error
+		}
+	}
+	DeleteDiskCall struct {
+		CallCount int
+		Receives  []DeleteDiskReceive
+		Returns   struct {
+			Error error
+		}
+	}
+	DeleteRegionDiskCall struct {
+		CallCount int
+		Receives  []DeleteRegionDiskReceive
+		Returns   struct {
+			Error error
+		}
+	}
+	ListImagesCall struct {
+		CallCount int
+		Receives  struct {
+			ProjectID string
+		}
+		Returns struct {
+			ImageList *compute.ImageList
+			Error     error
+		}
+	}
+	DeleteImageCall struct {
+		CallCount int
+		Receives  []DeleteImageReceive
+		Returns   struct {
+			Error error
+		}
+	}
+	ListRoutersCall struct {
+		CallCount int
+		Receives  struct {
+			ProjectID string
+			Region    string
+		}
+		Returns struct {
+			RouterList *compute.RouterList
+			Error      error
+		}
+	}
+	DeleteRouterCall struct {
+		CallCount int
+		Receives  []DeleteRouterReceive
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+type DeleteDiskReceive struct {
+	ProjectID string
+	Zone      string
+	Disk      string
+}
+
+type DeleteRegionDiskReceive struct {
+	ProjectID string
+	Region    string
+	Disk      string
+}
+
+type DeleteImageReceive struct {
+	ProjectID string
+	Image     string
+}
+
+type DeleteRouterReceive struct {
+	ProjectID string
+	Region    string
+	Router    string
 }
 
 func (g *GCPComputeClient) ListInstances(projectID, zone string) (*compute.InstanceList, error) {
@@ -94,3 +198,87 @@ func (g *GCPComputeClient) GetNetworks(name, projectID string) (*compute.Network
 	g.GetNetworksCall.Receives.ProjectID = projectID
 	return g.GetNetworksCall.Returns.NetworkList, g.GetNetworksCall.Returns.Error
 }
+
+func (g *GCPComputeClient) GetProject(projectID string) (*compute.Project, error) {
+	g.GetProjectCall.CallCount++
+	g.GetProjectCall.Receives.ProjectID = projectID
+	return g.GetProjectCall.Returns.Project, g.GetProjectCall.Returns.Error
+}
+
+func (g *GCPComputeClient) ListDisks(projectID, zone string) (*compute.DiskList, error) {
+	g.ListDisksCall.CallCount++
+	g.ListDisksCall.Receives.ProjectID = projectID
+	g.ListDisksCall.Receives.Zone = zone
+	if g.ListDisksCall.Returns.DiskList == nil {
+		return &compute.DiskList{}, g.ListDisksCall.Returns.Error
+	}
+	return g.ListDisksCall.Returns.DiskList, g.ListDisksCall.Returns.Error
+}
+
+func (g *GCPComputeClient) ListRegionDisks(projectID, region string) (*compute.DiskList, error) {
+	g.ListRegionDisksCall.CallCount++
+	g.ListRegionDisksCall.Receives.ProjectID = projectID
+	g.ListRegionDisksCall.Receives.Region = region
+	if g.ListRegionDisksCall.Returns.DiskList == nil {
+		return &compute.DiskList{}, g.ListRegionDisksCall.Returns.Error
+	}
+	return g.ListRegionDisksCall.Returns.DiskList, g.ListRegionDisksCall.Returns.Error
+}
+
+func (g *GCPComputeClient) DeleteDisk(projectID, zone, disk string) error {
+	g.DeleteDiskCall.CallCount++
+	g.DeleteDiskCall.Receives = append(g.DeleteDiskCall.Receives, DeleteDiskReceive{
+		ProjectID: projectID,
+		Zone:      zone,
+		Disk:      disk,
+	})
+	return g.DeleteDiskCall.Returns.Error
+}
+
+func (g *GCPComputeClient) DeleteRegionDisk(projectID, region, disk string) error {
+	g.DeleteRegionDiskCall.CallCount++
+	g.DeleteRegionDiskCall.Receives = append(g.DeleteRegionDiskCall.Receives, DeleteRegionDiskReceive{
+		ProjectID: projectID,
+		Region:    region,
+		Disk:      disk,
+	})
+	return g.DeleteRegionDiskCall.Returns.Error
+}
+
+func (g *GCPComputeClient) ListImages(projectID string) (*compute.ImageList, error) {
+	g.ListImagesCall.CallCount++
+	g.ListImagesCall.Receives.ProjectID = projectID
+	if g.ListImagesCall.Returns.ImageList == nil {
+		return &compute.ImageList{}, g.ListImagesCall.Returns.Error
+	}
+	return g.ListImagesCall.Returns.ImageList, g.ListImagesCall.Returns.Error
+}
+
+func (g *GCPComputeClient) DeleteImage(projectID, image string) error {
+	g.DeleteImageCall.CallCount++
+	g.DeleteImageCall.Receives = append(g.DeleteImageCall.Receives, DeleteImageReceive{
+		ProjectID: projectID,
+		Image:     image,
+	})
+	return g.DeleteImageCall.Returns.Error
+}
+
+func (g *GCPComputeClient) ListRouters(projectID, region string) (*compute.RouterList, error) {
+	g.ListRoutersCall.CallCount++
+	g.ListRoutersCall.Receives.ProjectID = projectID
+	g.ListRoutersCall.Receives.Region = region
+	if g.ListRoutersCall.Returns.RouterList == nil {
+		return &compute.RouterList{}, g.ListRoutersCall.Returns.Error
+	}
+	return g.ListRoutersCall.Returns.RouterList, g.ListRoutersCall.Returns.Error
+}
+
+func (g *GCPComputeClient) DeleteRouter(projectID, region, router string) error {
+	g.DeleteRouterCall.CallCount++
+	g.DeleteRouterCall.Receives = append(g.DeleteRouterCall.Receives, DeleteRouterReceive{
+		ProjectID: projectID,
+		Region:    region,
+		Router:    router,
+	})
+	return g.DeleteRouterCall.Returns.Error
+}
@@ -0,0 +1,22 @@
+package fakes
+
+type GitStatus struct {
+	CheckCleanCall struct {
+		CallCount int
+		Receives  struct {
+			Dir  string
+			File string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (g *GitStatus) CheckClean(dir, file string) error {
+	g.CheckCleanCall.CallCount++
+	g.CheckCleanCall.Receives.Dir = dir
+	g.CheckCleanCall.Receives.File = file
+
+	return g.CheckCleanCall.Returns.Error
+}
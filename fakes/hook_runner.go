@@ -0,0 +1,23 @@
+package fakes
+
+type HookRunner struct {
+	RunCall struct {
+		CallCount int
+		Receives  struct {
+			Path string
+			Env  []string
+		}
+		Returns struct {
+			Output string
+			Error  error
+		}
+	}
+}
+
+func (h *HookRunner) Run(path string, env []string) (string, error) {
+	h.RunCall.CallCount++
+	h.RunCall.Receives.Path = path
+	h.RunCall.Receives.Env = env
+
+	return h.RunCall.Returns.Output, h.RunCall.Returns.Error
+}
@@ -0,0 +1,21 @@
+package fakes
+
+type ImageCleaner struct {
+	DeleteImagesCall struct {
+		CallCount int
+		Receives  struct {
+			EnvID string
+		}
+		Returns struct {
+			Deleted []string
+			Error   error
+		}
+	}
+}
+
+func (i *ImageCleaner) DeleteImages(envID string) ([]string, error) {
+	i.DeleteImagesCall.CallCount++
+	i.DeleteImagesCall.Receives.EnvID = envID
+
+	return i.DeleteImagesCall.Returns.Deleted, i.DeleteImagesCall.Returns.Error
+}
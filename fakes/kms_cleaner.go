@@ -0,0 +1,23 @@
+package fakes
+
+type KMSCleaner struct {
+	DeleteKMSKeysCall struct {
+		CallCount int
+		Receives  struct {
+			EnvID             string
+			PendingWindowDays int
+		}
+		Returns struct {
+			Deleted []string
+			Error   error
+		}
+	}
+}
+
+func (k *KMSCleaner) DeleteKMSKeys(envID string, pendingWindowDays int) ([]string, error) {
+	k.DeleteKMSKeysCall.CallCount++
+	k.DeleteKMSKeysCall.Receives.EnvID = envID
+	k.DeleteKMSKeysCall.Receives.PendingWindowDays = pendingWindowDays
+
+	return k.DeleteKMSKeysCall.Returns.Deleted, k.DeleteKMSKeysCall.Returns.Error
+}
@@ -0,0 +1,22 @@
+package fakes
+
+type LocationChecker struct {
+	CheckLocationCall struct {
+		CallCount int
+		Receives  struct {
+			Region string
+			Zone   string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (l *LocationChecker) CheckLocation(region, zone string) error {
+	l.CheckLocationCall.CallCount++
+	l.CheckLocationCall.Receives.Region = region
+	l.CheckLocationCall.Receives.Zone = zone
+
+	return l.CheckLocationCall.Returns.Error
+}
@@ -48,6 +48,24 @@ type Logger struct {
 			Proceed bool
 		}
 	}
+
+	CanPromptCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	PromptForNameCall struct {
+		CallCount int
+		Receives  struct {
+			Message string
+			Name    string
+		}
+		Returns struct {
+			Proceed bool
+		}
+	}
 }
 
 func (l *Logger) Step(message string, a ...interface{}) {
@@ -91,6 +109,20 @@ func (l *Logger) Prompt(message string) bool {
 	return l.PromptCall.Returns.Proceed
 }
 
+func (l *Logger) PromptForName(message, name string) bool {
+	l.PromptForNameCall.CallCount++
+	l.PromptForNameCall.Receives.Message = message
+	l.PromptForNameCall.Receives.Name = name
+
+	return l.PromptForNameCall.Returns.Proceed
+}
+
+func (l *Logger) CanPrompt() error {
+	l.CanPromptCall.CallCount++
+
+	return l.CanPromptCall.Returns.Error
+}
+
 func (l *Logger) PrintlnMessages() []string {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
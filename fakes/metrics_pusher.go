@@ -0,0 +1,24 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/metrics"
+
+type MetricsPusher struct {
+	PushDestroyCall struct {
+		CallCount int
+		Receives  struct {
+			GatewayURL string
+			Destroy    metrics.Destroy
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (m *MetricsPusher) PushDestroy(gatewayURL string, destroy metrics.Destroy) error {
+	m.PushDestroyCall.CallCount++
+	m.PushDestroyCall.Receives.GatewayURL = gatewayURL
+	m.PushDestroyCall.Receives.Destroy = destroy
+
+	return m.PushDestroyCall.Returns.Error
+}
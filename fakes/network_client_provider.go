@@ -0,0 +1,30 @@
+package fakes
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type NetworkClientProvider struct {
+	ClientCall struct {
+		CallCount int
+		Receives  struct {
+			States []storage.State
+		}
+		Returns struct {
+			Clients map[string]*NetworkClient
+			Errors  map[string]error
+		}
+	}
+}
+
+func (n *NetworkClientProvider) Client(state storage.State) (helpers.NetworkClient, error) {
+	n.ClientCall.CallCount++
+	n.ClientCall.Receives.States = append(n.ClientCall.Receives.States, state)
+
+	if err := n.ClientCall.Returns.Errors[state.IAAS]; err != nil {
+		return nil, err
+	}
+
+	return n.ClientCall.Returns.Clients[state.IAAS], nil
+}
@@ -0,0 +1,26 @@
+package fakes
+
+type OutputScrubber struct {
+	ScrubCall struct {
+		CallCount int
+		Receives  struct {
+			Scrub func(string) string
+		}
+	}
+	FlushCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (o *OutputScrubber) Scrub(scrub func(string) string) {
+	o.ScrubCall.CallCount++
+	o.ScrubCall.Receives.Scrub = scrub
+}
+
+func (o *OutputScrubber) Flush() error {
+	o.FlushCall.CallCount++
+	return o.FlushCall.Returns.Error
+}
@@ -0,0 +1,28 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type RecoveryBundle struct {
+	WriteCall struct {
+		CallCount int
+		Receives  struct {
+			Path    string
+			State   storage.State
+			Outputs map[string]interface{}
+			Key     []byte
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (r *RecoveryBundle) Write(path string, state storage.State, outputs map[string]interface{}, key []byte) error {
+	r.WriteCall.CallCount++
+	r.WriteCall.Receives.Path = path
+	r.WriteCall.Receives.State = state
+	r.WriteCall.Receives.Outputs = outputs
+	r.WriteCall.Receives.Key = key
+
+	return r.WriteCall.Returns.Error
+}
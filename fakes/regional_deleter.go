@@ -0,0 +1,36 @@
+package fakes
+
+type RegionalDeleter struct {
+	RegionsCall struct {
+		CallCount int
+		Returns   struct {
+			Regions []string
+			Error   error
+		}
+	}
+
+	DeleteInRegionCall struct {
+		CallCount int
+		Receives  struct {
+			Regions []string
+			Filter  string
+		}
+		Returns struct {
+			Errors map[string]error
+		}
+	}
+}
+
+func (r *RegionalDeleter) Regions() ([]string, error) {
+	r.RegionsCall.CallCount++
+
+	return r.RegionsCall.Returns.Regions, r.RegionsCall.Returns.Error
+}
+
+func (r *RegionalDeleter) DeleteInRegion(region, filter string) error {
+	r.DeleteInRegionCall.CallCount++
+	r.DeleteInRegionCall.Receives.Regions = append(r.DeleteInRegionCall.Receives.Regions, region)
+	r.DeleteInRegionCall.Receives.Filter = filter
+
+	return r.DeleteInRegionCall.Returns.Errors[region]
+}
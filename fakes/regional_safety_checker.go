@@ -0,0 +1,48 @@
+package fakes
+
+import "sync"
+
+type RegionalSafetyChecker struct {
+	mutex sync.Mutex
+
+	RegionsCall struct {
+		CallCount int
+		Returns   struct {
+			Regions []string
+			Error   error
+		}
+	}
+
+	ValidateSafeToDeleteInRegionCall struct {
+		CallCount int
+		Receives  struct {
+			Regions []string
+			EnvID   string
+		}
+		Returns struct {
+			Errors map[string]error
+		}
+		Stub func(region string) error
+	}
+}
+
+func (r *RegionalSafetyChecker) Regions() ([]string, error) {
+	r.RegionsCall.CallCount++
+
+	return r.RegionsCall.Returns.Regions, r.RegionsCall.Returns.Error
+}
+
+func (r *RegionalSafetyChecker) ValidateSafeToDeleteInRegion(region, envID string) error {
+	r.mutex.Lock()
+	r.ValidateSafeToDeleteInRegionCall.CallCount++
+	r.ValidateSafeToDeleteInRegionCall.Receives.Regions = append(r.ValidateSafeToDeleteInRegionCall.Receives.Regions, region)
+	r.ValidateSafeToDeleteInRegionCall.Receives.EnvID = envID
+	err := r.ValidateSafeToDeleteInRegionCall.Returns.Errors[region]
+	r.mutex.Unlock()
+
+	if r.ValidateSafeToDeleteInRegionCall.Stub != nil {
+		return r.ValidateSafeToDeleteInRegionCall.Stub(region)
+	}
+
+	return err
+}
@@ -0,0 +1,21 @@
+package fakes
+
+type ResidualLister struct {
+	ListResidualCall struct {
+		CallCount int
+		Receives  struct {
+			EnvID string
+		}
+		Returns struct {
+			Residual []string
+			Error    error
+		}
+	}
+}
+
+func (r *ResidualLister) ListResidual(envID string) ([]string, error) {
+	r.ListResidualCall.CallCount++
+	r.ListResidualCall.Receives.EnvID = envID
+
+	return r.ListResidualCall.Returns.Residual, r.ListResidualCall.Returns.Error
+}
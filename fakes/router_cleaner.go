@@ -0,0 +1,21 @@
+package fakes
+
+type RouterCleaner struct {
+	DeleteRoutersCall struct {
+		CallCount int
+		Receives  struct {
+			EnvID string
+		}
+		Returns struct {
+			Deleted []string
+			Error   error
+		}
+	}
+}
+
+func (r *RouterCleaner) DeleteRouters(envID string) ([]string, error) {
+	r.DeleteRoutersCall.CallCount++
+	r.DeleteRoutersCall.Receives.EnvID = envID
+
+	return r.DeleteRoutersCall.Returns.Deleted, r.DeleteRoutersCall.Returns.Error
+}
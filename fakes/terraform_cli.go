@@ -1,6 +1,7 @@
 package fakes
 
 import (
+	"context"
 	"io"
 )
 
@@ -13,20 +14,28 @@ type TerraformCLI struct {
 		}
 		Initialized bool
 		Receives    struct {
+			Context          context.Context
 			Stdout           io.Writer
 			WorkingDirectory string
 			Args             []string
 			Env              []string
 		}
+		AllArgs [][]string
 	}
 }
 
 func (t *TerraformCLI) RunWithEnv(stdout io.Writer, workingDirectory string, args []string, env []string) error {
+	return t.RunWithContext(context.Background(), stdout, workingDirectory, args, env)
+}
+
+func (t *TerraformCLI) RunWithContext(ctx context.Context, stdout io.Writer, workingDirectory string, args []string, env []string) error {
 	t.RunCall.CallCount++
+	t.RunCall.Receives.Context = ctx
 	t.RunCall.Receives.Stdout = stdout
 	t.RunCall.Receives.WorkingDirectory = workingDirectory
 	t.RunCall.Receives.Args = args
 	t.RunCall.Receives.Env = env
+	t.RunCall.AllArgs = append(t.RunCall.AllArgs, args)
 
 	switch args[0] {
 	case "version":
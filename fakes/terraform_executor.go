@@ -1,5 +1,7 @@
 package fakes
 
+import "context"
+
 type Import struct {
 	Addr string
 	ID   string
@@ -41,12 +43,31 @@ type TerraformExecutor struct {
 	DestroyCall struct {
 		CallCount int
 		Receives  struct {
+			Context     context.Context
+			Credentials map[string]string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	DestroyTargetsCall struct {
+		CallCount int
+		Receives  struct {
+			Context     context.Context
 			Credentials map[string]string
+			Targets     []string
 		}
 		Returns struct {
 			Error error
 		}
 	}
+	StateListCall struct {
+		CallCount int
+		Returns   struct {
+			Addresses []string
+			Error     error
+		}
+	}
 	ValidateCall struct {
 		CallCount int
 		Receives  struct {
@@ -114,12 +135,26 @@ func (t *TerraformExecutor) Apply(credentials map[string]string) error {
 	return t.ApplyCall.Returns.Error
 }
 
-func (t *TerraformExecutor) Destroy(credentials map[string]string) error {
+func (t *TerraformExecutor) Destroy(ctx context.Context, credentials map[string]string) error {
 	t.DestroyCall.CallCount++
+	t.DestroyCall.Receives.Context = ctx
 	t.DestroyCall.Receives.Credentials = credentials
 	return t.DestroyCall.Returns.Error
 }
 
+func (t *TerraformExecutor) DestroyTargets(ctx context.Context, credentials map[string]string, targets []string) error {
+	t.DestroyTargetsCall.CallCount++
+	t.DestroyTargetsCall.Receives.Context = ctx
+	t.DestroyTargetsCall.Receives.Credentials = credentials
+	t.DestroyTargetsCall.Receives.Targets = targets
+	return t.DestroyTargetsCall.Returns.Error
+}
+
+func (t *TerraformExecutor) StateList() ([]string, error) {
+	t.StateListCall.CallCount++
+	return t.StateListCall.Returns.Addresses, t.StateListCall.Returns.Error
+}
+
 func (t *TerraformExecutor) Validate(credentials map[string]string) error {
 	t.ValidateCall.CallCount++
 	t.ValidateCall.Receives.Credentials = credentials
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"context"
+
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
@@ -24,6 +26,26 @@ type TerraformManager struct {
 			Error error
 		}
 	}
+	SetupWithTemplateCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+			Template string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	ValidateTemplateCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+			Template string
+		}
+		Returns struct {
+			Error error
+		}
+	}
 	ApplyCall struct {
 		CallCount int
 		Receives  struct {
@@ -37,6 +59,19 @@ type TerraformManager struct {
 	DestroyCall struct {
 		CallCount int
 		Receives  struct {
+			Context  context.Context
+			BBLState storage.State
+		}
+		Returns struct {
+			BBLState storage.State
+			Error    error
+		}
+		Stub func(storage.State) (storage.State, error)
+	}
+	DestroyKeepingNetworkCall struct {
+		CallCount int
+		Receives  struct {
+			Context  context.Context
 			BBLState storage.State
 		}
 		Returns struct {
@@ -44,6 +79,17 @@ type TerraformManager struct {
 			Error    error
 		}
 	}
+	DestroyAddressesCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState    storage.State
+			KeepNetwork bool
+		}
+		Returns struct {
+			Addresses []string
+			Error     error
+		}
+	}
 	ValidateCall struct {
 		CallCount int
 		Receives  struct {
@@ -101,6 +147,22 @@ func (t *TerraformManager) Setup(bblState storage.State) error {
 	return t.SetupCall.Returns.Error
 }
 
+func (t *TerraformManager) SetupWithTemplate(bblState storage.State, template string) error {
+	t.SetupWithTemplateCall.CallCount++
+	t.SetupWithTemplateCall.Receives.BBLState = bblState
+	t.SetupWithTemplateCall.Receives.Template = template
+
+	return t.SetupWithTemplateCall.Returns.Error
+}
+
+func (t *TerraformManager) ValidateTemplate(bblState storage.State, template string) error {
+	t.ValidateTemplateCall.CallCount++
+	t.ValidateTemplateCall.Receives.BBLState = bblState
+	t.ValidateTemplateCall.Receives.Template = template
+
+	return t.ValidateTemplateCall.Returns.Error
+}
+
 func (t *TerraformManager) Init(bblState storage.State) error {
 	t.InitCall.CallCount++
 	t.InitCall.Receives.BBLState = bblState
@@ -115,13 +177,34 @@ func (t *TerraformManager) Apply(bblState storage.State) (storage.State, error)
 	return t.ApplyCall.Returns.BBLState, t.ApplyCall.Returns.Error
 }
 
-func (t *TerraformManager) Destroy(bblState storage.State) (storage.State, error) {
+func (t *TerraformManager) Destroy(ctx context.Context, bblState storage.State) (storage.State, error) {
 	t.DestroyCall.CallCount++
+	t.DestroyCall.Receives.Context = ctx
 	t.DestroyCall.Receives.BBLState = bblState
 
+	if t.DestroyCall.Stub != nil {
+		return t.DestroyCall.Stub(bblState)
+	}
+
 	return t.DestroyCall.Returns.BBLState, t.DestroyCall.Returns.Error
 }
 
+func (t *TerraformManager) DestroyKeepingNetwork(ctx context.Context, bblState storage.State) (storage.State, error) {
+	t.DestroyKeepingNetworkCall.CallCount++
+	t.DestroyKeepingNetworkCall.Receives.Context = ctx
+	t.DestroyKeepingNetworkCall.Receives.BBLState = bblState
+
+	return t.DestroyKeepingNetworkCall.Returns.BBLState, t.DestroyKeepingNetworkCall.Returns.Error
+}
+
+func (t *TerraformManager) DestroyAddresses(bblState storage.State, keepNetwork bool) ([]string, error) {
+	t.DestroyAddressesCall.CallCount++
+	t.DestroyAddressesCall.Receives.BBLState = bblState
+	t.DestroyAddressesCall.Receives.KeepNetwork = keepNetwork
+
+	return t.DestroyAddressesCall.Returns.Addresses, t.DestroyAddressesCall.Returns.Error
+}
+
 func (t *TerraformManager) Validate(bblState storage.State) (storage.State, error) {
 	t.ValidateCall.CallCount++
 	t.ValidateCall.Receives.BBLState = bblState
@@ -0,0 +1,24 @@
+package fakes
+
+type TombstoneWriter struct {
+	WriteTombstoneCall struct {
+		CallCount int
+		Receives  struct {
+			Bucket   string
+			EnvID    string
+			Contents []byte
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (t *TombstoneWriter) WriteTombstone(bucket, envID string, contents []byte) error {
+	t.WriteTombstoneCall.CallCount++
+	t.WriteTombstoneCall.Receives.Bucket = bucket
+	t.WriteTombstoneCall.Receives.EnvID = envID
+	t.WriteTombstoneCall.Receives.Contents = contents
+
+	return t.WriteTombstoneCall.Returns.Error
+}
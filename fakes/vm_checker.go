@@ -0,0 +1,26 @@
+package fakes
+
+type VMChecker struct {
+	VMExistsCall struct {
+		CallCount int
+		Receives  struct {
+			CID string
+		}
+		Returns struct {
+			Exists bool
+			Error  error
+		}
+		Stub func(cid string) (bool, error)
+	}
+}
+
+func (v *VMChecker) VMExists(cid string) (bool, error) {
+	v.VMExistsCall.CallCount++
+	v.VMExistsCall.Receives.CID = cid
+
+	if v.VMExistsCall.Stub != nil {
+		return v.VMExistsCall.Stub(cid)
+	}
+
+	return v.VMExistsCall.Returns.Exists, v.VMExistsCall.Returns.Error
+}
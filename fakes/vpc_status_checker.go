@@ -11,6 +11,27 @@ type VPCStatusChecker struct {
 			Error error
 		}
 	}
+
+	ActivePeeringsCall struct {
+		CallCount int
+		Receives  struct {
+			VPCID string
+		}
+		Returns struct {
+			Peerings []string
+			Error    error
+		}
+	}
+
+	DeletePeeringsCall struct {
+		CallCount int
+		Receives  struct {
+			VPCID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
 }
 
 func (v *VPCStatusChecker) ValidateSafeToDelete(vpcID, envID string) error {
@@ -19,3 +40,15 @@ func (v *VPCStatusChecker) ValidateSafeToDelete(vpcID, envID string) error {
 	v.ValidateSafeToDeleteCall.Receives.EnvID = envID
 	return v.ValidateSafeToDeleteCall.Returns.Error
 }
+
+func (v *VPCStatusChecker) ActivePeerings(vpcID string) ([]string, error) {
+	v.ActivePeeringsCall.CallCount++
+	v.ActivePeeringsCall.Receives.VPCID = vpcID
+	return v.ActivePeeringsCall.Returns.Peerings, v.ActivePeeringsCall.Returns.Error
+}
+
+func (v *VPCStatusChecker) DeletePeerings(vpcID string) error {
+	v.DeletePeeringsCall.CallCount++
+	v.DeletePeeringsCall.Receives.VPCID = vpcID
+	return v.DeletePeeringsCall.Returns.Error
+}
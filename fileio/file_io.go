@@ -45,3 +45,11 @@ type AllRemover interface {
 type AllMkdirer interface {
 	MkdirAll(dir string, perm os.FileMode) error
 }
+
+type Opener interface {
+	Open(name string) (afero.File, error)
+}
+
+type Chmoder interface {
+	Chmod(name string, mode os.FileMode) error
+}
@@ -3,6 +3,7 @@ package flags
 import (
 	"flag"
 	"io/ioutil"
+	"time"
 )
 
 type Flags struct {
@@ -27,6 +28,24 @@ func (f Flags) Bool(v *bool, name string) {
 	f.set.BoolVar(v, name, false, "")
 }
 
+func (f Flags) Int(v *int, name string, value int) {
+	f.set.IntVar(v, name, value, "")
+}
+
+func (f Flags) Duration(v *time.Duration, name string, value time.Duration) {
+	f.set.DurationVar(v, name, value, "")
+}
+
+// Values returns every flag by name with its value after parsing, including
+// the defaults of flags that were not given.
+func (f Flags) Values() map[string]string {
+	values := map[string]string{}
+	f.set.VisitAll(func(fl *flag.Flag) {
+		values[fl.Name] = fl.Value.String()
+	})
+	return values
+}
+
 func (f Flags) Parse(args []string) error {
 	return f.set.Parse(args)
 }
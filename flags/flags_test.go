@@ -1,6 +1,8 @@
 package flags_test
 
 import (
+	"time"
+
 	"github.com/cloudfoundry/bosh-bootloader/flags"
 
 	. "github.com/onsi/ginkgo"
@@ -9,15 +11,19 @@ import (
 
 var _ = Describe("Flags", func() {
 	var (
-		f         flags.Flags
-		stringVal string
-		boolVal   bool
+		f           flags.Flags
+		stringVal   string
+		boolVal     bool
+		durationVal time.Duration
+		intVal      int
 	)
 
 	BeforeEach(func() {
 		f = flags.New("test")
 		f.String(&stringVal, "string", "")
 		f.Bool(&boolVal, "bool")
+		f.Duration(&durationVal, "duration", 0)
+		f.Int(&intVal, "int", 0)
 	})
 
 	Describe("Parse", func() {
@@ -32,6 +38,31 @@ var _ = Describe("Flags", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(boolVal).To(BeTrue())
 		})
+
+		It("can parse duration flags", func() {
+			err := f.Parse([]string{"--duration", "90s"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(durationVal).To(Equal(90 * time.Second))
+		})
+
+		It("can parse int flags", func() {
+			err := f.Parse([]string{"--int", "7"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(intVal).To(Equal(7))
+		})
+	})
+
+	Describe("Values", func() {
+		It("returns the value of every flag", func() {
+			err := f.Parse([]string{"--string", "string_value", "--int", "7"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(f.Values()).To(Equal(map[string]string{
+				"string":   "string_value",
+				"bool":     "false",
+				"duration": "0s",
+				"int":      "7",
+			}))
+		})
 	})
 
 	Describe("Args", func() {
@@ -2,15 +2,18 @@ package gcp
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 type Client struct {
 	computeClient ComputeClient
 	projectID     string
 	zone          string
+	region        string
 }
 
 type ComputeClient interface {
@@ -19,6 +22,15 @@ type ComputeClient interface {
 	GetZone(zone, projectID string) (*compute.Zone, error)
 	GetRegion(region, projectID string) (*compute.Region, error)
 	GetNetworks(name, projectID string) (*compute.NetworkList, error)
+	GetProject(projectID string) (*compute.Project, error)
+	ListDisks(projectID, zone string) (*compute.DiskList, error)
+	ListRegionDisks(projectID, region string) (*compute.DiskList, error)
+	DeleteDisk(projectID, zone, disk string) error
+	DeleteRegionDisk(projectID, region, disk string) error
+	ListImages(projectID string) (*compute.ImageList, error)
+	DeleteImage(projectID, image string) error
+	ListRouters(projectID, region string) (*compute.RouterList, error)
+	DeleteRouter(projectID, region, router string) error
 }
 
 func (c Client) ProjectID() string {
@@ -47,6 +59,15 @@ func (c Client) GetNetworks(name string) (*compute.NetworkList, error) {
 
 // Methods added to conform to IAAS-agnostic interfaces
 
+func (c Client) CheckConnectivity() error {
+	_, err := c.computeClient.GetProject(c.projectID)
+	if err != nil {
+		return fmt.Errorf("Get project: %s", err)
+	}
+
+	return nil
+}
+
 func (c Client) CheckExists(networkName string) (bool, error) {
 	networkList, err := c.GetNetworks(networkName)
 	if err != nil {
@@ -74,7 +95,12 @@ func (c Client) ValidateSafeToDelete(networkName string, envID string) error {
 		}
 	}
 
-	if len(runningInstances) == 0 {
+	attachedDisks, err := c.listAttachedRegionDisks(envID, instanceList.Items)
+	if err != nil {
+		return err
+	}
+
+	if len(runningInstances) == 0 && len(attachedDisks) == 0 {
 		return nil
 	}
 
@@ -94,8 +120,20 @@ func (c Client) ValidateSafeToDelete(networkName string, envID string) error {
 		}
 	}
 
-	return fmt.Errorf("bbl environment is not safe to delete; vms still exist in network:\n%s",
-		strings.Join(errorMessages, "\n"))
+	for _, disk := range attachedDisks {
+		errorMessages = append(errorMessages, fmt.Sprintf("%s (regional disk attached to %s)", disk.Name, strings.Join(disk.Users, ", ")))
+	}
+
+	var remaining []string
+	if len(runningInstances) > 0 {
+		remaining = append(remaining, "vms still exist in network")
+	}
+	if len(attachedDisks) > 0 {
+		remaining = append(remaining, "disks are still attached")
+	}
+
+	return fmt.Errorf("bbl environment is not safe to delete; %s:\n%s",
+		strings.Join(remaining, " and "), strings.Join(errorMessages, "\n"))
 }
 
 func (c Client) isInNetwork(networkName string, networkInterfaces []*compute.NetworkInterface) bool {
@@ -117,3 +155,200 @@ func (c Client) isBoshDirector(metadata *compute.Metadata) bool {
 
 	return false
 }
+
+// VMExists reports whether an instance with the given name is still in the
+// zone. A preempted instance is stopped rather than removed, so it counts.
+func (c Client) VMExists(name string) (bool, error) {
+	instances, err := c.listInstances()
+	if err != nil {
+		return false, fmt.Errorf("List instances: %s", err)
+	}
+
+	for _, instance := range instances.Items {
+		if instance.Name == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// DeleteDisks removes the unattached zonal and regional disks labelled with
+// the env id, such as the persistent disk of a director whose deletion failed.
+// Regional disks are not returned by the zonal API and are deleted separately.
+func (c Client) DeleteDisks(envID string) error {
+	zonalDisks, err := c.computeClient.ListDisks(c.projectID, c.zone)
+	if err != nil {
+		return fmt.Errorf("List zonal disks: %s", err)
+	}
+
+	for _, disk := range zonalDisks.Items {
+		if !isEnvDisk(disk, envID) || len(disk.Users) > 0 {
+			continue
+		}
+
+		err = c.computeClient.DeleteDisk(c.projectID, c.zone, disk.Name)
+		if err != nil {
+			return fmt.Errorf("Delete zonal disk %s: %s", disk.Name, err)
+		}
+	}
+
+	regionalDisks, err := c.computeClient.ListRegionDisks(c.projectID, c.region)
+	if err != nil {
+		return fmt.Errorf("List regional disks: %s", err)
+	}
+
+	for _, disk := range regionalDisks.Items {
+		if !isEnvDisk(disk, envID) || len(disk.Users) > 0 {
+			continue
+		}
+
+		err = c.computeClient.DeleteRegionDisk(c.projectID, c.region, disk.Name)
+		if err != nil {
+			return fmt.Errorf("Delete regional disk %s: %s", disk.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteImages deletes the images in the project that are labelled with the
+// env id and returns the names of those it deleted. Images that are already
+// gone are skipped and not reported.
+func (c Client) DeleteImages(envID string) ([]string, error) {
+	images, err := c.computeClient.ListImages(c.projectID)
+	if err != nil {
+		return nil, fmt.Errorf("List images: %s", err)
+	}
+
+	deleted := []string{}
+	for _, image := range images.Items {
+		if image.Labels["env_id"] != envID {
+			continue
+		}
+
+		err = c.computeClient.DeleteImage(c.projectID, image.Name)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("Delete image %s: %s", image.Name, err)
+		}
+
+		deleted = append(deleted, image.Name)
+	}
+
+	return deleted, nil
+}
+
+// DeleteRouters deletes the Cloud Routers in the region that are named after
+// the environment or attached to its network, along with the Cloud NAT
+// configs they hold. Only the exact env id counts as its name, so that the
+// routers of an env whose id starts with this one are left alone. Routers that are already gone are skipped and not
+// reported.
+func (c Client) DeleteRouters(envID string) ([]string, error) {
+	routers, err := c.computeClient.ListRouters(c.projectID, c.region)
+	if err != nil {
+		return nil, fmt.Errorf("List routers: %s", err)
+	}
+
+	deleted := []string{}
+	for _, router := range routers.Items {
+		if !isEnvRouter(router, envID) {
+			continue
+		}
+
+		err = c.computeClient.DeleteRouter(c.projectID, c.region, router.Name)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("Delete router %s: %s", router.Name, err)
+		}
+
+		deleted = append(deleted, router.Name)
+	}
+
+	return deleted, nil
+}
+
+func isEnvRouter(router *compute.Router, envID string) bool {
+	if router.Name == envID {
+		return true
+	}
+
+	return strings.HasSuffix(router.Network, "/networks/"+envID+"-network")
+}
+
+// CheckLocation fails if the region or zone the env was created in is no
+// longer offered, since nothing can be deleted from a zone that is gone.
+func (c Client) CheckLocation(region, zone string) error {
+	_, err := c.computeClient.GetRegion(region, c.projectID)
+	if isNotFound(err) {
+		return fmt.Errorf("region %s no longer exists", region)
+	}
+	if err != nil {
+		return fmt.Errorf("Get region: %s", err)
+	}
+
+	if zone == "" {
+		return nil
+	}
+
+	gcpZone, err := c.computeClient.GetZone(zone, c.projectID)
+	if isNotFound(err) {
+		return fmt.Errorf("zone %s no longer exists", zone)
+	}
+	if err != nil {
+		return fmt.Errorf("Get zone: %s", err)
+	}
+
+	if gcpZone.Deprecated != nil && (gcpZone.Deprecated.State == "DELETED" || gcpZone.Deprecated.State == "OBSOLETE") {
+		return fmt.Errorf("zone %s no longer exists", zone)
+	}
+
+	return nil
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+// listAttachedRegionDisks returns the regional disks of the environment that
+// are attached to something other than a director in the zone. Their users
+// may live in any zone of the region, so they are not covered by the zonal
+// instance list.
+func (c Client) listAttachedRegionDisks(envID string, instances []*compute.Instance) ([]*compute.Disk, error) {
+	directors := map[string]bool{}
+	for _, instance := range instances {
+		if c.isBoshDirector(instance.Metadata) {
+			directors[instance.SelfLink] = true
+		}
+	}
+
+	diskList, err := c.computeClient.ListRegionDisks(c.projectID, c.region)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachedDisks []*compute.Disk
+	for _, disk := range diskList.Items {
+		if !isEnvDisk(disk, envID) {
+			continue
+		}
+
+		for _, user := range disk.Users {
+			if !directors[user] {
+				attachedDisks = append(attachedDisks, disk)
+				break
+			}
+		}
+	}
+
+	return attachedDisks, nil
+}
+
+func isEnvDisk(disk *compute.Disk, envID string) bool {
+	return disk.Labels["env_id"] == envID
+}
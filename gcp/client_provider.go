@@ -5,20 +5,22 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	compute "google.golang.org/api/compute/v1"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
 )
 
-func gcpHTTPClientFunc(config *jwt.Config) *http.Client {
-	return config.Client(context.Background())
+func gcpHTTPClientFunc(ctx context.Context, config *jwt.Config) *http.Client {
+	return config.Client(ctx)
 }
 
 var gcpHTTPClient = gcpHTTPClientFunc
 
-func NewClient(gcpConfig storage.GCP, basePath string) (Client, error) {
+func NewClient(gcpConfig storage.GCP, httpSettings helpers.HTTPSettings, basePath string) (Client, error) {
 	config, err := google.JWTConfigFromJSON([]byte(gcpConfig.ServiceAccountKey), compute.ComputeScope)
 	if err != nil {
 		return Client{}, fmt.Errorf("parse service account key: %s", err)
@@ -28,7 +30,17 @@ func NewClient(gcpConfig storage.GCP, basePath string) (Client, error) {
 		config.TokenURL = basePath
 	}
 
-	service, err := compute.New(gcpHTTPClient(config))
+	// The oauth2 client sends both the token requests and the api calls
+	// with the client in the context. Without one it would use
+	// http.DefaultClient, which bbl wraps with the same settings.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: httpSettings.Transport()})
+
+	httpClient := gcpHTTPClient(ctx, config)
+	if httpClient != nil {
+		httpClient = httpSettings.Wrap(httpClient)
+	}
+
+	service, err := compute.New(httpClient)
 	if err != nil {
 		return Client{}, fmt.Errorf("create gcp client: %s", err)
 	}
@@ -41,6 +53,7 @@ func NewClient(gcpConfig storage.GCP, basePath string) (Client, error) {
 		computeClient: gcpComputeClient{service: service},
 		projectID:     gcpConfig.ProjectID,
 		zone:          gcpConfig.Zone,
+		region:        gcpConfig.Region,
 	}
 
 	_, err = client.GetRegion(gcpConfig.Region)
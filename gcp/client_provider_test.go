@@ -1,16 +1,20 @@
 package gcp_test
 
 import (
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
 )
 
@@ -28,7 +32,7 @@ var _ = Describe("NewClient", func() {
 				"private_key": %q
 			}`, string(privateKeyContents))
 
-		gcp.SetGCPHTTPClient(func(*jwt.Config) *http.Client {
+		gcp.SetGCPHTTPClient(func(context.Context, *jwt.Config) *http.Client {
 			return &http.Client{
 				Transport: &http.Transport{
 					TLSClientConfig: &tls.Config{
@@ -61,10 +65,39 @@ var _ = Describe("NewClient", func() {
 			ProjectID:         "proj-id",
 			Region:            "some-region",
 			Zone:              "some-zone",
-		}, basePath)
+		}, helpers.HTTPSettings{}, basePath)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
+	Context("when root CAs are provided", func() {
+		It("sends the token requests and api calls with a client that trusts them", func() {
+			var ctxClient interface{}
+			gcp.SetGCPHTTPClient(func(ctx context.Context, _ *jwt.Config) *http.Client {
+				ctxClient = ctx.Value(oauth2.HTTPClient)
+				return &http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{
+							InsecureSkipVerify: true,
+						},
+					},
+				}
+			})
+
+			rootCAs := x509.NewCertPool()
+			_, err := gcp.NewClient(storage.GCP{
+				ServiceAccountKey: serviceAccountKey,
+				ProjectID:         "proj-id",
+				Region:            "some-region",
+				Zone:              "some-zone",
+			}, helpers.HTTPSettings{RootCAs: rootCAs}, basePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ctxClient).To(BeAssignableToTypeOf(&http.Client{}))
+			transport := ctxClient.(*http.Client).Transport.(*http.Transport)
+			Expect(transport.TLSClientConfig.RootCAs).To(BeIdenticalTo(rootCAs))
+		})
+	})
+
 	Context("when the service account key is not valid json", func() {
 		It("returns an error", func() {
 			_, err := gcp.NewClient(storage.GCP{
@@ -72,14 +105,14 @@ var _ = Describe("NewClient", func() {
 				ProjectID:         "proj-id",
 				Region:            "some-region",
 				Zone:              "some-zone",
-			}, basePath)
+			}, helpers.HTTPSettings{}, basePath)
 			Expect(err).To(MatchError("parse service account key: invalid character '%' looking for beginning of value"))
 		})
 	})
 
 	Context("when a service could not be created", func() {
 		BeforeEach(func() {
-			gcp.SetGCPHTTPClient(func(*jwt.Config) *http.Client {
+			gcp.SetGCPHTTPClient(func(context.Context, *jwt.Config) *http.Client {
 				return nil
 			})
 		})
@@ -90,7 +123,7 @@ var _ = Describe("NewClient", func() {
 				ProjectID:         "proj-id",
 				Region:            "some-region",
 				Zone:              "some-zone",
-			}, basePath)
+			}, helpers.HTTPSettings{}, basePath)
 			Expect(err).To(MatchError("create gcp client: client is nil"))
 		})
 	})
@@ -102,7 +135,7 @@ var _ = Describe("NewClient", func() {
 				ProjectID:         "proj-id",
 				Region:            "bad-region",
 				Zone:              "some-zone",
-			}, basePath)
+			}, helpers.HTTPSettings{}, basePath)
 			Expect(err).To(MatchError(ContainSubstring("get region: ")))
 			Expect(err).To(MatchError(ContainSubstring("googleapi")))
 			Expect(err).To(MatchError(ContainSubstring("404")))
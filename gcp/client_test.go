@@ -6,6 +6,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/gcp"
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -20,7 +21,7 @@ var _ = Describe("Client", func() {
 	Describe("ValidateSafeToDelete", func() {
 		BeforeEach(func() {
 			computeClient = &fakes.GCPComputeClient{}
-			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone")
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
 		})
 
 		Context("when the bosh director is the only vm on the network", func() {
@@ -124,7 +125,70 @@ not-a-bosh-managed-vm (not managed by bosh)`))
 			})
 		})
 
+		Context("when a regional disk of the environment is attached outside of the zone", func() {
+			BeforeEach(func() {
+				boshInitString := "bosh-init"
+
+				computeClient.ListInstancesCall.Returns.InstanceList = &compute.InstanceList{
+					Items: []*compute.Instance{
+						{
+							Name:     "bosh-director",
+							SelfLink: "zones/some-zone/instances/bosh-director",
+							Metadata: &compute.Metadata{
+								Items: []*compute.MetadataItems{
+									{
+										Key:   "director",
+										Value: &boshInitString,
+									},
+								},
+							},
+						},
+					},
+				}
+				computeClient.ListRegionDisksCall.Returns.DiskList = &compute.DiskList{
+					Items: []*compute.Disk{
+						{
+							Name:   "director-disk",
+							Labels: map[string]string{"env_id": "some-env-id"},
+							Users:  []string{"zones/some-zone/instances/bosh-director"},
+						},
+						{
+							Name:   "other-env-disk",
+							Labels: map[string]string{"env_id": "other-env-id"},
+							Users:  []string{"zones/other-zone/instances/other-vm"},
+						},
+						{
+							Name:   "deployment-disk",
+							Labels: map[string]string{"env_id": "some-env-id"},
+							Users:  []string{"zones/other-zone/instances/some-vm"},
+						},
+					},
+				}
+			})
+
+			It("returns a helpful error message", func() {
+				err := client.ValidateSafeToDelete("network-name", "some-env-id")
+
+				Expect(computeClient.ListRegionDisksCall.Receives.ProjectID).To(Equal("some-project-id"))
+				Expect(computeClient.ListRegionDisksCall.Receives.Region).To(Equal("some-region"))
+				Expect(err).To(MatchError(`bbl environment is not safe to delete; disks are still attached:
+deployment-disk (regional disk attached to zones/other-zone/instances/some-vm)`))
+			})
+		})
+
 		Context("failure cases", func() {
+			Context("when gcp client list region disks fails", func() {
+				BeforeEach(func() {
+					computeClient.ListInstancesCall.Returns.InstanceList = &compute.InstanceList{}
+					computeClient.ListRegionDisksCall.Returns.Error = errors.New("fails to list disks")
+				})
+
+				It("returns an error", func() {
+					err := client.ValidateSafeToDelete("some-network", "some-env-id")
+					Expect(err).To(MatchError("fails to list disks"))
+				})
+			})
+
 			Context("when gcp client list instances fails", func() {
 				BeforeEach(func() {
 					computeClient.ListInstancesCall.Returns.Error = errors.New("fails to list instances")
@@ -137,4 +201,302 @@ not-a-bosh-managed-vm (not managed by bosh)`))
 			})
 		})
 	})
+
+	Describe("CheckConnectivity", func() {
+		BeforeEach(func() {
+			computeClient = &fakes.GCPComputeClient{}
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
+		})
+
+		It("gets the project", func() {
+			err := client.CheckConnectivity()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(computeClient.GetProjectCall.CallCount).To(Equal(1))
+			Expect(computeClient.GetProjectCall.Receives.ProjectID).To(Equal("some-project-id"))
+		})
+
+		Context("when getting the project fails", func() {
+			It("returns an error", func() {
+				computeClient.GetProjectCall.Returns.Error = errors.New("permission denied")
+
+				err := client.CheckConnectivity()
+				Expect(err).To(MatchError("Get project: permission denied"))
+			})
+		})
+	})
+
+	Describe("VMExists", func() {
+		BeforeEach(func() {
+			computeClient = &fakes.GCPComputeClient{}
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
+			computeClient.ListInstancesCall.Returns.InstanceList = &compute.InstanceList{
+				Items: []*compute.Instance{{Name: "vm-123"}},
+			}
+		})
+
+		It("reports whether an instance with the name is in the zone", func() {
+			exists, err := client.VMExists("vm-123")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+
+			exists, err = client.VMExists("vm-456")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		Context("when listing instances fails", func() {
+			It("returns an error", func() {
+				computeClient.ListInstancesCall.Returns.Error = errors.New("fails to list instances")
+
+				_, err := client.VMExists("vm-123")
+				Expect(err).To(MatchError("List instances: fails to list instances"))
+			})
+		})
+	})
+
+	Describe("DeleteDisks", func() {
+		BeforeEach(func() {
+			computeClient = &fakes.GCPComputeClient{}
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
+
+			computeClient.ListDisksCall.Returns.DiskList = &compute.DiskList{
+				Items: []*compute.Disk{
+					{Name: "zonal-disk", Labels: map[string]string{"env_id": "some-env-id"}},
+					{Name: "attached-zonal-disk", Labels: map[string]string{"env_id": "some-env-id"}, Users: []string{"some-vm"}},
+					{Name: "other-zonal-disk", Labels: map[string]string{"env_id": "other-env-id"}},
+				},
+			}
+			computeClient.ListRegionDisksCall.Returns.DiskList = &compute.DiskList{
+				Items: []*compute.Disk{
+					{Name: "regional-disk", Labels: map[string]string{"env_id": "some-env-id"}},
+					{Name: "unlabelled-regional-disk"},
+				},
+			}
+		})
+
+		It("deletes unattached zonal disks of the environment in the zone", func() {
+			err := client.DeleteDisks("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(computeClient.ListDisksCall.Receives.ProjectID).To(Equal("some-project-id"))
+			Expect(computeClient.ListDisksCall.Receives.Zone).To(Equal("some-zone"))
+			Expect(computeClient.DeleteDiskCall.Receives).To(Equal([]fakes.DeleteDiskReceive{
+				{ProjectID: "some-project-id", Zone: "some-zone", Disk: "zonal-disk"},
+			}))
+		})
+
+		It("deletes unattached regional disks of the environment in the region", func() {
+			err := client.DeleteDisks("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(computeClient.ListRegionDisksCall.Receives.ProjectID).To(Equal("some-project-id"))
+			Expect(computeClient.ListRegionDisksCall.Receives.Region).To(Equal("some-region"))
+			Expect(computeClient.DeleteRegionDiskCall.Receives).To(Equal([]fakes.DeleteRegionDiskReceive{
+				{ProjectID: "some-project-id", Region: "some-region", Disk: "regional-disk"},
+			}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when listing zonal disks fails", func() {
+				computeClient.ListDisksCall.Returns.Error = errors.New("failed to list")
+
+				err := client.DeleteDisks("some-env-id")
+				Expect(err).To(MatchError("List zonal disks: failed to list"))
+			})
+
+			It("returns an error when deleting a zonal disk fails", func() {
+				computeClient.DeleteDiskCall.Returns.Error = errors.New("failed to delete")
+
+				err := client.DeleteDisks("some-env-id")
+				Expect(err).To(MatchError("Delete zonal disk zonal-disk: failed to delete"))
+			})
+
+			It("returns an error when listing regional disks fails", func() {
+				computeClient.ListRegionDisksCall.Returns.Error = errors.New("failed to list")
+
+				err := client.DeleteDisks("some-env-id")
+				Expect(err).To(MatchError("List regional disks: failed to list"))
+			})
+
+			It("returns an error when deleting a regional disk fails", func() {
+				computeClient.DeleteRegionDiskCall.Returns.Error = errors.New("failed to delete")
+
+				err := client.DeleteDisks("some-env-id")
+				Expect(err).To(MatchError("Delete regional disk regional-disk: failed to delete"))
+			})
+		})
+	})
+
+	Describe("DeleteImages", func() {
+		BeforeEach(func() {
+			computeClient = &fakes.GCPComputeClient{}
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
+
+			computeClient.ListImagesCall.Returns.ImageList = &compute.ImageList{
+				Items: []*compute.Image{
+					{Name: "some-image", Labels: map[string]string{"env_id": "some-env-id"}},
+					{Name: "other-image", Labels: map[string]string{"env_id": "other-env-id"}},
+					{Name: "unlabelled-image"},
+				},
+			}
+		})
+
+		It("deletes the images of the environment", func() {
+			deleted, err := client.DeleteImages("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal([]string{"some-image"}))
+
+			Expect(computeClient.ListImagesCall.Receives.ProjectID).To(Equal("some-project-id"))
+			Expect(computeClient.DeleteImageCall.Receives).To(Equal([]fakes.DeleteImageReceive{
+				{ProjectID: "some-project-id", Image: "some-image"},
+			}))
+		})
+
+		Context("when the image is already gone", func() {
+			It("skips it without reporting it as deleted", func() {
+				computeClient.DeleteImageCall.Returns.Error = &googleapi.Error{Code: 404}
+
+				deleted, err := client.DeleteImages("some-env-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(BeEmpty())
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when listing images fails", func() {
+				computeClient.ListImagesCall.Returns.Error = errors.New("failed to list")
+
+				_, err := client.DeleteImages("some-env-id")
+				Expect(err).To(MatchError("List images: failed to list"))
+			})
+
+			It("returns an error when deleting an image fails", func() {
+				computeClient.DeleteImageCall.Returns.Error = errors.New("failed to delete")
+
+				_, err := client.DeleteImages("some-env-id")
+				Expect(err).To(MatchError("Delete image some-image: failed to delete"))
+			})
+		})
+	})
+
+	Describe("DeleteRouters", func() {
+		BeforeEach(func() {
+			computeClient = &fakes.GCPComputeClient{}
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
+
+			computeClient.ListRoutersCall.Returns.RouterList = &compute.RouterList{
+				Items: []*compute.Router{
+					{Name: "some-env-id"},
+					{Name: "nat-router", Network: "https://www.googleapis.com/compute/v1/projects/some-project-id/global/networks/some-env-id-network"},
+					{Name: "some-env-id-2-router", Network: "https://www.googleapis.com/compute/v1/projects/some-project-id/global/networks/some-env-id-2-network"},
+					{Name: "some-env-id-router", Network: "https://www.googleapis.com/compute/v1/projects/some-project-id/global/networks/shared-network"},
+					{Name: "other-env-id-router"},
+				},
+			}
+		})
+
+		It("deletes the routers named after the environment or attached to its network", func() {
+			deleted, err := client.DeleteRouters("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal([]string{"some-env-id", "nat-router"}))
+
+			Expect(computeClient.ListRoutersCall.Receives.ProjectID).To(Equal("some-project-id"))
+			Expect(computeClient.ListRoutersCall.Receives.Region).To(Equal("some-region"))
+			Expect(computeClient.DeleteRouterCall.Receives).To(ContainElement(fakes.DeleteRouterReceive{
+				ProjectID: "some-project-id", Region: "some-region", Router: "nat-router",
+			}))
+		})
+
+		It("leaves the routers of envs whose id starts with the env id", func() {
+			_, err := client.DeleteRouters("some-env-id")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(computeClient.DeleteRouterCall.Receives).To(HaveLen(2))
+			Expect(computeClient.DeleteRouterCall.Receives).NotTo(ContainElement(fakes.DeleteRouterReceive{
+				ProjectID: "some-project-id", Region: "some-region", Router: "some-env-id-2-router",
+			}))
+		})
+
+		Context("when the router is already gone", func() {
+			It("skips it without reporting it as deleted", func() {
+				computeClient.DeleteRouterCall.Returns.Error = &googleapi.Error{Code: 404}
+
+				deleted, err := client.DeleteRouters("some-env-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deleted).To(BeEmpty())
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when listing routers fails", func() {
+				computeClient.ListRoutersCall.Returns.Error = errors.New("failed to list")
+
+				_, err := client.DeleteRouters("some-env-id")
+				Expect(err).To(MatchError("List routers: failed to list"))
+			})
+
+			It("returns an error when deleting a router fails", func() {
+				computeClient.DeleteRouterCall.Returns.Error = errors.New("failed to delete")
+
+				_, err := client.DeleteRouters("some-env-id")
+				Expect(err).To(MatchError("Delete router some-env-id: failed to delete"))
+			})
+		})
+	})
+
+	Describe("CheckLocation", func() {
+		BeforeEach(func() {
+			computeClient = &fakes.GCPComputeClient{}
+			client = gcp.NewClientWithInjectedComputeClient(computeClient, "some-project-id", "some-zone", "some-region")
+			computeClient.GetZoneCall.Returns.Zone = &compute.Zone{Name: "some-zone", Status: "UP"}
+		})
+
+		It("gets the region and zone", func() {
+			err := client.CheckLocation("some-region", "some-zone")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(computeClient.GetRegionCall.Receives.Region).To(Equal("some-region"))
+			Expect(computeClient.GetZoneCall.Receives.Zone).To(Equal("some-zone"))
+			Expect(computeClient.GetZoneCall.Receives.ProjectID).To(Equal("some-project-id"))
+		})
+
+		Context("when the zone is gone", func() {
+			It("returns an error", func() {
+				computeClient.GetZoneCall.Returns.Zone = nil
+				computeClient.GetZoneCall.Returns.Error = &googleapi.Error{Code: 404}
+
+				err := client.CheckLocation("some-region", "some-zone")
+				Expect(err).To(MatchError("zone some-zone no longer exists"))
+			})
+		})
+
+		Context("when the zone has been deleted", func() {
+			It("returns an error", func() {
+				computeClient.GetZoneCall.Returns.Zone.Deprecated = &compute.DeprecationStatus{State: "DELETED"}
+
+				err := client.CheckLocation("some-region", "some-zone")
+				Expect(err).To(MatchError("zone some-zone no longer exists"))
+			})
+		})
+
+		Context("when the region is gone", func() {
+			It("returns an error without checking the zone", func() {
+				computeClient.GetRegionCall.Returns.Error = &googleapi.Error{Code: 404}
+
+				err := client.CheckLocation("some-region", "some-zone")
+				Expect(err).To(MatchError("region some-region no longer exists"))
+				Expect(computeClient.GetZoneCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when getting the zone fails", func() {
+				computeClient.GetZoneCall.Returns.Error = errors.New("permission denied")
+
+				err := client.CheckLocation("some-region", "some-zone")
+				Expect(err).To(MatchError("Get zone: permission denied"))
+			})
+		})
+	})
 })
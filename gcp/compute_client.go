@@ -1,18 +1,36 @@
 package gcp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	compute "google.golang.org/api/compute/v1"
 )
 
+var (
+	operationPollInterval = 2 * time.Second
+	operationTimeout      = 10 * time.Minute
+)
+
 type gcpComputeClient struct {
 	service *compute.Service
 }
 
+// ListInstances returns the instances in the zone from every page of results.
 func (g gcpComputeClient) ListInstances(projectID, zone string) (*compute.InstanceList, error) {
-	return g.service.Instances.List(projectID, zone).Do()
+	instances := &compute.InstanceList{}
+	err := g.service.Instances.List(projectID, zone).Pages(context.Background(), func(page *compute.InstanceList) error {
+		instances.Items = append(instances.Items, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return instances, nil
 }
 
 func (g gcpComputeClient) GetZones(region, projectID string) ([]string, error) {
@@ -54,3 +72,140 @@ func (g gcpComputeClient) GetNetworks(name, projectID string) (*compute.NetworkL
 	networksListCall := g.service.Networks.List(projectID)
 	return networksListCall.Filter(fmt.Sprintf("name eq %s", name)).Do()
 }
+
+func (g gcpComputeClient) GetProject(projectID string) (*compute.Project, error) {
+	return g.service.Projects.Get(projectID).Do()
+}
+
+// ListDisks returns the disks in the zone from every page of results.
+func (g gcpComputeClient) ListDisks(projectID, zone string) (*compute.DiskList, error) {
+	disks := &compute.DiskList{}
+	err := g.service.Disks.List(projectID, zone).Pages(context.Background(), func(page *compute.DiskList) error {
+		disks.Items = append(disks.Items, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return disks, nil
+}
+
+// ListRegionDisks returns the regional disks from every page of results.
+func (g gcpComputeClient) ListRegionDisks(projectID, region string) (*compute.DiskList, error) {
+	disks := &compute.DiskList{}
+	err := g.service.RegionDisks.List(projectID, region).Pages(context.Background(), func(page *compute.DiskList) error {
+		disks.Items = append(disks.Items, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return disks, nil
+}
+
+// DeleteDisk deletes the disk and waits for the deletion to finish.
+func (g gcpComputeClient) DeleteDisk(projectID, zone, disk string) error {
+	op, err := g.service.Disks.Delete(projectID, zone, disk).Do()
+	if err != nil {
+		return err
+	}
+
+	return waitForOperation(op, func(name string) (*compute.Operation, error) {
+		return g.service.ZoneOperations.Get(projectID, zone, name).Do()
+	})
+}
+
+// DeleteRegionDisk deletes the regional disk and waits for the deletion to
+// finish.
+func (g gcpComputeClient) DeleteRegionDisk(projectID, region, disk string) error {
+	op, err := g.service.RegionDisks.Delete(projectID, region, disk).Do()
+	if err != nil {
+		return err
+	}
+
+	return waitForOperation(op, func(name string) (*compute.Operation, error) {
+		return g.service.RegionOperations.Get(projectID, region, name).Do()
+	})
+}
+
+// ListImages returns the images in the project from every page of results.
+func (g gcpComputeClient) ListImages(projectID string) (*compute.ImageList, error) {
+	images := &compute.ImageList{}
+	err := g.service.Images.List(projectID).Pages(context.Background(), func(page *compute.ImageList) error {
+		images.Items = append(images.Items, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// DeleteImage deletes the image and waits for the deletion to finish.
+func (g gcpComputeClient) DeleteImage(projectID, image string) error {
+	op, err := g.service.Images.Delete(projectID, image).Do()
+	if err != nil {
+		return err
+	}
+
+	return waitForOperation(op, func(name string) (*compute.Operation, error) {
+		return g.service.GlobalOperations.Get(projectID, name).Do()
+	})
+}
+
+// ListRouters returns the routers in the region from every page of results.
+func (g gcpComputeClient) ListRouters(projectID, region string) (*compute.RouterList, error) {
+	routers := &compute.RouterList{}
+	err := g.service.Routers.List(projectID, region).Pages(context.Background(), func(page *compute.RouterList) error {
+		routers.Items = append(routers.Items, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return routers, nil
+}
+
+// DeleteRouter deletes the router and waits for the deletion to finish, so
+// that the network is no longer in use when terraform deletes it.
+func (g gcpComputeClient) DeleteRouter(projectID, region, router string) error {
+	op, err := g.service.Routers.Delete(projectID, region, router).Do()
+	if err != nil {
+		return err
+	}
+
+	return waitForOperation(op, func(name string) (*compute.Operation, error) {
+		return g.service.RegionOperations.Get(projectID, region, name).Do()
+	})
+}
+
+// waitForOperation polls the operation with get until it is done, and returns
+// the error it finished with. Deletes are asynchronous, so a resource is only
+// gone once its operation is done without errors.
+func waitForOperation(op *compute.Operation, get func(name string) (*compute.Operation, error)) error {
+	name := op.Name
+	deadline := time.Now().Add(operationTimeout)
+	for op.Status != "DONE" {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("operation %s did not finish within %s", name, operationTimeout)
+		}
+
+		time.Sleep(operationPollInterval)
+
+		var err error
+		op, err = get(name)
+		if err != nil {
+			return fmt.Errorf("Get operation %s: %s", name, err)
+		}
+	}
+
+	if op.Error != nil && len(op.Error.Errors) > 0 {
+		return errors.New(op.Error.Errors[0].Message)
+	}
+
+	return nil
+}
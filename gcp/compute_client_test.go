@@ -0,0 +1,77 @@
+package gcp_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/gcp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	compute "google.golang.org/api/compute/v1"
+)
+
+var _ = Describe("waitForOperation", func() {
+	BeforeEach(func() {
+		gcp.SetOperationPolling(time.Millisecond, time.Second)
+	})
+
+	AfterEach(func() {
+		gcp.SetOperationPolling(2*time.Second, 10*time.Minute)
+	})
+
+	It("polls the operation until it is done", func() {
+		var names []string
+		statuses := []string{"RUNNING", "DONE"}
+
+		err := gcp.WaitForOperation(&compute.Operation{Name: "some-op", Status: "PENDING"}, func(name string) (*compute.Operation, error) {
+			names = append(names, name)
+			status := statuses[0]
+			statuses = statuses[1:]
+			return &compute.Operation{Name: name, Status: status}, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(names).To(Equal([]string{"some-op", "some-op"}))
+	})
+
+	It("does not poll an operation that is already done", func() {
+		err := gcp.WaitForOperation(&compute.Operation{Name: "some-op", Status: "DONE"}, func(string) (*compute.Operation, error) {
+			Fail("polled a finished operation")
+			return nil, nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("when the operation finishes with an error", func() {
+		It("returns the error", func() {
+			err := gcp.WaitForOperation(&compute.Operation{
+				Name:   "some-op",
+				Status: "DONE",
+				Error: &compute.OperationError{
+					Errors: []*compute.OperationErrorErrors{{Message: "The disk is in use"}},
+				},
+			}, nil)
+			Expect(err).To(MatchError("The disk is in use"))
+		})
+	})
+
+	Context("when the operation cannot be read", func() {
+		It("returns an error", func() {
+			err := gcp.WaitForOperation(&compute.Operation{Name: "some-op", Status: "RUNNING"}, func(string) (*compute.Operation, error) {
+				return nil, errors.New("quota exceeded")
+			})
+			Expect(err).To(MatchError("Get operation some-op: quota exceeded"))
+		})
+	})
+
+	Context("when the operation does not finish in time", func() {
+		It("returns an error", func() {
+			gcp.SetOperationPolling(time.Millisecond, 10*time.Millisecond)
+
+			err := gcp.WaitForOperation(&compute.Operation{Name: "some-op", Status: "RUNNING"}, func(name string) (*compute.Operation, error) {
+				return &compute.Operation{Name: name, Status: "RUNNING"}, nil
+			})
+			Expect(err).To(MatchError("operation some-op did not finish within 10ms"))
+		})
+	})
+})
@@ -1,12 +1,15 @@
 package gcp
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"golang.org/x/oauth2/jwt"
+	compute "google.golang.org/api/compute/v1"
 )
 
-func SetGCPHTTPClient(f func(*jwt.Config) *http.Client) {
+func SetGCPHTTPClient(f func(context.Context, *jwt.Config) *http.Client) {
 	gcpHTTPClient = f
 }
 
@@ -14,10 +17,20 @@ func ResetGCPHTTPClient() {
 	gcpHTTPClient = gcpHTTPClientFunc
 }
 
-func NewClientWithInjectedComputeClient(computeClient ComputeClient, projectID, zone string) Client {
+func NewClientWithInjectedComputeClient(computeClient ComputeClient, projectID, zone, region string) Client {
 	return Client{
 		computeClient: computeClient,
 		projectID:     projectID,
 		zone:          zone,
+		region:        region,
 	}
 }
+
+func SetOperationPolling(interval, timeout time.Duration) {
+	operationPollInterval = interval
+	operationTimeout = timeout
+}
+
+func WaitForOperation(op *compute.Operation, get func(name string) (*compute.Operation, error)) error {
+	return waitForOperation(op, get)
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// EnvDestroyer destroys the envs found by destroy --all-matching. It runs bbl
+// again with the arguments it was started with, less --all-matching, for each
+// state dir. That way each env gets its own credentials, terraform and state
+// store.
+type EnvDestroyer struct {
+	bblPath string
+	args    []string
+}
+
+func NewEnvDestroyer(bblPath string, args []string) EnvDestroyer {
+	return EnvDestroyer{
+		bblPath: bblPath,
+		args:    withoutFlag(args, "all-matching"),
+	}
+}
+
+// DestroyEnv runs the destroy for the env in stateDir, sharing bbl's stdin,
+// stdout and stderr so that it can prompt and report its progress. The
+// --state-dir is given last, so that it overrides the one bbl was started
+// with.
+func (e EnvDestroyer) DestroyEnv(stateDir string) error {
+	args := append(append([]string{}, e.args...), "--state-dir", stateDir)
+
+	command := exec.Command(e.bblPath, args...)
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+
+	err := command.Run()
+	if err != nil {
+		return fmt.Errorf("Run bbl destroy: %s", err)
+	}
+
+	return nil
+}
+
+// withoutFlag removes the flag called name from args, along with its value.
+func withoutFlag(args []string, name string) []string {
+	var remaining []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--"+name || arg == "-"+name:
+			i++
+		case strings.HasPrefix(arg, "--"+name+"=") || strings.HasPrefix(arg, "-"+name+"="):
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+
+	return remaining
+}
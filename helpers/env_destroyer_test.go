@@ -0,0 +1,55 @@
+package helpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnvDestroyer", func() {
+	var (
+		dir     string
+		bblPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "env-destroyer")
+		Expect(err).NotTo(HaveOccurred())
+
+		bblPath = filepath.Join(dir, "bbl")
+		err = ioutil.WriteFile(bblPath, []byte("#!/bin/sh\necho \"$@\" > "+filepath.Join(dir, "args")+"\n"), 0700)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("runs bbl for the state dir without --all-matching", func() {
+		envDestroyer := helpers.NewEnvDestroyer(bblPath, []string{
+			"--state-dir", "/some/root", "destroy", "--all-matching", "eph-", "--skip-bosh", "--all-matching=eph-",
+		})
+
+		err := envDestroyer.DestroyEnv("/some/root/eph-1")
+		Expect(err).NotTo(HaveOccurred())
+
+		args, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(args)).To(Equal("--state-dir /some/root destroy --skip-bosh --state-dir /some/root/eph-1\n"))
+	})
+
+	Context("when the destroy fails", func() {
+		It("returns an error", func() {
+			err := ioutil.WriteFile(bblPath, []byte("#!/bin/sh\nexit 1\n"), 0700)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = helpers.NewEnvDestroyer(bblPath, []string{"destroy"}).DestroyEnv("/some/state-dir")
+			Expect(err).To(MatchError("Run bbl destroy: exit status 1"))
+		})
+	})
+})
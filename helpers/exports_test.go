@@ -1,6 +1,9 @@
 package helpers
 
-import "regexp"
+import (
+	"regexp"
+	"time"
+)
 
 func SetMatchString(f func(string, string) (bool, error)) {
 	matchString = f
@@ -9,3 +12,11 @@ func SetMatchString(f func(string, string) (bool, error)) {
 func ResetMatchString() {
 	matchString = regexp.MatchString
 }
+
+func SetRetryBackoff(d time.Duration) {
+	retryBackoff = d
+}
+
+func ResetRetryBackoff() {
+	retryBackoff = 500 * time.Millisecond
+}
@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+type GitStatus struct{}
+
+func NewGitStatus() GitStatus {
+	return GitStatus{}
+}
+
+// CheckClean returns an error if the named file in dir has uncommitted
+// changes or merge conflicts. It also returns an error when it cannot tell,
+// because git is missing or dir is not in a git work tree.
+func (g GitStatus) CheckClean(dir, file string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("cannot verify git status: git not found")
+	}
+
+	err := exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Run()
+	if err != nil {
+		return fmt.Errorf("cannot verify git status: %s is not in a git work tree", dir)
+	}
+
+	output, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--", file).Output()
+	if err != nil {
+		return fmt.Errorf("Run git status: %s", err)
+	}
+
+	status := strings.TrimSpace(string(output))
+	if status == "" {
+		return nil
+	}
+
+	switch status[:2] {
+	case "DD", "AU", "UD", "UA", "DU", "AA", "UU":
+		return fmt.Errorf("%s has merge conflicts", file)
+	}
+
+	return fmt.Errorf("%s has uncommitted changes", file)
+}
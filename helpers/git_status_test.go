@@ -0,0 +1,107 @@
+package helpers_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitStatus", func() {
+	var (
+		gitStatus helpers.GitStatus
+		dir       string
+	)
+
+	git := func(args ...string) {
+		args = append([]string{"-C", dir, "-c", "user.name=bbl", "-c", "user.email=bbl@example.com"}, args...)
+		output, err := exec.Command("git", args...).CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(output))
+	}
+
+	BeforeEach(func() {
+		gitStatus = helpers.NewGitStatus()
+
+		var err error
+		dir, err = ioutil.TempDir("", "git-status")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("CheckClean", func() {
+		Context("when the directory is not in a git repository", func() {
+			It("returns an error", func() {
+				err := gitStatus.CheckClean(dir, "bbl-state.json")
+				Expect(err).To(MatchError(fmt.Sprintf("cannot verify git status: %s is not in a git work tree", dir)))
+			})
+		})
+
+		Context("when git is not installed", func() {
+			var path string
+
+			BeforeEach(func() {
+				path = os.Getenv("PATH")
+				os.Setenv("PATH", dir)
+			})
+
+			AfterEach(func() {
+				os.Setenv("PATH", path)
+			})
+
+			It("returns an error", func() {
+				err := gitStatus.CheckClean(dir, "bbl-state.json")
+				Expect(err).To(MatchError("cannot verify git status: git not found"))
+			})
+		})
+
+		Context("when the directory is in a git repository", func() {
+			BeforeEach(func() {
+				git("init", "-q")
+				err := ioutil.WriteFile(filepath.Join(dir, "bbl-state.json"), []byte("{}"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+				git("add", "bbl-state.json")
+				git("commit", "-q", "-m", "state")
+			})
+
+			It("returns no error when the file is committed", func() {
+				err := gitStatus.CheckClean(dir, "bbl-state.json")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error when the file has uncommitted changes", func() {
+				err := ioutil.WriteFile(filepath.Join(dir, "bbl-state.json"), []byte(`{"iaas": "gcp"}`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = gitStatus.CheckClean(dir, "bbl-state.json")
+				Expect(err).To(MatchError("bbl-state.json has uncommitted changes"))
+			})
+
+			It("returns an error when the file has merge conflicts", func() {
+				git("checkout", "-q", "-b", "other")
+				err := ioutil.WriteFile(filepath.Join(dir, "bbl-state.json"), []byte(`{"iaas": "aws", "envID": "other"}`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+				git("commit", "-q", "-am", "aws")
+
+				git("checkout", "-q", "-")
+				err = ioutil.WriteFile(filepath.Join(dir, "bbl-state.json"), []byte(`{"iaas": "gcp"}`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+				git("commit", "-q", "-am", "gcp")
+
+				output, err := exec.Command("git", "-C", dir, "-c", "user.name=bbl", "-c", "user.email=bbl@example.com", "merge", "other").CombinedOutput()
+				Expect(err).To(HaveOccurred())
+				Expect(string(output)).To(ContainSubstring("CONFLICT"))
+
+				err = gitStatus.CheckClean(dir, "bbl-state.json")
+				Expect(err).To(MatchError("bbl-state.json has merge conflicts"))
+			})
+		})
+	})
+})
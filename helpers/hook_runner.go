@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+type HookRunner struct{}
+
+func NewHookRunner() HookRunner {
+	return HookRunner{}
+}
+
+// Run runs the executable at path with env added to bbl's environment and
+// returns what it wrote to stdout and stderr, interleaved.
+func (h HookRunner) Run(path string, env []string) (string, error) {
+	command := exec.Command(path)
+	command.Env = append(os.Environ(), env...)
+
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("Run %s: %s", path, err)
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,57 @@
+package helpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HookRunner", func() {
+	var (
+		hookRunner helpers.HookRunner
+		dir        string
+	)
+
+	writeHook := func(script string) string {
+		path := filepath.Join(dir, "hook")
+		err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0700)
+		Expect(err).NotTo(HaveOccurred())
+		return path
+	}
+
+	BeforeEach(func() {
+		hookRunner = helpers.NewHookRunner()
+
+		var err error
+		dir, err = ioutil.TempDir("", "hook-runner")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("runs the hook with the given environment and returns its output", func() {
+		path := writeHook("echo out $SOME_VAR\necho err >&2\n")
+
+		output, err := hookRunner.Run(path, []string{"SOME_VAR=some-value"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(output).To(Equal("out some-value\nerr\n"))
+	})
+
+	Context("when the hook exits non-zero", func() {
+		It("returns its output and an error", func() {
+			path := writeHook("echo draining failed\nexit 3\n")
+
+			output, err := hookRunner.Run(path, nil)
+			Expect(err).To(MatchError("Run " + path + ": exit status 3"))
+
+			Expect(output).To(Equal("draining failed\n"))
+		})
+	})
+})
@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var retryBackoff = 500 * time.Millisecond
+
+// HTTPSettings tunes the http clients used to talk to the IAAS APIs. The zero
+// value keeps the default behaviour of each client.
+type HTTPSettings struct {
+	Timeout      time.Duration
+	MaxRetries   int
+	Interactions *Interactions
+
+	// RootCAs replaces the system trust store, such as when the API calls
+	// go through a proxy that intercepts TLS.
+	RootCAs *x509.CertPool
+}
+
+// LoadCACertFile returns the system trust store with the PEM certificates
+// in path added.
+func LoadCACertFile(path string) (*x509.CertPool, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Read CA certificate file: %s", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(contents) {
+		return nil, fmt.Errorf("No certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// Transport returns the default transport, trusting RootCAs when set.
+func (s HTTPSettings) Transport() http.RoundTripper {
+	if s.RootCAs == nil {
+		return http.DefaultTransport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: s.RootCAs}
+
+	return transport
+}
+
+// Wrap returns a copy of client that applies the timeout and retries failed
+// requests that can safely be sent again: ones with an idempotent method or
+// an Idempotency-Key header, and a body that can be replayed. Clients that
+// retry on their own, such as the aws sdk's, should not be given MaxRetries.
+// With Interactions set, requests are recorded or replayed as well. RootCAs
+// only applies to a client that uses the default transport.
+func (s HTTPSettings) Wrap(client *http.Client) *http.Client {
+	wrapped := *client
+
+	if s.Timeout > 0 {
+		wrapped.Timeout = s.Timeout
+	}
+
+	if s.RootCAs != nil && wrapped.Transport == nil {
+		wrapped.Transport = s.Transport()
+	}
+
+	if s.MaxRetries > 0 {
+		transport := wrapped.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		wrapped.Transport = retryTransport{
+			transport:  transport,
+			maxRetries: s.MaxRetries,
+		}
+	}
+
+	if s.Interactions != nil {
+		transport := wrapped.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		wrapped.Transport = s.Interactions.transport(transport)
+	}
+
+	return &wrapped
+}
+
+type retryTransport struct {
+	transport  http.RoundTripper
+	maxRetries int
+}
+
+func (t retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+
+		response, err := t.transport.RoundTrip(request)
+		if attempt == t.maxRetries || !shouldRetry(request, response, err) {
+			return response, err
+		}
+
+		if response != nil {
+			io.Copy(ioutil.Discard, response.Body)
+			response.Body.Close()
+		}
+
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(retryBackoff * time.Duration(attempt+1)):
+		}
+	}
+}
+
+func shouldRetry(request *http.Request, response *http.Response, err error) bool {
+	if !isIdempotent(request) {
+		return false
+	}
+
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError
+}
+
+// isIdempotent reports whether sending the request twice has the same effect
+// as sending it once, such as a POST to the AWS Query API that creates or
+// deletes something does not.
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return request.Header.Get("Idempotency-Key") != ""
+}
@@ -0,0 +1,202 @@
+package helpers_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPSettings", func() {
+	var (
+		server   *httptest.Server
+		requests int
+		failures int
+	)
+
+	BeforeEach(func() {
+		helpers.SetRetryBackoff(time.Millisecond)
+
+		requests = 0
+		failures = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		helpers.ResetRetryBackoff()
+	})
+
+	Describe("Wrap", func() {
+		It("does not modify the client it wraps", func() {
+			client := &http.Client{}
+
+			wrapped := helpers.HTTPSettings{Timeout: time.Minute, MaxRetries: 2}.Wrap(client)
+
+			Expect(wrapped.Timeout).To(Equal(time.Minute))
+			Expect(client.Timeout).To(Equal(time.Duration(0)))
+			Expect(client.Transport).To(BeNil())
+		})
+
+		It("keeps the client as-is with the zero value", func() {
+			client := &http.Client{Timeout: time.Second}
+
+			wrapped := helpers.HTTPSettings{}.Wrap(client)
+
+			Expect(wrapped).To(Equal(client))
+		})
+
+		Context("when requests fail with a server error", func() {
+			BeforeEach(func() {
+				failures = 2
+			})
+
+			It("retries up to the max retries", func() {
+				client := helpers.HTTPSettings{MaxRetries: 2}.Wrap(&http.Client{})
+
+				response, err := client.Get(server.URL)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+				Expect(requests).To(Equal(3))
+			})
+
+			It("returns the last response when the retries are exhausted", func() {
+				client := helpers.HTTPSettings{MaxRetries: 1}.Wrap(&http.Client{})
+
+				response, err := client.Get(server.URL)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				Expect(requests).To(Equal(2))
+			})
+
+			It("resends the body of requests that can be replayed", func() {
+				client := helpers.HTTPSettings{MaxRetries: 2}.Wrap(&http.Client{})
+
+				request, err := http.NewRequest("PUT", server.URL, strings.NewReader("some-body"))
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := client.Do(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+				Expect(requests).To(Equal(3))
+			})
+
+			It("does not retry requests that are not idempotent", func() {
+				client := helpers.HTTPSettings{MaxRetries: 2}.Wrap(&http.Client{})
+
+				response, err := client.Post(server.URL, "text/plain", strings.NewReader("some-body"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				Expect(requests).To(Equal(1))
+			})
+
+			It("retries a request with an idempotency key", func() {
+				client := helpers.HTTPSettings{MaxRetries: 2}.Wrap(&http.Client{})
+
+				request, err := http.NewRequest("POST", server.URL, strings.NewReader("some-body"))
+				Expect(err).NotTo(HaveOccurred())
+				request.Header.Set("Idempotency-Key", "some-key")
+
+				response, err := client.Do(request)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+				Expect(requests).To(Equal(3))
+			})
+		})
+
+		Context("when requests time out", func() {
+			It("returns an error", func() {
+				slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(100 * time.Millisecond)
+				}))
+				defer slowServer.Close()
+
+				client := helpers.HTTPSettings{Timeout: 10 * time.Millisecond}.Wrap(&http.Client{})
+
+				_, err := client.Get(slowServer.URL)
+				Expect(err).To(MatchError(ContainSubstring("Client.Timeout exceeded")))
+			})
+		})
+	})
+
+	Context("when the IAAS API is behind a proxy with its own CA", func() {
+		var (
+			tlsServer *httptest.Server
+			caPath    string
+		)
+
+		BeforeEach(func() {
+			tlsServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			dir, err := ioutil.TempDir("", "ca-cert-file")
+			Expect(err).NotTo(HaveOccurred())
+			caPath = filepath.Join(dir, "ca.pem")
+
+			caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+			err = ioutil.WriteFile(caPath, caCert, 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			tlsServer.Close()
+			os.RemoveAll(filepath.Dir(caPath))
+		})
+
+		It("trusts the CA certificates in the file", func() {
+			rootCAs, err := helpers.LoadCACertFile(caPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			client := helpers.HTTPSettings{RootCAs: rootCAs}.Wrap(&http.Client{})
+
+			response, err := client.Get(tlsServer.URL)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("rejects the proxy's certificate without them", func() {
+			client := helpers.HTTPSettings{}.Wrap(&http.Client{})
+
+			_, err := client.Get(tlsServer.URL)
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+
+		Context("when the file has no certificates", func() {
+			It("returns an error", func() {
+				err := ioutil.WriteFile(caPath, []byte("not a certificate"), 0600)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = helpers.LoadCACertFile(caPath)
+				Expect(err).To(MatchError("No certificates found in " + caPath))
+			})
+		})
+
+		Context("when the file cannot be read", func() {
+			It("returns an error", func() {
+				_, err := helpers.LoadCACertFile(filepath.Join(filepath.Dir(caPath), "missing.pem"))
+				Expect(err).To(MatchError(ContainSubstring("Read CA certificate file: ")))
+			})
+		})
+	})
+})
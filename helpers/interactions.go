@@ -0,0 +1,167 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Interaction is one IAAS API request and the response it got.
+type Interaction struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	RequestBody  string      `json:"request_body,omitempty"`
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	ResponseBody string      `json:"response_body"`
+}
+
+// Interactions records the IAAS API calls made through the http clients it
+// wraps, or answers them from an earlier recording without reaching the IAAS.
+type Interactions struct {
+	path         string
+	replay       bool
+	mutex        sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// RecordInteractions writes every request and response to path as they
+// happen.
+func RecordInteractions(path string) *Interactions {
+	return &Interactions{path: path}
+}
+
+// ReplayInteractions answers requests from the recording at path.
+func ReplayInteractions(path string) (*Interactions, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Read interactions: %s", err)
+	}
+
+	var interactions []Interaction
+	err = json.Unmarshal(contents, &interactions)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshal interactions: %s", err)
+	}
+
+	return &Interactions{
+		path:         path,
+		replay:       true,
+		interactions: interactions,
+		replayed:     make([]bool, len(interactions)),
+	}, nil
+}
+
+func (i *Interactions) transport(transport http.RoundTripper) http.RoundTripper {
+	return interactionsTransport{
+		transport:    transport,
+		interactions: i,
+	}
+}
+
+func (i *Interactions) record(interaction Interaction) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.interactions = append(i.interactions, interaction)
+
+	contents, err := json.MarshalIndent(i.interactions, "", "  ")
+	if err != nil {
+		return err // not tested
+	}
+
+	err = ioutil.WriteFile(i.path, contents, 0600)
+	if err != nil {
+		return fmt.Errorf("Write interactions: %s", err)
+	}
+
+	return nil
+}
+
+// find returns the first interaction for the request that has not been
+// replayed yet, so repeated calls get their responses in recorded order.
+func (i *Interactions) find(method, url, body string) (Interaction, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for index, interaction := range i.interactions {
+		if i.replayed[index] {
+			continue
+		}
+
+		if interaction.Method == method && interaction.URL == url && interaction.RequestBody == body {
+			i.replayed[index] = true
+			return interaction, true
+		}
+	}
+
+	return Interaction{}, false
+}
+
+type interactionsTransport struct {
+	transport    http.RoundTripper
+	interactions *Interactions
+}
+
+func (t interactionsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if request.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		request.Body.Close()
+		request.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	url := request.URL.String()
+
+	if t.interactions.replay {
+		interaction, ok := t.interactions.find(request.Method, url, string(requestBody))
+		if !ok {
+			return nil, fmt.Errorf("No recorded interaction for %s %s", request.Method, url)
+		}
+
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+			StatusCode: interaction.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     interaction.Header,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(interaction.ResponseBody)),
+			Request:    request,
+		}, nil
+	}
+
+	response, err := t.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	err = t.interactions.record(Interaction{
+		Method:       request.Method,
+		URL:          url,
+		RequestBody:  string(requestBody),
+		StatusCode:   response.StatusCode,
+		Header:       response.Header,
+		ResponseBody: string(responseBody),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
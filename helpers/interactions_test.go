@@ -0,0 +1,89 @@
+package helpers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Interactions", func() {
+	var (
+		server   *httptest.Server
+		requests int
+		path     string
+	)
+
+	BeforeEach(func() {
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("hello " + string(body)))
+		}))
+
+		dir, err := ioutil.TempDir("", "interactions")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "interactions.json")
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(filepath.Dir(path))
+	})
+
+	It("replays the responses it recorded without reaching the server", func() {
+		client := helpers.HTTPSettings{Interactions: helpers.RecordInteractions(path)}.Wrap(&http.Client{})
+
+		response, err := client.Post(server.URL+"/some-path", "text/plain", strings.NewReader("world"))
+		Expect(err).NotTo(HaveOccurred())
+		body, err := ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal("hello world"))
+
+		server.Close()
+
+		interactions, err := helpers.ReplayInteractions(path)
+		Expect(err).NotTo(HaveOccurred())
+		client = helpers.HTTPSettings{Interactions: interactions}.Wrap(&http.Client{})
+
+		response, err = client.Post(server.URL+"/some-path", "text/plain", strings.NewReader("world"))
+		Expect(err).NotTo(HaveOccurred())
+		body, err = ioutil.ReadAll(response.Body)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(response.StatusCode).To(Equal(http.StatusOK))
+		Expect(response.Header.Get("Content-Type")).To(Equal("text/plain"))
+		Expect(string(body)).To(Equal("hello world"))
+		Expect(requests).To(Equal(1))
+	})
+
+	Context("when a request was not recorded", func() {
+		It("returns an error", func() {
+			err := ioutil.WriteFile(path, []byte("[]"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			interactions, err := helpers.ReplayInteractions(path)
+			Expect(err).NotTo(HaveOccurred())
+			client := helpers.HTTPSettings{Interactions: interactions}.Wrap(&http.Client{})
+
+			_, err = client.Get(server.URL + "/some-path")
+			Expect(err).To(MatchError(ContainSubstring("No recorded interaction for GET " + server.URL + "/some-path")))
+			Expect(requests).To(Equal(0))
+		})
+	})
+
+	Context("when the recording cannot be read", func() {
+		It("returns an error", func() {
+			_, err := helpers.ReplayInteractions(path)
+			Expect(err).To(MatchError(ContainSubstring("Read interactions: ")))
+		})
+	})
+})
@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// OutputScrubber redacts secrets from the output of the tools bbl runs, such
+// as terraform and the bosh cli, on its way to the terminal. Its writers pass
+// the output on as it is until Scrub is called.
+type OutputScrubber struct {
+	mutex   sync.Mutex
+	scrub   func(string) string
+	writers []*scrubbingWriter
+}
+
+func NewOutputScrubber() *OutputScrubber {
+	return &OutputScrubber{}
+}
+
+// Writer returns a writer to out that redacts the output it is given once
+// Scrub is called.
+func (s *OutputScrubber) Writer(out io.Writer) io.Writer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	writer := &scrubbingWriter{scrubber: s, out: out}
+	s.writers = append(s.writers, writer)
+	return writer
+}
+
+// Scrub makes the writers pass their output through scrub. They hold back a
+// line until it is complete, so that a secret split across two writes is
+// still redacted.
+func (s *OutputScrubber) Scrub(scrub func(string) string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scrub = scrub
+}
+
+// Flush writes out the incomplete lines the writers held back.
+func (s *OutputScrubber) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, writer := range s.writers {
+		if err := writer.flush(len(writer.pending)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scrubbingWriter struct {
+	scrubber *OutputScrubber
+	out      io.Writer
+	pending  []byte
+}
+
+func (w *scrubbingWriter) Write(p []byte) (int, error) {
+	w.scrubber.mutex.Lock()
+	defer w.scrubber.mutex.Unlock()
+
+	if w.scrubber.scrub == nil {
+		return w.out.Write(p)
+	}
+
+	w.pending = append(w.pending, p...)
+	if err := w.flush(bytes.LastIndexByte(w.pending, '\n') + 1); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush writes the first n bytes held back, redacted. It is called with the
+// scrubber locked.
+func (w *scrubbingWriter) flush(n int) error {
+	if n == 0 {
+		return nil
+	}
+
+	output := string(w.pending[:n])
+	if w.scrubber.scrub != nil {
+		output = w.scrubber.scrub(output)
+	}
+	w.pending = append([]byte{}, w.pending[n:]...)
+
+	_, err := io.WriteString(w.out, output)
+	return err
+}
@@ -0,0 +1,72 @@
+package helpers_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OutputScrubber", func() {
+	var (
+		out      *bytes.Buffer
+		scrubber *helpers.OutputScrubber
+		redact   func(string) string
+	)
+
+	BeforeEach(func() {
+		out = bytes.NewBuffer([]byte{})
+		scrubber = helpers.NewOutputScrubber()
+		redact = strings.NewReplacer("some-secret", "<redacted>").Replace
+	})
+
+	It("passes the output on as it is until Scrub is called", func() {
+		_, err := scrubber.Writer(out).Write([]byte("some-secret"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out.String()).To(Equal("some-secret"))
+	})
+
+	It("redacts the output once Scrub is called", func() {
+		scrubber.Scrub(redact)
+
+		n, err := scrubber.Writer(out).Write([]byte("password: some-secret\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(22))
+
+		Expect(out.String()).To(Equal("password: <redacted>\n"))
+	})
+
+	It("redacts a secret split across two writes", func() {
+		scrubber.Scrub(redact)
+		writer := scrubber.Writer(out)
+
+		_, err := writer.Write([]byte("password: some-"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(BeEmpty())
+
+		_, err = writer.Write([]byte("secret\nnext "))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.String()).To(Equal("password: <redacted>\n"))
+	})
+
+	Describe("Flush", func() {
+		It("writes out the incomplete lines, redacted", func() {
+			scrubber.Scrub(redact)
+			other := bytes.NewBuffer([]byte{})
+
+			_, err := scrubber.Writer(out).Write([]byte("password: some-secret"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = scrubber.Writer(other).Write([]byte("no newline"))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = scrubber.Flush()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(out.String()).To(Equal("password: <redacted>"))
+			Expect(other.String()).To(Equal("no newline"))
+		})
+	})
+})
@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	leftoversResource = regexp.MustCompile(`^\[(.+: .+)\]$`)
+	ansiEscape        = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+type filteredLister interface {
+	List(filter string)
+}
+
+// LeftoversRecorder is a logger for the leftovers libraries that records
+// what they list instead of printing it, and never agrees to delete anything.
+type LeftoversRecorder struct {
+	resources []string
+	errors    []string
+}
+
+func NewLeftoversRecorder() *LeftoversRecorder {
+	return &LeftoversRecorder{}
+}
+
+func (r *LeftoversRecorder) Printf(message string, a ...interface{}) {}
+
+func (r *LeftoversRecorder) Println(message string) {
+	message = ansiEscape.ReplaceAllString(message, "")
+
+	if match := leftoversResource.FindStringSubmatch(message); match != nil {
+		r.resources = append(r.resources, match[1])
+		return
+	}
+
+	r.errors = append(r.errors, message)
+}
+
+func (r *LeftoversRecorder) PromptWithDetails(resourceType, resourceName string) bool {
+	return false
+}
+
+func (r *LeftoversRecorder) NoConfirm() {}
+
+// ResidualLister returns what a leftovers List prints, which is every
+// resource of a supported type with the filter in its name or tags.
+type ResidualLister struct {
+	lister   filteredLister
+	recorder *LeftoversRecorder
+}
+
+// NewResidualLister takes a leftovers library created with recorder as its
+// logger.
+func NewResidualLister(lister filteredLister, recorder *LeftoversRecorder) ResidualLister {
+	return ResidualLister{
+		lister:   lister,
+		recorder: recorder,
+	}
+}
+
+// ListResidual returns the resources as "type: name". The error holds the
+// resource types that could not be listed.
+func (r ResidualLister) ListResidual(filter string) ([]string, error) {
+	r.recorder.resources = nil
+	r.recorder.errors = nil
+
+	r.lister.List(filter)
+
+	if len(r.recorder.errors) > 0 {
+		return r.recorder.resources, errors.New(strings.Join(r.recorder.errors, ", "))
+	}
+
+	return r.recorder.resources, nil
+}
@@ -0,0 +1,78 @@
+package helpers_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeLeftovers struct {
+	logger  *helpers.LeftoversRecorder
+	filter  string
+	printed []string
+}
+
+func (f *fakeLeftovers) List(filter string) {
+	f.filter = filter
+	f.logger.NoConfirm()
+	for _, line := range f.printed {
+		f.logger.Println(line)
+	}
+}
+
+var _ = Describe("ResidualLister", func() {
+	var (
+		recorder  *helpers.LeftoversRecorder
+		leftovers *fakeLeftovers
+		lister    helpers.ResidualLister
+	)
+
+	BeforeEach(func() {
+		recorder = helpers.NewLeftoversRecorder()
+		leftovers = &fakeLeftovers{logger: recorder}
+		lister = helpers.NewResidualLister(leftovers, recorder)
+	})
+
+	It("returns the resources the leftovers library lists", func() {
+		leftovers.printed = []string{
+			"[EC2 Volume: some-env-disk]",
+			"[S3 Bucket: some-env-bucket]",
+		}
+
+		resources, err := lister.ListResidual("some-env")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(leftovers.filter).To(Equal("some-env"))
+		Expect(resources).To(Equal([]string{"EC2 Volume: some-env-disk", "S3 Bucket: some-env-bucket"}))
+	})
+
+	It("never agrees to delete anything", func() {
+		Expect(recorder.PromptWithDetails("EC2 Volume", "some-env-disk")).To(BeFalse())
+	})
+
+	It("forgets the resources of a previous list", func() {
+		leftovers.printed = []string{"[EC2 Volume: some-env-disk]"}
+		_, err := lister.ListResidual("some-env")
+		Expect(err).NotTo(HaveOccurred())
+
+		leftovers.printed = nil
+		resources, err := lister.ListResidual("some-env")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resources).To(BeEmpty())
+	})
+
+	Context("when a resource type cannot be listed", func() {
+		It("returns the resources it found and an error", func() {
+			leftovers.printed = []string{
+				"\x1b[33mList S3 Buckets: access denied\x1b[0m",
+				"[EC2 Volume: some-env-disk]",
+			}
+
+			resources, err := lister.ListResidual("some-env")
+			Expect(err).To(MatchError("List S3 Buckets: access denied"))
+
+			Expect(resources).To(Equal([]string{"EC2 Volume: some-env-disk"}))
+		})
+	})
+})
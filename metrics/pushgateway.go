@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const destroyJob = "bbl_destroy"
+
+type Destroy struct {
+	EnvID            string
+	IAAS             string
+	Duration         time.Duration
+	Success          bool
+	ResourcesDeleted int
+}
+
+type Pushgateway struct {
+	httpClient *http.Client
+}
+
+func NewPushgateway(httpClient *http.Client) Pushgateway {
+	return Pushgateway{
+		httpClient: httpClient,
+	}
+}
+
+// PushDestroy replaces the metrics of the env's bbl_destroy job on the
+// Pushgateway with the outcome of a single destroy run. The env id and iaas
+// are the grouping key, so that destroys of different envs do not overwrite
+// each other's metrics.
+func (p Pushgateway) PushDestroy(gatewayURL string, destroy Destroy) error {
+	success := 0
+	if destroy.Success {
+		success = 1
+	}
+
+	var body bytes.Buffer
+	writeSample(&body, "destroy_duration_seconds", "gauge", fmt.Sprintf("%g", destroy.Duration.Seconds()))
+	writeSample(&body, "destroy_success", "gauge", fmt.Sprintf("%d", success))
+	writeSample(&body, "resources_deleted_total", "counter", fmt.Sprintf("%d", destroy.ResourcesDeleted))
+
+	pushURL := fmt.Sprintf("%s/metrics/job/%s%s%s", strings.TrimSuffix(gatewayURL, "/"), destroyJob,
+		groupingLabel("env_id", destroy.EnvID), groupingLabel("iaas", destroy.IAAS))
+	request, err := http.NewRequest("PUT", pushURL, &body)
+	if err != nil {
+		return fmt.Errorf("Create pushgateway request: %s", err)
+	}
+	request.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	response, err := p.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("Push metrics: %s", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	return nil
+}
+
+func writeSample(buf *bytes.Buffer, name, metricType, value string) {
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, metricType)
+	fmt.Fprintf(buf, "%s %s\n", name, value)
+}
+
+// groupingLabel returns a label of the grouping key as a url path segment.
+// The Pushgateway takes an empty value only in its base64 form.
+func groupingLabel(name, value string) string {
+	if value == "" {
+		return fmt.Sprintf("/%s@base64/=", name)
+	}
+	return fmt.Sprintf("/%s/%s", name, url.PathEscape(value))
+}
@@ -0,0 +1,96 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pushgateway", func() {
+	var (
+		server      *httptest.Server
+		status      int
+		method      string
+		path        string
+		contentType string
+		body        string
+
+		pushgateway metrics.Pushgateway
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			path = r.URL.Path
+			contentType = r.Header.Get("Content-Type")
+			contents, _ := ioutil.ReadAll(r.Body)
+			body = string(contents)
+			w.WriteHeader(status)
+		}))
+
+		pushgateway = metrics.NewPushgateway(http.DefaultClient)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("PushDestroy", func() {
+		It("puts the destroy metrics in the text exposition format", func() {
+			err := pushgateway.PushDestroy(server.URL+"/", metrics.Destroy{
+				EnvID:            "some-env-id",
+				IAAS:             "gcp",
+				Duration:         90 * time.Second,
+				Success:          true,
+				ResourcesDeleted: 12,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(method).To(Equal("PUT"))
+			Expect(path).To(Equal("/metrics/job/bbl_destroy/env_id/some-env-id/iaas/gcp"))
+			Expect(contentType).To(Equal("text/plain; version=0.0.4"))
+			Expect(body).To(Equal(`# TYPE destroy_duration_seconds gauge
+destroy_duration_seconds 90
+# TYPE destroy_success gauge
+destroy_success 1
+# TYPE resources_deleted_total counter
+resources_deleted_total 12
+`))
+		})
+
+		It("reports a failed destroy as zero", func() {
+			err := pushgateway.PushDestroy(server.URL, metrics.Destroy{EnvID: "some-env-id", IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(body).To(ContainSubstring("destroy_success 0"))
+		})
+
+		It("groups an env without an id under an empty env_id", func() {
+			err := pushgateway.PushDestroy(server.URL, metrics.Destroy{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path).To(Equal("/metrics/job/bbl_destroy/env_id@base64/=/iaas/aws"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the pushgateway rejects the metrics", func() {
+				status = http.StatusBadRequest
+
+				err := pushgateway.PushDestroy(server.URL, metrics.Destroy{IAAS: "aws"})
+				Expect(err).To(MatchError("unexpected http response 400 Bad Request"))
+			})
+
+			It("returns an error when the pushgateway cannot be reached", func() {
+				err := pushgateway.PushDestroy("%%%", metrics.Destroy{})
+				Expect(err).To(MatchError(ContainSubstring("Create pushgateway request")))
+			})
+		})
+	})
+})
@@ -27,6 +27,9 @@ func NewStateBootstrap(bootstrapLogger bootstrapLogger, bblVersion string) State
 
 func (b StateBootstrap) GetState(dir string) (State, error) {
 	_, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return State{}, fmt.Errorf("state directory %q does not exist", dir)
+	}
 	if err != nil {
 		return State{}, err
 	}
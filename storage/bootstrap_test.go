@@ -177,7 +177,7 @@ var _ = Describe("StateBootstrap", func() {
 			Context("when the directory does not exist", func() {
 				It("returns an error", func() {
 					_, err := bootstrap.GetState("some-fake-directory")
-					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
+					Expect(err).To(MatchError(`state directory "some-fake-directory" does not exist`))
 				})
 			})
 
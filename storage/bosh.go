@@ -13,6 +13,19 @@ type BOSH struct {
 	Variables              string                 `json:"variables,omitempty"`
 	State                  map[string]interface{} `json:"state,omitempty"`
 	Manifest               string                 `json:"manifest,omitempty"`
+	Preemptible            bool                   `json:"preemptible,omitempty"`
+
+	// DirectorVMType is the vm type of a director sized with ops files.
+	// It is passed to create-env and delete-env as ((director_vm_type)) so
+	// that the director is deleted with the manifest it was created with.
+	// When empty, the default vm type of the cpi is assumed.
+	DirectorVMType string `json:"directorVMType,omitempty"`
+
+	// ExternalDatabase and ExternalBlobstore name the database and
+	// blobstore the director uses when they were not created by bbl, such
+	// as a shared RDS instance or GCS bucket. Destroy leaves them alone.
+	ExternalDatabase  string `json:"externalDatabase,omitempty"`
+	ExternalBlobstore string `json:"externalBlobstore,omitempty"`
 }
 
 func (b BOSH) IsEmpty() bool {
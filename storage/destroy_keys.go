@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DESTROY_KEYS_FILE lives next to bbl-state.json but is not managed by bbl,
+// so it survives the state directory being cleaned up after a destroy.
+const DESTROY_KEYS_FILE = ".bbl-destroy-keys.json"
+
+// DestroyKeys records the idempotency keys of the destroys that completed in
+// a state directory.
+type DestroyKeys struct {
+	dir string
+	fs  fs
+}
+
+func NewDestroyKeys(dir string, fs fs) DestroyKeys {
+	return DestroyKeys{
+		dir: dir,
+		fs:  fs,
+	}
+}
+
+func (k DestroyKeys) Completed(key string) (bool, error) {
+	keys, err := k.read()
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := keys[key]
+	return ok, nil
+}
+
+func (k DestroyKeys) Complete(key string) error {
+	keys, err := k.read()
+	if err != nil {
+		return err
+	}
+
+	keys[key] = time.Now().UTC()
+
+	contents, err := json.MarshalIndent(keys, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+
+	err = k.fs.WriteFile(filepath.Join(k.dir, DESTROY_KEYS_FILE), contents, os.FileMode(0644))
+	if err != nil {
+		return fmt.Errorf("Write destroy keys: %s", err)
+	}
+
+	return nil
+}
+
+func (k DestroyKeys) read() (map[string]time.Time, error) {
+	keys := map[string]time.Time{}
+
+	contents, err := k.fs.ReadFile(filepath.Join(k.dir, DESTROY_KEYS_FILE))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, fmt.Errorf("Read destroy keys: %s", err)
+	}
+
+	err = json.Unmarshal(contents, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("Unmarshal destroy keys: %s", err)
+	}
+
+	return keys, nil
+}
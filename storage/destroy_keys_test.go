@@ -0,0 +1,91 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DestroyKeys", func() {
+	var (
+		fileIO      *fakes.FileIO
+		destroyKeys storage.DestroyKeys
+	)
+
+	BeforeEach(func() {
+		fileIO = &fakes.FileIO{}
+		fileIO.ReadFileCall.Returns.Error = os.ErrNotExist
+		destroyKeys = storage.NewDestroyKeys("some-dir", fileIO)
+	})
+
+	Describe("Completed", func() {
+		It("reports keys that were completed", func() {
+			fileIO.ReadFileCall.Returns.Error = nil
+			fileIO.ReadFileCall.Returns.Contents = []byte(`{"some-key": "2018-01-02T03:04:05Z"}`)
+
+			completed, err := destroyKeys.Completed("some-key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completed).To(BeTrue())
+
+			completed, err = destroyKeys.Completed("other-key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completed).To(BeFalse())
+
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal(filepath.Join("some-dir", ".bbl-destroy-keys.json")))
+		})
+
+		It("reports no keys when nothing was recorded yet", func() {
+			completed, err := destroyKeys.Completed("some-key")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completed).To(BeFalse())
+		})
+
+		Context("when the file cannot be read", func() {
+			It("returns an error", func() {
+				fileIO.ReadFileCall.Returns.Error = errors.New("permission denied")
+
+				_, err := destroyKeys.Completed("some-key")
+				Expect(err).To(MatchError("Read destroy keys: permission denied"))
+			})
+		})
+
+		Context("when the file is not valid json", func() {
+			It("returns an error", func() {
+				fileIO.ReadFileCall.Returns.Error = nil
+				fileIO.ReadFileCall.Returns.Contents = []byte("%%%")
+
+				_, err := destroyKeys.Completed("some-key")
+				Expect(err).To(MatchError(ContainSubstring("Unmarshal destroy keys: ")))
+			})
+		})
+	})
+
+	Describe("Complete", func() {
+		It("records the key alongside the ones already completed", func() {
+			fileIO.ReadFileCall.Returns.Error = nil
+			fileIO.ReadFileCall.Returns.Contents = []byte(`{"some-key": "2018-01-02T03:04:05Z"}`)
+
+			err := destroyKeys.Complete("other-key")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.WriteFileCall.Receives).To(HaveLen(1))
+			Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join("some-dir", ".bbl-destroy-keys.json")))
+			Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(ContainSubstring(`"some-key": "2018-01-02T03:04:05Z"`))
+			Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(ContainSubstring(`"other-key": `))
+		})
+
+		Context("when the file cannot be written", func() {
+			It("returns an error", func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("disk full")}}
+
+				err := destroyKeys.Complete("some-key")
+				Expect(err).To(MatchError("Write destroy keys: disk full"))
+			})
+		})
+	})
+})
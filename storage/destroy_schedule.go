@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DESTROY_SCHEDULE_FILE is where a scheduled destroy is recorded in the state
+// directory unless another location is given.
+const DESTROY_SCHEDULE_FILE = ".bbl-destroy-schedule.json"
+
+// DestroySchedule records when a destroy was first requested, so that it
+// only runs once a cool-off period has passed.
+type DestroySchedule struct {
+	fs fs
+}
+
+type destroyIntent struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+func NewDestroySchedule(fs fs) DestroySchedule {
+	return DestroySchedule{
+		fs: fs,
+	}
+}
+
+// Scheduled returns when the destroy recorded at path was scheduled, and
+// false if none is.
+func (s DestroySchedule) Scheduled(path string) (time.Time, bool, error) {
+	contents, err := s.fs.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("Read destroy schedule: %s", err)
+	}
+
+	var intent destroyIntent
+	err = json.Unmarshal(contents, &intent)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("Unmarshal destroy schedule: %s", err)
+	}
+
+	return intent.ScheduledAt, true, nil
+}
+
+func (s DestroySchedule) Schedule(path string, at time.Time) error {
+	contents, err := json.MarshalIndent(destroyIntent{ScheduledAt: at.UTC()}, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+
+	err = s.fs.WriteFile(path, contents, os.FileMode(0644))
+	if err != nil {
+		return fmt.Errorf("Write destroy schedule: %s", err)
+	}
+
+	return nil
+}
+
+func (s DestroySchedule) Cancel(path string) error {
+	err := s.fs.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Remove destroy schedule: %s", err)
+	}
+
+	return nil
+}
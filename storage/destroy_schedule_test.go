@@ -0,0 +1,100 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DestroySchedule", func() {
+	var (
+		fileIO   *fakes.FileIO
+		schedule storage.DestroySchedule
+	)
+
+	BeforeEach(func() {
+		fileIO = &fakes.FileIO{}
+		schedule = storage.NewDestroySchedule(fileIO)
+	})
+
+	Describe("Scheduled", func() {
+		It("returns when the destroy was scheduled", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte(`{"scheduled_at": "2018-01-02T03:04:05Z"}`)
+
+			at, ok, err := schedule.Scheduled("some-path")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(at).To(Equal(time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal("some-path"))
+		})
+
+		It("reports when no destroy is scheduled", func() {
+			fileIO.ReadFileCall.Returns.Error = os.ErrNotExist
+
+			_, ok, err := schedule.Scheduled("some-path")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+
+		Context("when the schedule cannot be read", func() {
+			It("returns an error", func() {
+				fileIO.ReadFileCall.Returns.Error = errors.New("permission denied")
+
+				_, _, err := schedule.Scheduled("some-path")
+				Expect(err).To(MatchError("Read destroy schedule: permission denied"))
+			})
+		})
+
+		Context("when the schedule is not valid json", func() {
+			It("returns an error", func() {
+				fileIO.ReadFileCall.Returns.Contents = []byte("%%%")
+
+				_, _, err := schedule.Scheduled("some-path")
+				Expect(err).To(MatchError(ContainSubstring("Unmarshal destroy schedule: ")))
+			})
+		})
+	})
+
+	Describe("Schedule", func() {
+		It("records when the destroy was scheduled", func() {
+			err := schedule.Schedule("some-path", time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal("some-path"))
+			Expect(fileIO.WriteFileCall.Receives[0].Contents).To(MatchJSON(`{"scheduled_at": "2018-01-02T03:04:05Z"}`))
+		})
+
+		Context("when the schedule cannot be written", func() {
+			It("returns an error", func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("disk full")}}
+
+				err := schedule.Schedule("some-path", time.Now())
+				Expect(err).To(MatchError("Write destroy schedule: disk full"))
+			})
+		})
+	})
+
+	Describe("Cancel", func() {
+		It("removes the schedule", func() {
+			err := schedule.Cancel("some-path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.RemoveCall.Receives).To(Equal([]fakes.RemoveReceive{{Name: "some-path"}}))
+		})
+
+		Context("when the schedule cannot be removed", func() {
+			It("returns an error", func() {
+				fileIO.RemoveCall.Returns = []fakes.RemoveReturn{{Error: errors.New("permission denied")}}
+
+				err := schedule.Cancel("some-path")
+				Expect(err).To(MatchError("Remove destroy schedule: permission denied"))
+			})
+		})
+	})
+})
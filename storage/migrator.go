@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"reflect"
@@ -139,7 +141,7 @@ func (m Migrator) MigrateTerraformTemplate(terraformDir string) error {
 
 func (m Migrator) migrateStateFile(state map[string]interface{}, deployment, varsDir string) error {
 	if len(state) > 0 {
-		stateJSON, err := json.Marshal(state)
+		stateJSON, err := marshalDeploymentState(state)
 		if err != nil {
 			return fmt.Errorf("marshalling %s state: %s", deployment, err)
 		}
@@ -151,6 +153,28 @@ func (m Migrator) migrateStateFile(state map[string]interface{}, deployment, var
 	return nil
 }
 
+// Some tooling stores the create-env state base64-encoded as
+// {"encoding": "base64", "data": "..."}. It is decoded so that
+// the bosh cli receives the plain state file.
+func marshalDeploymentState(state map[string]interface{}) ([]byte, error) {
+	if encoding, ok := state["encoding"].(string); ok && encoding == "base64" {
+		data, _ := state["data"].(string)
+
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding base64 state: %s", err)
+		}
+
+		if !json.Valid(decoded) {
+			return nil, errors.New("decoded base64 state is not valid json")
+		}
+
+		return decoded, nil
+	}
+
+	return json.Marshal(state)
+}
+
 func (m Migrator) MigrateDirectorState(state State, varsDir string) (State, error) {
 	err := m.migrateStateFile(state.BOSH.State, "bosh", varsDir)
 	if err != nil {
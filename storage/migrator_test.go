@@ -1,6 +1,7 @@
 package storage_test
 
 import (
+	"encoding/base64"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -409,6 +410,46 @@ var _ = Describe("Migrator", func() {
 				})
 			})
 		})
+
+		Context("when the BOSH state is base64-encoded", func() {
+			BeforeEach(func() {
+				incomingState = storage.State{
+					EnvID: "some-env-id",
+					BOSH: storage.BOSH{
+						State: map[string]interface{}{
+							"encoding": "base64",
+							"data":     base64.StdEncoding.EncodeToString([]byte(`{"some-bosh-key": "some-bosh-value"}`)),
+						},
+					},
+				}
+			})
+
+			It("writes the decoded BOSH state to the bosh-state.json vars file", func() {
+				state, err := migrator.MigrateDirectorState(incomingState, varsDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fileIO.WriteFileCall.Receives[0].Contents).To(MatchJSON(`{"some-bosh-key": "some-bosh-value"}`))
+				Expect(state.BOSH.State).To(BeNil())
+			})
+
+			Context("when the data is not valid base64", func() {
+				It("returns an error", func() {
+					incomingState.BOSH.State["data"] = "%%%"
+
+					_, err := migrator.MigrateDirectorState(incomingState, varsDir)
+					Expect(err).To(MatchError(ContainSubstring("marshalling bosh state: decoding base64 state: ")))
+				})
+			})
+
+			Context("when the decoded data is not json", func() {
+				It("returns an error", func() {
+					incomingState.BOSH.State["data"] = base64.StdEncoding.EncodeToString([]byte("not json"))
+
+					_, err := migrator.MigrateDirectorState(incomingState, varsDir)
+					Expect(err).To(MatchError("marshalling bosh state: decoded base64 state is not valid json"))
+				})
+			})
+		})
 	})
 
 	Describe("MigrateTerraformState", func() {
@@ -430,6 +471,18 @@ var _ = Describe("Migrator", func() {
 				Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join(varsDir, "terraform.tfstate")))
 			})
 
+			Context("when the TFState was changed outside of bbl", func() {
+				It("writes it as it is, whatever its serial", func() {
+					tfState := `{"version": 3, "terraform_version": "0.10.7", "serial": 4096, "lineage": "some-lineage", "modules": []}`
+					incomingState.TFState = tfState
+
+					_, err := migrator.MigrateTerraformState(incomingState, varsDir)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal(tfState))
+				})
+			})
+
 			Context("when the tfstate file cannot be written", func() {
 				BeforeEach(func() {
 					fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("cherimoya")}}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// recoveryBundleFiles are the files in the state directory, besides the
+// state itself, needed to stand an environment back up.
+var recoveryBundleFiles = []string{
+	"terraform/bbl-template.tf",
+	"vars/bbl.tfvars",
+	"vars/director-vars-file.yml",
+	"vars/director-vars-store.yml",
+	"vars/jumpbox-vars-file.yml",
+	"vars/jumpbox-vars-store.yml",
+}
+
+// RecoveryBundle packs what is needed to recreate an environment into a
+// single gzipped tar before it is destroyed.
+type RecoveryBundle struct {
+	dir string
+	fs  fs
+}
+
+func NewRecoveryBundle(dir string, fs fs) RecoveryBundle {
+	return RecoveryBundle{
+		dir: dir,
+		fs:  fs,
+	}
+}
+
+// Write packs the state, the terraform outputs and the terraform template
+// and director credentials from the state directory into path. With a key,
+// the bundle is encrypted with AES-GCM under the SHA-256 of the key.
+func (r RecoveryBundle) Write(path string, state State, outputs map[string]interface{}, key []byte) error {
+	files := map[string][]byte{}
+
+	stateContents, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err // not tested
+	}
+	files[STATE_FILE] = stateContents
+
+	outputsContents, err := json.MarshalIndent(outputs, "", "\t")
+	if err != nil {
+		return fmt.Errorf("Marshal terraform outputs: %s", err)
+	}
+	files["terraform-outputs.json"] = outputsContents
+
+	for _, name := range recoveryBundleFiles {
+		contents, err := r.fs.ReadFile(filepath.Join(r.dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("Read %s: %s", name, err)
+		}
+		files[name] = contents
+	}
+
+	bundle, err := archive(files)
+	if err != nil {
+		return err
+	}
+
+	if len(key) > 0 {
+		bundle, err = encrypt(bundle, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = r.fs.WriteFile(path, bundle, os.FileMode(0600))
+	if err != nil {
+		return fmt.Errorf("Write recovery bundle: %s", err)
+	}
+
+	return nil
+}
+
+func archive(files map[string][]byte) ([]byte, error) {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, name := range names {
+		err := tarWriter.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0600,
+			Size: int64(len(files[name])),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Archive %s: %s", name, err) // not tested
+		}
+
+		_, err = tarWriter.Write(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("Archive %s: %s", name, err) // not tested
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err // not tested
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err // not tested
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// encrypt prefixes the sealed contents with the random nonce.
+func encrypt(contents, key []byte) ([]byte, error) {
+	sum := sha256.Sum256(key)
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("Generate nonce: %s", err) // not tested
+	}
+
+	return gcm.Seal(nonce, nonce, contents, nil), nil
+}
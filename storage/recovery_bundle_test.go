@@ -0,0 +1,117 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecoveryBundle", func() {
+	var (
+		fs             *afero.Afero
+		recoveryBundle storage.RecoveryBundle
+		state          storage.State
+		outputs        map[string]interface{}
+	)
+
+	unpack := func(contents []byte) map[string]string {
+		gzipReader, err := gzip.NewReader(bytes.NewReader(contents))
+		Expect(err).NotTo(HaveOccurred())
+
+		files := map[string]string{}
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			file, err := ioutil.ReadAll(tarReader)
+			Expect(err).NotTo(HaveOccurred())
+			files[header.Name] = string(file)
+		}
+		return files
+	}
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		recoveryBundle = storage.NewRecoveryBundle("some-dir", fs)
+
+		err := fs.WriteFile(filepath.Join("some-dir", "terraform", "bbl-template.tf"), []byte("some-template"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+		err = fs.WriteFile(filepath.Join("some-dir", "vars", "director-vars-store.yml"), []byte("admin_password: some-password"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		state = storage.State{IAAS: "gcp", EnvID: "some-env-id"}
+		outputs = map[string]interface{}{"network_name": "some-network"}
+	})
+
+	It("writes the state, outputs, template and director credentials into a gzipped tar", func() {
+		err := recoveryBundle.Write("bundle.tgz", state, outputs, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := fs.ReadFile("bundle.tgz")
+		Expect(err).NotTo(HaveOccurred())
+
+		files := unpack(contents)
+		Expect(files).To(HaveLen(4))
+		Expect(files["terraform/bbl-template.tf"]).To(Equal("some-template"))
+		Expect(files["vars/director-vars-store.yml"]).To(Equal("admin_password: some-password"))
+		Expect(files["terraform-outputs.json"]).To(MatchJSON(`{"network_name": "some-network"}`))
+
+		var bundledState storage.State
+		err = json.Unmarshal([]byte(files["bbl-state.json"]), &bundledState)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bundledState.EnvID).To(Equal("some-env-id"))
+
+		info, err := fs.Stat("bundle.tgz")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	Context("when a key is given", func() {
+		It("encrypts the bundle", func() {
+			err := recoveryBundle.Write("bundle.tgz.enc", state, outputs, []byte("some-key"))
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile("bundle.tgz.enc")
+			Expect(err).NotTo(HaveOccurred())
+
+			sum := sha256.Sum256([]byte("some-key"))
+			block, err := aes.NewCipher(sum[:])
+			Expect(err).NotTo(HaveOccurred())
+			gcm, err := cipher.NewGCM(block)
+			Expect(err).NotTo(HaveOccurred())
+
+			nonce, sealed := contents[:gcm.NonceSize()], contents[gcm.NonceSize():]
+			plain, err := gcm.Open(nil, nonce, sealed, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(unpack(plain)).To(HaveKey("terraform/bbl-template.tf"))
+		})
+	})
+
+	Context("when the bundle cannot be written", func() {
+		It("returns an error", func() {
+			recoveryBundle = storage.NewRecoveryBundle("some-dir", &afero.Afero{Fs: afero.NewReadOnlyFs(fs.Fs)})
+
+			err := recoveryBundle.Write("bundle.tgz", state, outputs, nil)
+			Expect(err).To(MatchError(HavePrefix("Write recovery bundle: ")))
+		})
+	})
+})
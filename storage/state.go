@@ -6,6 +6,7 @@ type State struct {
 	IAAS           string    `json:"iaas"`
 	ID             string    `json:"id"`
 	EnvID          string    `json:"envID"`
+	EnvClass       string    `json:"envClass,omitempty"`
 	NoDirector     bool      `json:"noDirector"`
 	AWS            AWS       `json:"aws,omitempty"`
 	Azure          Azure     `json:"azure,omitempty"`
@@ -6,9 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
 	uuid "github.com/nu7hatch/gouuid"
+	"github.com/spf13/afero"
 )
 
 var (
@@ -29,12 +31,17 @@ type Store struct {
 }
 
 type fs interface {
+	fileio.FileReader
 	fileio.FileWriter
+	fileio.Renamer
 	fileio.Remover
 	fileio.AllRemover
 	fileio.Stater
 	fileio.AllMkdirer
 	fileio.DirReader
+	fileio.TempFiler
+	fileio.Opener
+	fileio.Chmoder
 }
 
 type garbageCollector interface {
@@ -79,15 +86,94 @@ func (s Store) Set(state State) error {
 		return err
 	}
 
-	stateFile := filepath.Join(s.dir, STATE_FILE)
-	err = s.fs.WriteFile(stateFile, jsonData, os.FileMode(0644))
+	// The state is written to a temporary file next to the state file and
+	// renamed over it, so that a bbl killed mid-write, or a machine that
+	// loses power, leaves the previous state file intact.
+	tempFile, err := s.fs.TempFile(s.dir, STATE_FILE)
 	if err != nil {
+		return fmt.Errorf("Create temporary state file: %s", err)
+	}
+
+	err = s.writeTempFile(tempFile, jsonData)
+	if err != nil {
+		s.fs.Remove(tempFile.Name())
 		return err
 	}
 
+	err = s.fs.Rename(tempFile.Name(), filepath.Join(s.dir, STATE_FILE))
+	if err != nil {
+		s.fs.Remove(tempFile.Name())
+		return fmt.Errorf("Replace state file: %s", err)
+	}
+
+	return s.syncDir()
+}
+
+// writeTempFile writes the state to the temporary file and flushes it to
+// disk, so that the rename never puts an empty or partial file in place.
+func (s Store) writeTempFile(tempFile afero.File, jsonData []byte) error {
+	_, err := tempFile.Write(jsonData)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+
+	closeErr := tempFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Write state file: %s", err)
+	}
+
+	err = s.fs.Chmod(tempFile.Name(), os.FileMode(0644))
+	if err != nil {
+		return fmt.Errorf("Write state file: %s", err)
+	}
+
+	return nil
+}
+
+// syncDir flushes the rename to disk. A directory cannot be synced on
+// Windows, so the rename is left to the file system there.
+func (s Store) syncDir() error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := s.fs.Open(s.dir)
+	if err != nil {
+		return fmt.Errorf("Sync state dir: %s", err)
+	}
+	defer dir.Close()
+
+	err = dir.Sync()
+	if err != nil {
+		return fmt.Errorf("Sync state dir: %s", err)
+	}
+
 	return nil
 }
 
+// Get reads the state file back from the state directory. A missing state
+// file is reported as an empty state.
+func (s Store) Get() (State, error) {
+	contents, err := s.fs.ReadFile(filepath.Join(s.dir, STATE_FILE))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return State{}, nil
+		}
+		return State{}, fmt.Errorf("Read state file: %s", err)
+	}
+
+	var state State
+	err = json.Unmarshal(contents, &state)
+	if err != nil {
+		return State{}, fmt.Errorf("Unmarshal state file: %s", err)
+	}
+
+	return state, nil
+}
+
 func (s Store) GetStateDir() string {
 	return s.dir
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	uuid "github.com/nu7hatch/gouuid"
+	"github.com/spf13/afero"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
@@ -21,13 +22,21 @@ var _ = Describe("Store", func() {
 		garbageCollector *fakes.GarbageCollector
 		store            storage.Store
 		tempDir          string
+		memFS            *afero.Afero
+		tempFile         string
 	)
 
 	BeforeEach(func() {
 		var err error
 		tempDir, err = ioutil.TempDir("", "")
 
+		memFS = &afero.Afero{Fs: afero.NewMemMapFs()}
+		tempFile = filepath.Join(tempDir, "bbl-state.json123")
 		fileIO = &fakes.FileIO{}
+		fileIO.TempFileCall.Returns.File, err = memFS.Create(tempFile)
+		Expect(err).NotTo(HaveOccurred())
+		fileIO.OpenCall.Returns.File, err = memFS.Create(filepath.Join(tempDir, "dir"))
+		Expect(err).NotTo(HaveOccurred())
 		garbageCollector = &fakes.GarbageCollector{}
 
 		store = storage.NewStore(tempDir, fileIO, garbageCollector)
@@ -130,9 +139,17 @@ var _ = Describe("Store", func() {
 				})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(filepath.Join(tempDir, "bbl-state.json")))
-				Expect(fileIO.WriteFileCall.Receives[0].Mode).To(Equal(os.FileMode(0644)))
-				Expect(fileIO.WriteFileCall.Receives[0].Contents).To(MatchJSON(`{
+				Expect(fileIO.TempFileCall.Receives.Dir).To(Equal(tempDir))
+				Expect(fileIO.TempFileCall.Receives.Prefix).To(Equal("bbl-state.json"))
+				Expect(fileIO.RenameCall.Receives.Oldpath).To(Equal(tempFile))
+				Expect(fileIO.RenameCall.Receives.Newpath).To(Equal(filepath.Join(tempDir, "bbl-state.json")))
+				Expect(fileIO.ChmodCall.Receives.Name).To(Equal(tempFile))
+				Expect(fileIO.ChmodCall.Receives.Mode).To(Equal(os.FileMode(0644)))
+				Expect(fileIO.OpenCall.Receives.Name).To(Equal(tempDir))
+
+				contents, err := memFS.ReadFile(tempFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contents).To(MatchJSON(`{
 				"version": 14,
 				"bblVersion": "5.3.0",
 				"iaas": "aws",
@@ -247,14 +264,75 @@ var _ = Describe("Store", func() {
 				})
 			})
 
-			Context("when it fails to open the bbl-state.json file", func() {
+			Context("when it fails to create the temporary state file", func() {
 				BeforeEach(func() {
-					fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("permission denied")}}
+					fileIO.TempFileCall.Returns.Error = errors.New("permission denied")
 				})
 
 				It("returns an error", func() {
 					err := store.Set(storage.State{EnvID: "something"})
-					Expect(err).To(MatchError(ContainSubstring("permission denied")))
+					Expect(err).To(MatchError("Create temporary state file: permission denied"))
+
+					Expect(fileIO.RenameCall.CallCount).To(Equal(0))
+				})
+			})
+
+			Context("when the temporary state file cannot be written", func() {
+				BeforeEach(func() {
+					fileIO.ChmodCall.Returns.Error = errors.New("operation not permitted")
+				})
+
+				It("removes it and returns an error", func() {
+					err := store.Set(storage.State{EnvID: "something"})
+					Expect(err).To(MatchError("Write state file: operation not permitted"))
+
+					Expect(fileIO.RenameCall.CallCount).To(Equal(0))
+					Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: tempFile}))
+				})
+			})
+
+			Context("when the state dir cannot be synced", func() {
+				BeforeEach(func() {
+					fileIO.OpenCall.Returns.Error = errors.New("too many open files")
+				})
+
+				It("returns an error", func() {
+					err := store.Set(storage.State{EnvID: "something"})
+					Expect(err).To(MatchError("Sync state dir: too many open files"))
+				})
+			})
+
+			Context("when the state file cannot be replaced", func() {
+				BeforeEach(func() {
+					fileIO.RenameCall.Returns.Error = errors.New("cross-device link")
+				})
+
+				It("returns an error", func() {
+					err := store.Set(storage.State{EnvID: "something"})
+					Expect(err).To(MatchError("Replace state file: cross-device link"))
+
+					Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: tempFile}))
+				})
+			})
+
+			Context("when the write is interrupted", func() {
+				It("leaves the previous state file intact", func() {
+					stateFile := filepath.Join(tempDir, "bbl-state.json")
+					err := ioutil.WriteFile(stateFile, []byte(`{"envID": "old-env-id"}`), os.FileMode(0644))
+					Expect(err).NotTo(HaveOccurred())
+
+					store = storage.NewStore(tempDir, interruptedWriter{Afero: &afero.Afero{Fs: afero.NewOsFs()}}, garbageCollector)
+
+					err = store.Set(storage.State{EnvID: "new-env-id"})
+					Expect(err).To(MatchError("Write state file: interrupted"))
+
+					contents, err := ioutil.ReadFile(stateFile)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(contents).To(MatchJSON(`{"envID": "old-env-id"}`))
+
+					files, err := ioutil.ReadDir(tempDir)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(files).To(HaveLen(1))
 				})
 			})
 		})
@@ -296,6 +374,44 @@ var _ = Describe("Store", func() {
 		Entry("jumpbox-deployment", "jumpbox-deployment", func() (string, error) { return store.GetJumpboxDeploymentDir() }),
 	)
 
+	Describe("Get", func() {
+		It("reads the state file in the state dir", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte(`{"envID": "some-env-id"}`)
+
+			state, err := store.Get()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal(filepath.Join(tempDir, "bbl-state.json")))
+			Expect(state).To(Equal(storage.State{EnvID: "some-env-id"}))
+		})
+
+		Context("when the state file does not exist", func() {
+			It("returns an empty state", func() {
+				fileIO.ReadFileCall.Returns.Error = os.ErrNotExist
+
+				state, err := store.Get()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state).To(Equal(storage.State{}))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the state file cannot be read", func() {
+				fileIO.ReadFileCall.Returns.Error = errors.New("permission denied")
+
+				_, err := store.Get()
+				Expect(err).To(MatchError("Read state file: permission denied"))
+			})
+
+			It("returns an error when the state file is not valid json", func() {
+				fileIO.ReadFileCall.Returns.Contents = []byte("%%%")
+
+				_, err := store.Get()
+				Expect(err).To(MatchError(ContainSubstring("Unmarshal state file: ")))
+			})
+		})
+	})
+
 	Describe("GetCloudConfigDir", func() {
 		var expectedCloudConfigPath string
 
@@ -337,3 +453,31 @@ var _ = Describe("Store", func() {
 
 	})
 })
+
+// interruptedWriter creates files that write half of their contents before
+// failing, like a bbl killed mid-write.
+type interruptedWriter struct {
+	*afero.Afero
+}
+
+func (w interruptedWriter) TempFile(dir, prefix string) (afero.File, error) {
+	file, err := w.Afero.TempFile(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return interruptedFile{File: file}, nil
+}
+
+type interruptedFile struct {
+	afero.File
+}
+
+func (f interruptedFile) Write(data []byte) (int, error) {
+	n, err := f.File.Write(data[:len(data)/2])
+	if err != nil {
+		return n, err
+	}
+
+	return n, errors.New("interrupted")
+}
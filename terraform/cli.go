@@ -1,9 +1,11 @@
 package terraform
 
 import (
+	"context"
 	"io"
 	"os"
 	"os/exec"
+	"time"
 )
 
 type CLI struct {
@@ -25,6 +27,15 @@ func (c CLI) Run(stdout io.Writer, workingDirectory string, args []string) error
 }
 
 func (c CLI) RunWithEnv(stdout io.Writer, workingDirectory string, args []string, extraEnvVars []string) error {
+	return c.RunWithContext(context.Background(), stdout, workingDirectory, args, extraEnvVars)
+}
+
+// RunWithContext runs terraform like RunWithEnv, and interrupts it when the
+// deadline of ctx passes. Terraform stops at an interrupt the way it does at a
+// Ctrl-C, and saves the state of what it has already done. Cancelling ctx does
+// not interrupt terraform: the Ctrl-C that cancels it reaches terraform too,
+// and a second interrupt makes terraform exit without saving its state.
+func (c CLI) RunWithContext(ctx context.Context, stdout io.Writer, workingDirectory string, args []string, extraEnvVars []string) error {
 	path, err := BinaryPath()
 	if err != nil {
 		return err
@@ -38,5 +49,28 @@ func (c CLI) RunWithEnv(stdout io.Writer, workingDirectory string, args []string
 	command.Stdout = io.MultiWriter(stdout, c.outputBuffer)
 	command.Stderr = c.errorBuffer
 
-	return command.Run()
+	err = command.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- command.Wait() }()
+
+	var deadlinePassed <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		deadlinePassed = timer.C
+	}
+
+	select {
+	case err = <-done:
+		return err
+	case <-deadlinePassed:
+		if command.Process.Signal(os.Interrupt) != nil {
+			command.Process.Kill()
+		}
+		return <-done
+	}
 }
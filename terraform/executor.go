@@ -2,6 +2,7 @@ package terraform
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +26,7 @@ type Executor struct {
 	fs           fs
 	debug        bool
 	out          io.Writer
+	workspace    string
 }
 
 type tfOutput struct {
@@ -36,6 +38,7 @@ type tfOutput struct {
 type terraformCLI interface {
 	Run(stdout io.Writer, workingDirectory string, args []string) error
 	RunWithEnv(stdout io.Writer, workingDirectory string, args []string, envs []string) error
+	RunWithContext(ctx context.Context, stdout io.Writer, workingDirectory string, args []string, envs []string) error
 }
 
 type stateStore interface {
@@ -49,7 +52,7 @@ type fs interface {
 	fileio.Stater
 }
 
-func NewExecutor(cli terraformCLI, bufferingCLI terraformCLI, stateStore stateStore, fs fs, debug bool, out io.Writer) Executor {
+func NewExecutor(cli terraformCLI, bufferingCLI terraformCLI, stateStore stateStore, fs fs, debug bool, out io.Writer, workspace string) Executor {
 	return Executor{
 		cli:          cli,
 		bufferingCLI: bufferingCLI,
@@ -57,9 +60,46 @@ func NewExecutor(cli terraformCLI, bufferingCLI terraformCLI, stateStore stateSt
 		fs:           fs,
 		debug:        debug,
 		out:          out,
+		workspace:    workspace,
 	}
 }
 
+// statePath returns where the terraform state for the selected workspace
+// lives. The default workspace keeps vars/terraform.tfstate, others follow
+// terraform's own terraform.tfstate.d/<workspace> layout.
+func (e Executor) statePath(varsDir string) string {
+	if e.workspace == "" || e.workspace == "default" {
+		return filepath.Join(varsDir, "terraform.tfstate")
+	}
+
+	return filepath.Join(varsDir, "terraform.tfstate.d", e.workspace, "terraform.tfstate")
+}
+
+// selectWorkspace switches terraform to the configured workspace, creating
+// it the first time it is used.
+func (e Executor) selectWorkspace(terraformDir, varsDir string) error {
+	if e.workspace == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(e.statePath(varsDir)), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Create terraform workspace state directory: %s", err)
+	}
+
+	err = e.cli.Run(e.out, terraformDir, []string{"workspace", "select", e.workspace})
+	if err == nil {
+		return nil
+	}
+
+	err = e.cli.Run(e.out, terraformDir, []string{"workspace", "new", e.workspace})
+	if err != nil {
+		return fmt.Errorf("Select terraform workspace %s: %s", e.workspace, err)
+	}
+
+	return nil
+}
+
 func (e Executor) Setup(template string, input map[string]interface{}) error {
 	terraformDir, err := e.stateStore.GetTerraformDir()
 	if err != nil {
@@ -112,21 +152,26 @@ func formatVars(inputs map[string]interface{}) string {
 }
 
 func (e Executor) runTFCommand(args []string) error {
-	return e.runTFCommandWithEnvs(args, []string{})
+	return e.runTFCommandWithEnvs(context.Background(), args, []string{})
 }
 
-func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
+func (e Executor) runTFCommandWithEnvs(ctx context.Context, args, envs []string) error {
 	varsDir, err := e.stateStore.GetVarsDir()
 	if err != nil {
 		return err
 	}
 
-	tfStatePath := filepath.Join(varsDir, "terraform.tfstate")
+	tfStatePath := e.statePath(varsDir)
 
 	terraformDir, err := e.stateStore.GetTerraformDir()
 	if err != nil {
 		return err
 	}
+
+	err = e.selectWorkspace(terraformDir, varsDir)
+	if err != nil {
+		return err
+	}
 	relativeStatePath, err := filepath.Rel(terraformDir, tfStatePath)
 	if err != nil {
 		return fmt.Errorf("Get relative terraform state path: %s", err) //not tested
@@ -153,7 +198,10 @@ func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
 		}
 	}
 
-	err = e.cli.RunWithEnv(e.out, terraformDir, args, envs)
+	err = e.cli.RunWithContext(ctx, e.out, terraformDir, args, envs)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Run terraform %s: timed out", args[0])
+	}
 	if err != nil {
 		if e.debug {
 			return err
@@ -232,13 +280,49 @@ func (e Executor) Validate(credentials map[string]string) error {
 	return nil
 }
 
-func (e Executor) Destroy(credentials map[string]string) error {
+// Destroy destroys everything in the terraform state. When the deadline of
+// ctx passes, terraform is interrupted and saves what it destroyed so far.
+func (e Executor) Destroy(ctx context.Context, credentials map[string]string) error {
+	return e.DestroyTargets(ctx, credentials, nil)
+}
+
+// DestroyTargets destroys only the given resource addresses and whatever
+// depends on them. Without targets everything is destroyed.
+func (e Executor) DestroyTargets(ctx context.Context, credentials map[string]string, targets []string) error {
 	args := []string{"destroy", "-force"}
 	for key, value := range credentials {
 		arg := fmt.Sprintf("%s=%s", key, value)
 		args = append(args, "-var", arg)
 	}
-	return e.runTFCommandWithEnvs(args, []string{"TF_WARN_OUTPUT_ERRORS=1"})
+	for _, target := range targets {
+		args = append(args, "-target", target)
+	}
+	return e.runTFCommandWithEnvs(ctx, args, []string{"TF_WARN_OUTPUT_ERRORS=1"})
+}
+
+func (e Executor) StateList() ([]string, error) {
+	terraformDir, err := e.stateStore.GetTerraformDir()
+	if err != nil {
+		return nil, err
+	}
+
+	varsDir, err := e.stateStore.GetVarsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	relativeStatePath, err := filepath.Rel(terraformDir, e.statePath(varsDir))
+	if err != nil {
+		return nil, fmt.Errorf("Get relative terraform state path: %s", err) //not tested
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err = e.bufferingCLI.Run(buffer, terraformDir, []string{"state", "list", "-state", relativeStatePath})
+	if err != nil {
+		return nil, fmt.Errorf("Run terraform state list: %s", err)
+	}
+
+	return strings.Fields(buffer.String()), nil
 }
 
 func (e Executor) Version() (string, error) {
@@ -248,7 +332,7 @@ func (e Executor) Version() (string, error) {
 		return "", err
 	}
 	versionOutput := buffer.String()
-	regex := regexp.MustCompile(`\d+.\d+.\d+`)
+	regex := regexp.MustCompile(`\d+\.\d+\.\d+(-[0-9A-Za-z.]+)?`)
 
 	version := regex.FindString(versionOutput)
 	if version == "" {
@@ -275,9 +359,9 @@ func (e Executor) Output(outputName string) (string, error) {
 	}
 
 	args := []string{"output", outputName}
-	_, err = e.fs.Stat(filepath.Join(varsDir, "terraform.tfstate"))
+	_, err = e.fs.Stat(e.statePath(varsDir))
 	if err == nil {
-		args = append(args, "-state", filepath.Join(varsDir, "terraform.tfstate"))
+		args = append(args, "-state", e.statePath(varsDir))
 	}
 	buffer := bytes.NewBuffer([]byte{})
 	err = e.bufferingCLI.Run(buffer, terraformDir, args)
@@ -301,9 +385,9 @@ func (e Executor) Outputs() (map[string]interface{}, error) {
 
 	buffer := bytes.NewBuffer([]byte{})
 	args := []string{"output", "--json"}
-	_, err = e.fs.Stat(filepath.Join(varsDir, "terraform.tfstate"))
+	_, err = e.fs.Stat(e.statePath(varsDir))
 	if err == nil {
-		args = append(args, "-state", filepath.Join(varsDir, "terraform.tfstate"))
+		args = append(args, "-state", e.statePath(varsDir))
 	}
 	err = e.bufferingCLI.Run(buffer, terraformDir, args)
 	if err != nil {
@@ -342,9 +426,9 @@ func (e Executor) IsPaved() (bool, error) {
 
 	buffer := bytes.NewBuffer([]byte{})
 	args := []string{"show"}
-	_, err = e.fs.Stat(filepath.Join(varsDir, "terraform.tfstate"))
+	_, err = e.fs.Stat(e.statePath(varsDir))
 	if err == nil {
-		args = append(args, filepath.Join(varsDir, "terraform.tfstate"))
+		args = append(args, e.statePath(varsDir))
 	}
 
 	err = e.bufferingCLI.Run(buffer, terraformDir, args)
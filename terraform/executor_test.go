@@ -1,6 +1,7 @@
 package terraform_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -43,8 +45,8 @@ var _ = Describe("Executor", func() {
 		stateStore = &fakes.StateStore{}
 		fileIO = &fakes.FileIO{}
 
-		executor = terraform.NewExecutor(cli, bufferingCLI, stateStore, fileIO, true, os.Stdout)
-		debugFalse = terraform.NewExecutor(cli, bufferingCLI, stateStore, fileIO, false, nil)
+		executor = terraform.NewExecutor(cli, bufferingCLI, stateStore, fileIO, true, os.Stdout, "")
+		debugFalse = terraform.NewExecutor(cli, bufferingCLI, stateStore, fileIO, false, nil, "")
 
 		var err error
 		terraformDir, err = ioutil.TempDir("", "terraform")
@@ -422,7 +424,7 @@ var _ = Describe("Executor", func() {
 		})
 
 		It("writes the template and tf state to a temp dir", func() {
-			err := executor.Destroy(credentials)
+			err := executor.Destroy(context.Background(), credentials)
 			Expect(err).NotTo(HaveOccurred())
 
 			By("passing the correct args and dir to run command", func() {
@@ -439,6 +441,66 @@ var _ = Describe("Executor", func() {
 			})
 		})
 
+		It("runs terraform with the given context", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			err := executor.Destroy(ctx, credentials)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.RunCall.Receives.Context).To(Equal(ctx))
+		})
+
+		Context("when the deadline passes while terraform runs", func() {
+			It("returns an error", func() {
+				ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+				defer cancel()
+				cli.RunCall.Returns.Errors = []error{errors.New("exit status 1")}
+
+				err := debugFalse.Destroy(ctx, credentials)
+				Expect(err).To(MatchError("Run terraform destroy: timed out"))
+			})
+		})
+
+		Context("when a terraform workspace is given", func() {
+			BeforeEach(func() {
+				executor = terraform.NewExecutor(cli, bufferingCLI, stateStore, fileIO, true, os.Stdout, "some-workspace")
+			})
+
+			It("selects the workspace and uses its state", func() {
+				err := executor.Destroy(context.Background(), credentials)
+				Expect(err).NotTo(HaveOccurred())
+
+				workspaceStatePath, err := filepath.Rel(terraformDir, filepath.Join(varsDir, "terraform.tfstate.d", "some-workspace", "terraform.tfstate"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cli.RunCall.AllArgs).To(HaveLen(2))
+				Expect(cli.RunCall.AllArgs[0]).To(Equal([]string{"workspace", "select", "some-workspace"}))
+				Expect(cli.RunCall.AllArgs[1]).To(ContainElement(workspaceStatePath))
+			})
+
+			Context("when the workspace does not exist yet", func() {
+				It("creates it", func() {
+					cli.RunCall.Returns.Errors = []error{errors.New("workspace does not exist")}
+
+					err := executor.Destroy(context.Background(), credentials)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(cli.RunCall.AllArgs).To(HaveLen(3))
+					Expect(cli.RunCall.AllArgs[1]).To(Equal([]string{"workspace", "new", "some-workspace"}))
+				})
+			})
+
+			Context("when the workspace cannot be created", func() {
+				It("returns an error", func() {
+					cli.RunCall.Returns.Errors = []error{errors.New("no such workspace"), errors.New("permission denied")}
+
+					err := executor.Destroy(context.Background(), credentials)
+					Expect(err).To(MatchError("Select terraform workspace some-workspace: permission denied"))
+				})
+			})
+		})
+
 		Context("when an error occurs", func() {
 			Context("when getting terraform dir fails", func() {
 				BeforeEach(func() {
@@ -446,7 +508,7 @@ var _ = Describe("Executor", func() {
 				})
 
 				It("returns an error", func() {
-					err := executor.Destroy(credentials)
+					err := executor.Destroy(context.Background(), credentials)
 					Expect(err).To(MatchError("kiwi"))
 				})
 			})
@@ -457,7 +519,7 @@ var _ = Describe("Executor", func() {
 				})
 
 				It("returns an error", func() {
-					err := executor.Destroy(credentials)
+					err := executor.Destroy(context.Background(), credentials)
 					Expect(err).To(MatchError("banana"))
 				})
 			})
@@ -470,13 +532,13 @@ var _ = Describe("Executor", func() {
 				})
 
 				It("returns an error", func() {
-					err := executor.Destroy(credentials)
+					err := executor.Destroy(context.Background(), credentials)
 					Expect(err).To(MatchError("the-executor-error"))
 				})
 
 				Context("when --debug is false", func() {
 					It("returns a redacted error", func() {
-						err := debugFalse.Destroy(credentials)
+						err := debugFalse.Destroy(context.Background(), credentials)
 						Expect(err).To(MatchError("Some output has been redacted, use `bbl latest-error` to see it or run again with --debug for additional debug output"))
 					})
 				})
@@ -484,6 +546,47 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("DestroyTargets", func() {
+		It("destroys only the targeted resources", func() {
+			err := executor.DestroyTargets(context.Background(), map[string]string{}, []string{"google_compute_firewall.some-firewall", "google_compute_address.some-address"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cli.RunCall.Receives.Args).To(Equal([]string{
+				"destroy", "-force",
+				"-target", "google_compute_firewall.some-firewall",
+				"-target", "google_compute_address.some-address",
+				"-state", relativeStatePath,
+			}))
+		})
+	})
+
+	Describe("StateList", func() {
+		BeforeEach(func() {
+			bufferingCLI.RunCall.Stub = func(stdout io.Writer) {
+				stdout.Write([]byte("google_compute_network.bbl-network\ngoogle_compute_subnetwork.bbl-subnet\n"))
+			}
+		})
+
+		It("lists the resource addresses in the bbl terraform state", func() {
+			addresses, err := executor.StateList()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bufferingCLI.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
+			Expect(bufferingCLI.RunCall.Receives.Args).To(Equal([]string{"state", "list", "-state", relativeStatePath}))
+			Expect(addresses).To(Equal([]string{"google_compute_network.bbl-network", "google_compute_subnetwork.bbl-subnet"}))
+		})
+
+		Context("when terraform fails", func() {
+			It("returns an error", func() {
+				bufferingCLI.RunCall.Stub = nil
+				bufferingCLI.RunCall.Returns.Errors = []error{errors.New("guava")}
+
+				_, err := executor.StateList()
+				Expect(err).To(MatchError("Run terraform state list: guava"))
+			})
+		})
+	})
+
 	Describe("Version", func() {
 		BeforeEach(func() {
 			bufferingCLI.RunCall.Stub = func(stdout io.Writer) {
@@ -504,6 +607,16 @@ var _ = Describe("Executor", func() {
 			Expect(version).To(Equal("0.8.9"))
 		})
 
+		It("keeps the pre-release", func() {
+			bufferingCLI.RunCall.Stub = func(stdout io.Writer) {
+				stdout.Write([]byte("Terraform v0.12.0-rc1\n"))
+			}
+
+			version, err := executor.Version()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("0.12.0-rc1"))
+		})
+
 		Context("when an error occurs", func() {
 			Context("when the run command fails", func() {
 				BeforeEach(func() {
@@ -2,8 +2,11 @@ package terraform
 
 import (
 	"bytes"
-	"errors"
+	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/coreos/go-semver/semver"
@@ -23,12 +26,22 @@ type executor interface {
 	Init() error
 	Apply(credentials map[string]string) error
 	Validate(credentials map[string]string) error
-	Destroy(credentials map[string]string) error
+	Destroy(ctx context.Context, credentials map[string]string) error
+	DestroyTargets(ctx context.Context, credentials map[string]string, targets []string) error
+	StateList() ([]string, error)
 	Outputs() (map[string]interface{}, error)
 	Output(string) (string, error)
 	IsPaved() (bool, error)
 }
 
+// Resource types that make up the network of each iaas and are left in place
+// when destroying while keeping the network.
+var networkResourceTypes = map[string][]string{
+	"gcp": {"google_compute_network", "google_compute_subnetwork"},
+}
+
+var providerMismatch = regexp.MustCompile(`(?i)(no suitable version installed|provider requirements cannot be satisfied|failed to instantiate provider|could not load plugin)`)
+
 type InputGenerator interface {
 	Generate(storage.State) (map[string]interface{}, error)
 	Credentials(state storage.State) map[string]string
@@ -56,6 +69,28 @@ func (m Manager) Version() (string, error) {
 	return m.executor.Version()
 }
 
+// MinimumVersion is the oldest terraform the templates work with, and
+// UnsupportedVersion the first release whose state they cannot be trusted to
+// destroy. BundledVersion is the terraform scripts/update_terraform_binaries
+// packages with bbl.
+const (
+	MinimumVersion     = "0.11.0"
+	UnsupportedVersion = "0.12.0"
+	BundledVersion     = "0.11.7"
+)
+
+var versionRangeError = fmt.Errorf("Terraform version must be between v%s and v%s", MinimumVersion, lastSupportedRelease())
+
+// lastSupportedRelease names the minor release before UnsupportedVersion,
+// such as 0.11.x.
+func lastSupportedRelease() string {
+	unsupported := semver.New(UnsupportedVersion)
+	return fmt.Sprintf("%d.%d.x", unsupported.Major, unsupported.Minor-1)
+}
+
+// ValidateVersion checks that terraform is within the supported versions. A
+// pre-release of the minimum is too old, while a pre-release of the
+// unsupported version is already rejected.
 func (m Manager) ValidateVersion() error {
 	version, err := m.executor.Version()
 	if err != nil {
@@ -67,13 +102,14 @@ func (m Manager) ValidateVersion() error {
 		return err
 	}
 
-	minimumVersion, err := semver.NewVersion("0.11.0")
-	if err != nil {
-		return err
+	if currentVersion.LessThan(*semver.New(MinimumVersion)) {
+		return versionRangeError
 	}
 
-	if currentVersion.LessThan(*minimumVersion) {
-		return errors.New("Terraform version must be at least v0.11.0")
+	release := *currentVersion
+	release.PreRelease = ""
+	if !release.LessThan(*semver.New(UnsupportedVersion)) {
+		return versionRangeError
 	}
 
 	return nil
@@ -96,6 +132,64 @@ func (m Manager) Setup(bblState storage.State) error {
 	return m.Init(bblState)
 }
 
+// SetupWithTemplate sets up terraform like Setup, but with the given template
+// in place of the one bbl generates.
+func (m Manager) SetupWithTemplate(bblState storage.State, template string) error {
+	m.logger.Step("generating terraform variables")
+	input, err := m.inputGenerator.Generate(bblState)
+	if err != nil {
+		return fmt.Errorf("Input generator generate: %s", err)
+	}
+
+	err = m.validateTemplate(bblState, template, input)
+	if err != nil {
+		return err
+	}
+
+	m.logger.Step("using the terraform template override")
+	if err := m.executor.Setup(template, input); err != nil {
+		return fmt.Errorf("Executor setup: %s", err)
+	}
+
+	return m.Init(bblState)
+}
+
+// ValidateTemplate checks that a template used in place of the generated one
+// declares every variable bbl passes to terraform.
+func (m Manager) ValidateTemplate(bblState storage.State, template string) error {
+	input, err := m.inputGenerator.Generate(bblState)
+	if err != nil {
+		return fmt.Errorf("Input generator generate: %s", err)
+	}
+
+	return m.validateTemplate(bblState, template, input)
+}
+
+func (m Manager) validateTemplate(bblState storage.State, template string, input map[string]interface{}) error {
+	names := map[string]bool{}
+	for name := range input {
+		names[name] = true
+	}
+	for name := range m.inputGenerator.Credentials(bblState) {
+		names[name] = true
+	}
+
+	var missing []string
+	for name := range names {
+		declaration := regexp.MustCompile(fmt.Sprintf(`variable\s+"?%s"?\s*\{`, regexp.QuoteMeta(name)))
+		if !declaration.MatchString(template) {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("Terraform template override does not declare the variables bbl sets: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
 func (m Manager) Init(bblState storage.State) error {
 	m.logger.Step("terraform init")
 	if err := m.executor.Init(); err != nil {
@@ -122,13 +216,16 @@ func (m Manager) Apply(bblState storage.State) (storage.State, error) {
 	return bblState, nil
 }
 
-func (m Manager) Destroy(bblState storage.State) (storage.State, error) {
+func (m Manager) Destroy(ctx context.Context, bblState storage.State) (storage.State, error) {
 	m.logger.Step("terraform destroy")
-	err := m.executor.Destroy(m.inputGenerator.Credentials(bblState))
+	err := m.executor.Destroy(ctx, m.inputGenerator.Credentials(bblState))
 
 	bblState.LatestTFOutput = readAndReset(m.terraformOutputBuffer)
 
 	if err != nil {
+		if providerMismatch.MatchString(bblState.LatestTFOutput) {
+			return bblState, fmt.Errorf("Executor destroy: %s\nThe terraform state references a provider version that is not installed. Terraform v0.11 cannot move a state to another provider, so install the version the state references in the terraform plugin directory and run bbl destroy again.", err)
+		}
 		return bblState, fmt.Errorf("Executor destroy: %s", err)
 	}
 
@@ -136,6 +233,86 @@ func (m Manager) Destroy(bblState storage.State) (storage.State, error) {
 	return bblState, nil
 }
 
+// DestroyKeepingNetwork destroys every resource except the network, which
+// stays in the terraform state so that a later bbl up can reuse it.
+func (m Manager) DestroyKeepingNetwork(ctx context.Context, bblState storage.State) (storage.State, error) {
+	targets, err := m.DestroyAddresses(bblState, true)
+	if err != nil {
+		return bblState, err
+	}
+
+	if len(targets) == 0 {
+		m.logger.Step("only the network remains, nothing to destroy")
+		return bblState, nil
+	}
+
+	m.logger.Step("terraform destroy, keeping the network")
+	err = m.executor.DestroyTargets(ctx, m.inputGenerator.Credentials(bblState), targets)
+
+	bblState.LatestTFOutput = readAndReset(m.terraformOutputBuffer)
+
+	if err != nil {
+		return bblState, fmt.Errorf("Executor destroy: %s", err)
+	}
+
+	m.logger.Step("finished destroying infrastructure, the network was kept")
+	return bblState, nil
+}
+
+// DestroyAddresses returns the addresses of the resources in the terraform
+// state that a destroy would delete, leaving out the network when keepNetwork
+// is set. Nothing is destroyed.
+func (m Manager) DestroyAddresses(bblState storage.State, keepNetwork bool) ([]string, error) {
+	var keep []string
+	if keepNetwork {
+		var ok bool
+		keep, ok = networkResourceTypes[bblState.IAAS]
+		if !ok {
+			return nil, fmt.Errorf("Keeping the network is not supported on %s", bblState.IAAS)
+		}
+	}
+
+	addresses, err := m.executor.StateList()
+	if err != nil {
+		return nil, fmt.Errorf("Executor state list: %s", err)
+	}
+
+	var targets []string
+	for _, address := range addresses {
+		resourceType, managed := ResourceType(address)
+		if managed && !contains(keep, resourceType) {
+			targets = append(targets, address)
+		}
+	}
+
+	return targets, nil
+}
+
+// ResourceType returns the type of the resource at a terraform address such
+// as module.lb.google_compute_address.cf-address. Data sources are not
+// managed and cannot be destroyed.
+func ResourceType(address string) (string, bool) {
+	parts := strings.Split(address, ".")
+	if len(parts) < 2 {
+		return "", false
+	}
+
+	if len(parts) > 2 && parts[len(parts)-3] == "data" {
+		return "", false
+	}
+
+	return parts[len(parts)-2], true
+}
+
+func contains(list []string, item string) bool {
+	for _, element := range list {
+		if element == item {
+			return true
+		}
+	}
+	return false
+}
+
 func (m Manager) Validate(bblState storage.State) (storage.State, error) {
 	m.logger.Step("terraform validate")
 	err := m.executor.Validate(m.inputGenerator.Credentials(bblState))
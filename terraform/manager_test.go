@@ -2,6 +2,7 @@ package terraform_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
@@ -10,6 +11,7 @@ import (
 	"github.com/pivotal-cf-experimental/gomegamatchers"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -39,6 +41,60 @@ var _ = Describe("Manager", func() {
 		terraformOutputBuffer.Reset()
 	})
 
+	Describe("SetupWithTemplate", func() {
+		var template string
+
+		BeforeEach(func() {
+			inputGenerator.GenerateCall.Returns.Inputs = map[string]interface{}{
+				"env_id":     "some-env-id",
+				"project_id": "some-project-id",
+			}
+			inputGenerator.CredentialsCall.Returns.Credentials = map[string]string{
+				"credentials": "some-path",
+			}
+
+			template = `
+variable "env_id" {}
+variable "project_id" {
+  type = "string"
+}
+variable credentials {}
+`
+		})
+
+		It("sets up terraform with the given template", func() {
+			err := manager.SetupWithTemplate(storage.State{EnvID: "some-env-id"}, template)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(templateGenerator.GenerateCall.CallCount).To(Equal(0))
+			Expect(executor.SetupCall.Receives.Template).To(Equal(template))
+			Expect(executor.SetupCall.Receives.Inputs).To(Equal(map[string]interface{}{
+				"env_id":     "some-env-id",
+				"project_id": "some-project-id",
+			}))
+			Expect(executor.InitCall.CallCount).To(Equal(1))
+		})
+
+		Context("when the template does not declare a variable bbl sets", func() {
+			It("returns an error without setting up terraform", func() {
+				err := manager.SetupWithTemplate(storage.State{}, `variable "env_id" {}`)
+				Expect(err).To(MatchError("Terraform template override does not declare the variables bbl sets: credentials, project_id"))
+
+				Expect(executor.SetupCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when validating on its own", func() {
+			It("checks the same variables", func() {
+				err := manager.ValidateTemplate(storage.State{}, template)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = manager.ValidateTemplate(storage.State{}, `variable "env_id" {}`)
+				Expect(err).To(MatchError("Terraform template override does not declare the variables bbl sets: credentials, project_id"))
+			})
+		})
+	})
+
 	Describe("Setup", func() {
 		var incomingState storage.State
 
@@ -190,12 +246,16 @@ var _ = Describe("Manager", func() {
 		})
 
 		It("calling executor destroy with the right arguments", func() {
-			newBBLState, err := manager.Destroy(incomingState)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			newBBLState, err := manager.Destroy(ctx, incomingState)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(inputGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
 
 			Expect(executor.DestroyCall.CallCount).To(Equal(1))
+			Expect(executor.DestroyCall.Receives.Context).To(Equal(ctx))
 			Expect(executor.DestroyCall.Receives.Credentials).To(Equal(credentials))
 			Expect(logger.StepCall.Messages).To(gomegamatchers.ContainSequence([]string{
 				"terraform destroy",
@@ -212,10 +272,136 @@ var _ = Describe("Manager", func() {
 			})
 
 			It("returns the current bbl state and the error", func() {
-				state, err := manager.Destroy(incomingState)
+				state, err := manager.Destroy(context.Background(), incomingState)
 				Expect(err).To(MatchError("Executor destroy: grape"))
 				Expect(state.LatestTFOutput).To(Equal(incomingState.LatestTFOutput))
 			})
+
+			Context("when the terraform output reports a missing provider", func() {
+				BeforeEach(func() {
+					terraformOutputBuffer.Reset()
+					terraformOutputBuffer.Write([]byte("Error: provider.google: no suitable version installed"))
+				})
+
+				It("explains the provider mismatch", func() {
+					_, err := manager.Destroy(context.Background(), incomingState)
+					Expect(err).To(MatchError("Executor destroy: grape\nThe terraform state references a provider version that is not installed. Terraform v0.11 cannot move a state to another provider, so install the version the state references in the terraform plugin directory and run bbl destroy again."))
+				})
+			})
+		})
+	})
+
+	Describe("DestroyKeepingNetwork", func() {
+		var credentials map[string]string
+
+		BeforeEach(func() {
+			terraformOutputBuffer.Write([]byte(expectedTFOutput))
+			credentials = map[string]string{
+				"some-credential": "some-credential-value",
+			}
+			inputGenerator.CredentialsCall.Returns.Credentials = credentials
+
+			executor.StateListCall.Returns.Addresses = []string{
+				"google_compute_network.bbl-network",
+				"google_compute_subnetwork.bbl-subnet",
+				"google_compute_firewall.internal",
+				"module.lb.google_compute_address.cf-address",
+				"data.google_compute_zones.available",
+			}
+		})
+
+		It("destroys everything but the network", func() {
+			newBBLState, err := manager.DestroyKeepingNetwork(context.Background(), storage.State{IAAS: "gcp"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executor.DestroyTargetsCall.Receives.Credentials).To(Equal(credentials))
+			Expect(executor.DestroyTargetsCall.Receives.Targets).To(Equal([]string{
+				"google_compute_firewall.internal",
+				"module.lb.google_compute_address.cf-address",
+			}))
+			Expect(executor.DestroyCall.CallCount).To(Equal(0))
+			Expect(newBBLState.LatestTFOutput).To(Equal(expectedTFOutput))
+		})
+
+		Context("when only the network remains", func() {
+			It("does not destroy anything", func() {
+				executor.StateListCall.Returns.Addresses = []string{
+					"google_compute_network.bbl-network",
+					"google_compute_subnetwork.bbl-subnet",
+				}
+
+				_, err := manager.DestroyKeepingNetwork(context.Background(), storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executor.DestroyTargetsCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error on an unsupported iaas", func() {
+				_, err := manager.DestroyKeepingNetwork(context.Background(), storage.State{IAAS: "aws"})
+				Expect(err).To(MatchError("Keeping the network is not supported on aws"))
+			})
+
+			It("returns an error when listing the state fails", func() {
+				executor.StateListCall.Returns.Error = errors.New("pear")
+
+				_, err := manager.DestroyKeepingNetwork(context.Background(), storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("Executor state list: pear"))
+			})
+
+			It("returns an error when the destroy fails", func() {
+				executor.DestroyTargetsCall.Returns.Error = errors.New("plum")
+
+				_, err := manager.DestroyKeepingNetwork(context.Background(), storage.State{IAAS: "gcp"})
+				Expect(err).To(MatchError("Executor destroy: plum"))
+			})
+		})
+	})
+
+	Describe("DestroyAddresses", func() {
+		BeforeEach(func() {
+			executor.StateListCall.Returns.Addresses = []string{
+				"aws_vpc.vpc",
+				"aws_instance.nat",
+				"module.lb.aws_elb.cf_router_lb",
+				"data.aws_availability_zones.available",
+			}
+		})
+
+		It("returns the managed resources in the terraform state", func() {
+			addresses, err := manager.DestroyAddresses(storage.State{IAAS: "aws"}, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(addresses).To(Equal([]string{
+				"aws_vpc.vpc",
+				"aws_instance.nat",
+				"module.lb.aws_elb.cf_router_lb",
+			}))
+			Expect(executor.DestroyCall.CallCount).To(Equal(0))
+		})
+
+		Context("when the network is kept", func() {
+			It("leaves out the network", func() {
+				executor.StateListCall.Returns.Addresses = []string{
+					"google_compute_network.bbl-network",
+					"google_compute_firewall.internal",
+				}
+
+				addresses, err := manager.DestroyAddresses(storage.State{IAAS: "gcp"}, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(addresses).To(Equal([]string{"google_compute_firewall.internal"}))
+			})
+		})
+
+		Context("when listing the state fails", func() {
+			It("returns an error", func() {
+				executor.StateListCall.Returns.Error = errors.New("pear")
+
+				_, err := manager.DestroyAddresses(storage.State{IAAS: "aws"}, false)
+				Expect(err).To(MatchError("Executor state list: pear"))
+			})
 		})
 	})
 
@@ -316,26 +502,32 @@ var _ = Describe("Manager", func() {
 	})
 
 	Describe("ValidateVersion", func() {
-		Context("when terraform version is greater than the minimum", func() {
-			BeforeEach(func() {
-				executor.VersionCall.Returns.Version = "9.0.0"
-			})
+		DescribeTable("when terraform version is supported",
+			func(version string) {
+				executor.VersionCall.Returns.Version = version
 
-			It("validates the version of terraform and returns no error", func() {
 				err := manager.ValidateVersion()
 				Expect(err).NotTo(HaveOccurred())
-			})
-		})
+			},
+			Entry("the minimum", "0.11.0"),
+			Entry("a later patch", "0.11.7"),
+			Entry("a dev build of a later patch", "0.11.8-dev"),
+		)
 
 		Context("failure cases", func() {
-			Context("when terraform version is less than the minimum", func() {
-				It("returns an error", func() {
-					executor.VersionCall.Returns.Version = "0.0.1"
+			DescribeTable("when terraform version is not supported",
+				func(version string) {
+					executor.VersionCall.Returns.Version = version
 
 					err := manager.ValidateVersion()
-					Expect(err).To(MatchError("Terraform version must be at least v0.11.0"))
-				})
-			})
+					Expect(err).To(MatchError("Terraform version must be between v0.11.0 and v0.11.x"))
+				},
+				Entry("older than the minimum", "0.0.1"),
+				Entry("a pre-release of the minimum", "0.11.0-rc1"),
+				Entry("the first unsupported version", "0.12.0"),
+				Entry("a pre-release of the first unsupported version", "0.12.0-rc1"),
+				Entry("a later version", "9.0.0"),
+			)
 
 			Context("when terraform executor fails to get the version", func() {
 				It("fast fails", func() {